@@ -25,24 +25,16 @@ package main
 		},
 	}
 
-	func prepareVertices(meshes []Mesh) []Vertex3 {
+	func prepareVertices(meshes []obj.Mesh) []Vertex3 {
 		var instances []Vertex3
 		for _, mesh := range meshes {
-			for _, tri := range mesh.Triangles {
-				instances = append(instances,
-					Vertex3{
-						pos:    tri.A.Extend(1),
-						normal: tri.Na,
-					},
-					Vertex3{
-						pos:    tri.B.Extend(1),
-						normal: tri.Nb,
-					},
-					Vertex3{
-						pos:    tri.C.Extend(1),
-						normal: tri.Nc,
-					},
-				)
+			for _, vertexIdx := range mesh.Indices {
+				vertex := mesh.Vertices[vertexIdx]
+
+				instances = append(instances, Vertex3{
+					pos:    vertex.Position.Extend(1),
+					normal: vertex.Normal,
+				})
 			}
 		}
 
@@ -92,12 +84,12 @@ var shader2d string
 		s = &Context{}
 		s.startTime = time.Now()
 
-		meshes, err := LoadMeshes(_scene_obj)
+		model, err := obj.Parse(strings.NewReader(_scene_obj), &obj.Options{Name: "scene.obj"})
 		if err != nil {
 			return s, fmt.Errorf("load meshes: %w", err)
 		}
 
-		instances := prepareVertices(meshes)
+		instances := prepareVertices(model.Meshes)
 		s.vertexCount = uint32(len(instances))
 
 		s.vertexBuf, err = s.device.TryCreateBufferInit(&wgpu.BufferInitDescriptor{
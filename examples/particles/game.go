@@ -59,7 +59,7 @@ func (g *TestGame) Initialize() error {
 		return fmt.Errorf("decode gopher texture: %w", err)
 	}
 
-	particle, err := orion.DecodeImageFromBytes(particleImage, nil)
+	particle, err := orion.DecodeImageFromBytes(particleImage, &orion.DecodeImageOptions{Mipmaps: true})
 	if err != nil {
 		return fmt.Errorf("decode particle texture: %w", err)
 	}
@@ -216,7 +216,7 @@ func NewParticleCommand(particlesIn []Particle) *ParticleCommand {
 	// staging buffer buffer
 	bufParticlesSprites := dev.CreateBuffer(&wgpu.BufferDescriptor{
 		Label: "Particles.Sprites",
-		Size:  uint64((4 + 2 + 2 + 3 + 3) * 4 * len(particles)),
+		Size:  uint64((4 + 3 + 3 + 4 + 4 + 1) * 4 * len(particles)),
 		Usage: wgpu.BufferUsageStorage | wgpu.BufferUsageVertex,
 	})
 
@@ -10,24 +10,12 @@ struct SpriteInstance {
     tr_row0: array<f32, 3>,
     tr_row1: array<f32, 3>,
 
-    source_rect: Rect,
-    target_rect: Rect,
-};
-
-struct Rect {
-    pos_packed: u32,
-    size_packed: u32,
-}
+    // x, y, width and height
+    source_rect: array<f32, 4>,
+    target_rect: array<u32, 4>,
 
-fn pack_rect(x: u32, y: u32, w: u32, h: u32) -> Rect {
-    let pos = (y << 16) | (x & 0xffff);
-    let size = (h << 16) | (w & 0xffff);
-
-    var rect: Rect;
-    rect.pos_packed = pos;
-    rect.size_packed = size;
-    return rect;
-}
+    texture_index: u32,
+};
 
 @group(0) @binding(0)
 var<storage, read_write> data: array<Particle>;
@@ -58,8 +46,9 @@ fn update_particles(
     data[i].color.a,
   );
 
-  sprites[i].source_rect = pack_rect(0, 0, 64, 64);
-  sprites[i].target_rect = pack_rect(0, 0, 0xffff, 0xffff);
+  sprites[i].source_rect = array(0.0, 0.0, 64.0, 64.0);
+  sprites[i].target_rect = array(0u, 0u, 0xffffu, 0xffffu);
+  sprites[i].texture_index = 0;
 
   let tr = transpose(data[i].transform);
   sprites[i].tr_row0[0] = tr[0].x;
@@ -90,3 +90,17 @@ func (m Mat4[T]) RotateY(angle Rad) Mat4[T] {
 func (m Mat4[T]) RotateZ(angle Rad) Mat4[T] {
 	return m.Mul(RotationZMat4[T](angle))
 }
+
+// ToWGPU returns the matrix in column major order, as expected by a mat4x4<f32> in WGSL.
+func (m Mat4[T]) ToWGPU() [16]float32 {
+	values := m.Values()
+
+	var result [16]float32
+	for col := range 4 {
+		for row := range 4 {
+			result[col*4+row] = float32(values[col][row])
+		}
+	}
+
+	return result
+}
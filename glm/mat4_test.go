@@ -0,0 +1,100 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+// transformWGPU multiplies the vector with the matrix in the
+// layout returned by ToWGPU, as a shader does with a mat4x4<f32>.
+func transformWGPU(m [16]float32, v Vec4f) Vec4f {
+	var result Vec4f
+	for col := range 4 {
+		for row := range 4 {
+			result[row] += m[col*4+row] * v[col]
+		}
+	}
+
+	return result
+}
+
+func TestMat4ToWGPU(t *testing.T) {
+	cases := []struct {
+		name     string
+		matrix   Mat4f
+		expected [16]float32
+	}{
+		{
+			name:     "identity",
+			matrix:   IdentityMat4[float32](),
+			expected: [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1},
+		},
+		{
+			name:     "translation in the last column",
+			matrix:   TranslationMat4[float32](2, 3, 4),
+			expected: [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 2, 3, 4, 1},
+		},
+		{
+			name:     "scale",
+			matrix:   ScaleMat4[float32](2, 3, 4),
+			expected: [16]float32{2, 0, 0, 0, 0, 3, 0, 0, 0, 0, 4, 0, 0, 0, 0, 1},
+		},
+		{
+			name: "column major order",
+			matrix: Mat4Of([4][4]float32{
+				{1, 2, 3, 4},
+				{5, 6, 7, 8},
+				{9, 10, 11, 12},
+				{13, 14, 15, 16},
+			}),
+			expected: [16]float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if values := tc.matrix.ToWGPU(); values != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, values)
+			}
+		})
+	}
+}
+
+func TestMat4ToWGPUPerspectiveLookAt(t *testing.T) {
+	// camera at z=5 looking at the origin, near plane at z=4, far plane at z=-5
+	projection := Perspective[float32](math.Pi/2, 1, 1, 10)
+	view := LookAt(Vec3f{0, 0, 5}, Vec3f{0, 0, 0}, Vec3f{0, 1, 0})
+
+	values := projection.Mul(view).ToWGPU()
+
+	cases := []struct {
+		name     string
+		point    Vec3f
+		expected Vec3f
+	}{
+		{name: "center of the near plane", point: Vec3f{0, 0, 4}, expected: Vec3f{0, 0, -1}},
+		{name: "center of the far plane", point: Vec3f{0, 0, -5}, expected: Vec3f{0, 0, 1}},
+		{name: "target", point: Vec3f{0, 0, 0}, expected: Vec3f{0, 0, 7.0 / 9}},
+		{name: "right edge of the near plane", point: Vec3f{1, 0, 4}, expected: Vec3f{1, 0, -1}},
+		{name: "top edge", point: Vec3f{0, 2, 3}, expected: Vec3f{0, 1, 1.0 / 9}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clip := transformWGPU(values, tc.point.Extend(1))
+
+			// perspective divide
+			ndc := Vec3f{clip[0] / clip[3], clip[1] / clip[3], clip[2] / clip[3]}
+
+			for idx := range 3 {
+				if !approxEqual(ndc[idx], tc.expected[idx]) {
+					t.Fatalf("expected %v, got %v", tc.expected, ndc)
+				}
+			}
+		})
+	}
+}
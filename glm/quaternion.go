@@ -25,3 +25,15 @@ func (lhs Quaternion[T]) Mul(rhs Quaternion[T]) Quaternion[T] {
 		},
 	}
 }
+
+// ToMat4 returns the rotation matrix of the quaternion. The quaternion must be normalized.
+func (q Quaternion[T]) ToMat4() Mat4[T] {
+	x, y, z, s := q.V[0], q.V[1], q.V[2], q.S
+
+	return Mat4Of([4][4]T{
+		{1 - 2*(y*y+z*z), 2 * (x*y + z*s), 2 * (x*z - y*s), 0},
+		{2 * (x*y - z*s), 1 - 2*(x*x+z*z), 2 * (y*z + x*s), 0},
+		{2 * (x*z + y*s), 2 * (y*z - x*s), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	})
+}
@@ -0,0 +1,70 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuaternionToMat4(t *testing.T) {
+	cases := []struct {
+		name       string
+		quaternion Quaternion[float32]
+		point      Vec3f
+		expected   Vec3f
+	}{
+		{
+			name:       "identity",
+			quaternion: Quaternion[float32]{S: 1},
+			point:      Vec3f{1, 2, 3},
+			expected:   Vec3f{1, 2, 3},
+		},
+		{
+			name:       "quarter turn around z",
+			quaternion: QuaternionFromAxisAngle(Vec3f{0, 0, 1}, math.Pi/2),
+			point:      Vec3f{1, 0, 0},
+			expected:   Vec3f{0, 1, 0},
+		},
+		{
+			name:       "quarter turn around x",
+			quaternion: QuaternionFromAxisAngle(Vec3f{1, 0, 0}, math.Pi/2),
+			point:      Vec3f{0, 1, 0},
+			expected:   Vec3f{0, 0, 1},
+		},
+		{
+			name:       "half turn around y",
+			quaternion: QuaternionFromAxisAngle(Vec3f{0, 1, 0}, math.Pi),
+			point:      Vec3f{1, 0, 2},
+			expected:   Vec3f{-1, 0, -2},
+		},
+		{
+			name:       "third turn around the diagonal",
+			quaternion: QuaternionFromAxisAngle(Vec3f{1, 1, 1}.Normalize(), 2*math.Pi/3),
+			point:      Vec3f{1, 0, 0},
+			expected:   Vec3f{0, 1, 0},
+		},
+		{
+			// the right hand side is applied first
+			name: "combined rotations",
+			quaternion: QuaternionFromAxisAngle(Vec3f{0, 0, 1}, math.Pi/2).
+				Mul(QuaternionFromAxisAngle(Vec3f{1, 0, 0}, math.Pi/2)),
+			point:    Vec3f{0, 1, 0},
+			expected: Vec3f{0, 0, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rotated := transformWGPU(tc.quaternion.ToMat4().ToWGPU(), tc.point.Extend(1))
+
+			for idx := range 3 {
+				if !approxEqual(rotated[idx], tc.expected[idx]) {
+					t.Fatalf("expected %v, got %v", tc.expected, rotated)
+				}
+			}
+
+			if !approxEqual(rotated[3], 1) {
+				t.Errorf("expected w to stay 1, got %v", rotated[3])
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ require (
 	github.com/oliverbestmann/webgpu v1.0.0
 	github.com/pkg/profile v1.7.0
 	golang.org/x/exp v0.0.0-20251113190631-e25ba8c21ef6
+	golang.org/x/image v0.33.0
 	golang.org/x/mobile v0.0.0-20251113184115-a159579294ab
 )
 
@@ -28,6 +29,7 @@ require (
 	golang.org/x/mod v0.30.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
 	golang.org/x/tools v0.39.0 // indirect
 )
 
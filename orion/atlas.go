@@ -0,0 +1,101 @@
+package orion
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+type NewAtlasOptions struct {
+	// Size of each page. Defaults to 2048x2048.
+	Width  uint32
+	Height uint32
+
+	// LinearRGBA stores the images as linear rgba instead of srgb,
+	// see DecodeImageOptions.
+	LinearRGBA bool
+
+	// Number of transparent pixels between images. See pulse.AtlasOptions.
+	Padding uint32
+
+	// Number of times the border pixels of each image are repeated to prevent
+	// bleeding when drawing with linear filtering. Defaults to 1, set to
+	// a negative value to disable extrusion.
+	Extrude int
+
+	// Helpful label for wgpu error messages
+	Label string
+}
+
+// Atlas packs images into a small number of shared textures. Images
+// from the same atlas page can be drawn without breaking the sprite batch.
+type Atlas struct {
+	atlas *pulse.Atlas
+}
+
+func NewAtlas(opts *NewAtlasOptions) *Atlas {
+	if opts == nil {
+		opts = &NewAtlasOptions{}
+	}
+
+	format := wgpu.TextureFormatRGBA8UnormSrgb
+	if opts.LinearRGBA {
+		format = wgpu.TextureFormatRGBA8Unorm
+	}
+
+	extrude := opts.Extrude
+	if extrude == 0 {
+		extrude = 1
+	}
+
+	atlas := pulse.NewAtlas(currentContext.Get(), pulse.AtlasOptions{
+		Width:   opts.Width,
+		Height:  opts.Height,
+		Format:  format,
+		Padding: opts.Padding,
+		Extrude: uint32(max(0, extrude)),
+		Label:   opts.Label,
+	})
+
+	return &Atlas{atlas: atlas}
+}
+
+// AddImage copies the image into the atlas. The returned Image is a
+// SubImage of one of the atlas pages.
+func (a *Atlas) AddImage(src image.Image) (*Image, error) {
+	texture, err := a.atlas.Add(src)
+	if err != nil {
+		return nil, fmt.Errorf("add image to atlas: %w", err)
+	}
+
+	return asImage(texture), nil
+}
+
+// DecodeImageFromBytes decodes the image and adds it to the atlas.
+func (a *Atlas) DecodeImageFromBytes(buf []byte) (*Image, error) {
+	src, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	return a.AddImage(src)
+}
+
+// Pages returns all pages of the atlas as images.
+func (a *Atlas) Pages() []*Image {
+	var images []*Image
+	for _, texture := range a.atlas.Pages() {
+		images = append(images, asImage(texture))
+	}
+
+	return images
+}
+
+// Release releases the pages of the atlas. Images added to
+// the atlas must not be used afterward.
+func (a *Atlas) Release() {
+	a.atlas.Release()
+}
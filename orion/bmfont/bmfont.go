@@ -0,0 +1,320 @@
+// Package bmfont loads AngelCode BMFont bitmap fonts from .fnt files in the text
+// or the binary format, together with their page images. A Font implements
+// orion.FontFace and can be used with orion.LayoutText.
+package bmfont
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io/fs"
+	"path"
+
+	_ "image/png"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+type Font struct {
+	// Name of the font face the font was generated from
+	Face string
+
+	// Size of the font face the font was generated from
+	Size int
+
+	// LineHeight is the distance between two lines, Base is the distance
+	// from the top of a line to the baseline.
+	LineHeight float32
+	Base       float32
+
+	Glyphs  map[rune]*Glyph
+	Kerning map[KerningPair]float32
+
+	// Pages of the font as loaded from the page images
+	Pages []*orion.Image
+
+	// FilterMode to draw glyphs with, defaults to nearest,
+	// as most bitmap fonts are pixel fonts.
+	FilterMode wgpu.FilterMode
+}
+
+type Glyph struct {
+	// Image of the glyph, a SubImage of its page. Nil if the glyph is empty.
+	Image *orion.Image
+
+	// Offset from the top left corner of the line to the top left corner of the image
+	Offset glm.Vec2f
+
+	// Advance is the distance to the next glyph
+	Advance float32
+}
+
+type KerningPair struct {
+	First, Second rune
+}
+
+// Load loads a .fnt file and the page images it references. Page images
+// are loaded relative to the file from the same file system.
+func Load(fsys fs.FS, name string) (*Font, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	font, err := Parse(data, &Options{FS: fsys, Dir: path.Dir(name)})
+	if err != nil {
+		return nil, fmt.Errorf("load %q: %w", name, err)
+	}
+
+	return font, nil
+}
+
+type Options struct {
+	// FS to load the page images from
+	FS fs.FS
+
+	// Dir is the directory of the fnt file within FS
+	Dir string
+}
+
+// Parse parses a .fnt file in the text or in the binary format. The page images are
+// decoded and uploaded to the gpu, this requires an active orion context.
+func Parse(data []byte, opts *Options) (*Font, error) {
+	if opts == nil || opts.FS == nil {
+		return nil, errors.New("no file system to load page images from")
+	}
+
+	var desc *description
+	var err error
+
+	switch {
+	case bytes.HasPrefix(data, []byte("BMF")):
+		desc, err = parseBinary(data)
+
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")):
+		err = errors.New("xml format is not supported")
+
+	default:
+		desc, err = parseText(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return build(desc, opts)
+}
+
+// description is the content of a fnt file, independent of its format.
+type description struct {
+	face string
+	size int
+
+	lineHeight int
+	base       int
+
+	// set if each color channel of the pages holds different glyphs
+	packed bool
+
+	pages    []string
+	chars    []charDescription
+	kernings []kerningDescription
+}
+
+type charDescription struct {
+	id                         int
+	x, y, width, height        int
+	xOffset, yOffset, xAdvance int
+	page                       int
+
+	// bit mask of the channels that contain the glyph:
+	// 1 = blue, 2 = green, 4 = red, 8 = alpha
+	channel int
+}
+
+type kerningDescription struct {
+	first, second int
+	amount        int
+}
+
+func build(desc *description, opts *Options) (*Font, error) {
+	font := &Font{
+		Face:       desc.face,
+		Size:       desc.size,
+		LineHeight: float32(desc.lineHeight),
+		Base:       float32(desc.base),
+		Glyphs:     map[rune]*Glyph{},
+		Kerning:    map[KerningPair]float32{},
+		FilterMode: wgpu.FilterModeNearest,
+	}
+
+	var pages []image.Image
+
+	for idx, name := range desc.pages {
+		data, err := fs.ReadFile(opts.FS, path.Join(opts.Dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", idx, err)
+		}
+
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: decode %q: %w", idx, name, err)
+		}
+
+		pages = append(pages, decoded)
+
+		texture := pulse.NewTextureFromImage(orion.CurrentContext(), decoded, true)
+		font.Pages = append(font.Pages, orion.NewImageFromTexture(texture))
+	}
+
+	// pages holding a single channel of a packed page, by page and channel mask
+	channelPages := map[[2]int]*orion.Image{}
+
+	pageOf := func(page, channel int) *orion.Image {
+		if !desc.packed || channel == 0 || channel == 15 {
+			return font.Pages[page]
+		}
+
+		key := [2]int{page, channel}
+		if cached, ok := channelPages[key]; ok {
+			return cached
+		}
+
+		texture := pulse.NewTextureFromImage(orion.CurrentContext(), extractChannel(pages[page], channel), true)
+
+		result := orion.NewImageFromTexture(texture)
+		channelPages[key] = result
+
+		return result
+	}
+
+	for _, char := range desc.chars {
+		glyph := &Glyph{
+			Offset:  glm.Vec2f{float32(char.xOffset), float32(char.yOffset)},
+			Advance: float32(char.xAdvance),
+		}
+
+		if char.width > 0 && char.height > 0 {
+			if char.page < 0 || char.page >= len(font.Pages) {
+				return nil, fmt.Errorf("char %d: page %d does not exist", char.id, char.page)
+			}
+
+			bounds := pages[char.page].Bounds()
+			if char.x < 0 || char.y < 0 || char.x+char.width > bounds.Dx() || char.y+char.height > bounds.Dy() {
+				return nil, fmt.Errorf("char %d: exceeds page %d", char.id, char.page)
+			}
+
+			glyph.Image = pageOf(char.page, char.channel).SubImage(
+				uint32(char.x), uint32(char.y),
+				uint32(char.width), uint32(char.height),
+			)
+		}
+
+		// id -1 is the glyph for characters that are not part of the font
+		font.Glyphs[rune(char.id)] = glyph
+	}
+
+	for _, kerning := range desc.kernings {
+		pair := KerningPair{First: rune(kerning.first), Second: rune(kerning.second)}
+		font.Kerning[pair] = float32(kerning.amount)
+	}
+
+	return font, nil
+}
+
+// extractChannel copies one channel of a packed page into the alpha channel of a white image.
+func extractChannel(src image.Image, channel int) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			// the channels are independent of each other, they must not be premultiplied
+			c := color.NRGBAModel.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+
+			var value uint8
+			switch channel {
+			case 1:
+				value = c.B
+			case 2:
+				value = c.G
+			case 4:
+				value = c.R
+			case 8:
+				value = c.A
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset+0] = 0xff
+			dst.Pix[offset+1] = 0xff
+			dst.Pix[offset+2] = 0xff
+			dst.Pix[offset+3] = value
+		}
+	}
+
+	return dst
+}
+
+// glyph returns the glyph for the rune, the glyph for missing characters,
+// or a question mark. Returns nil if neither exists.
+func (f *Font) glyph(ch rune) *Glyph {
+	if glyph, ok := f.Glyphs[ch]; ok {
+		return glyph
+	}
+
+	if glyph, ok := f.Glyphs[-1]; ok {
+		return glyph
+	}
+
+	return f.Glyphs['?']
+}
+
+func (f *Font) Metrics() orion.FontMetrics {
+	return orion.FontMetrics{
+		Ascent:     f.Base,
+		Descent:    f.LineHeight - f.Base,
+		LineHeight: f.LineHeight,
+	}
+}
+
+func (f *Font) Advance(ch rune) float32 {
+	if glyph := f.glyph(ch); glyph != nil {
+		return glyph.Advance
+	}
+
+	return 0
+}
+
+func (f *Font) Kern(prev, ch rune) float32 {
+	return f.Kerning[KerningPair{First: prev, Second: ch}]
+}
+
+func (f *Font) DrawGlyph(target *orion.Image, ch rune, origin glm.Vec2f, opts *orion.DrawImageOptions) {
+	glyph := f.glyph(ch)
+	if glyph == nil || glyph.Image == nil {
+		return
+	}
+
+	glyphOpts := *opts
+	glyphOpts.FilterMode = f.FilterMode
+
+	// offsets are relative to the top of the line
+	glyphOpts.Transform = opts.Transform.Translate(
+		origin[0]+glyph.Offset[0],
+		origin[1]-f.Base+glyph.Offset[1],
+	)
+
+	target.DrawImage(glyph.Image, &glyphOpts)
+}
+
+// DrawText lays out the text using the font and draws it, see orion.LayoutText.
+// Use orion.LayoutText directly for wrapping, alignment and markup.
+func (f *Font) DrawText(target *orion.Image, text string, opts *orion.DrawTextLayoutOptions) {
+	layout := orion.LayoutText(text, &orion.LayoutTextOptions{Face: f})
+	target.DrawTextLayout(layout, opts)
+}
@@ -0,0 +1,233 @@
+package bmfont
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// expected description of the fonts used by the format tests
+var exampleDescription = &description{
+	face:       "Arial Bold",
+	size:       32,
+	lineHeight: 36,
+	base:       29,
+	packed:     true,
+	pages:      []string{"arial_0.png", "arial_1.png"},
+	chars: []charDescription{
+		{id: 65, x: 10, y: 20, width: 18, height: 22, xOffset: -1, yOffset: 7, xAdvance: 17, page: 1, channel: 4},
+		{id: -1, x: 0, y: 0, width: 3, height: 3, xOffset: 0, yOffset: 0, xAdvance: 8, page: 0, channel: 15},
+	},
+	kernings: []kerningDescription{
+		{first: 65, second: 86, amount: -2},
+	},
+}
+
+const exampleText = `info face="Arial Bold" size=-32 bold=1 italic=0 charset="" unicode=1 padding=0,0,0,0
+common lineHeight=36 base=29 scaleW=256 scaleH=256 pages=2 packed=1
+page id=0 file="arial_0.png"
+page id=1 file="arial_1.png"
+chars count=2
+char id=65   x=10   y=20   width=18   height=22   xoffset=-1   yoffset=7    xadvance=17   page=1  chnl=4
+char id=-1 x=0 y=0 width=3 height=3 xoffset=0 yoffset=0 xadvance=8 page=0 chnl=15
+kernings count=1
+kerning first=65 second=86 amount=-2
+`
+
+func TestParseText(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected *description
+		err      bool
+	}{
+		{name: "example", input: exampleText, expected: exampleDescription},
+		{
+			name:     "not packed",
+			input:    "common lineHeight=10 base=8 packed=0",
+			expected: &description{lineHeight: 10, base: 8},
+		},
+		{
+			name:     "pages out of order",
+			input:    "common lineHeight=10\npage id=1 file=\"b.png\"\npage id=0 file=a.png",
+			expected: &description{lineHeight: 10, pages: []string{"a.png", "b.png"}},
+		},
+		{name: "empty file", input: "", err: true},
+		{name: "not a bmfont file", input: "hello world", err: true},
+		{name: "invalid number", input: "common lineHeight=abc", err: true},
+		{name: "missing value", input: "common lineHeight", err: true},
+		{name: "unterminated string", input: `info face="Arial`, err: true},
+		{name: "invalid page id", input: "common lineHeight=10\npage id=-1 file=a.png", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			desc, err := parseText([]byte(tc.input))
+
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(desc, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, desc)
+			}
+		})
+	}
+}
+
+// binaryBlock encodes a block of the binary format
+func binaryBlock(blockType byte, values ...any) []byte {
+	var content []byte
+
+	for _, value := range values {
+		content, _ = binary.Append(content, binary.LittleEndian, value)
+	}
+
+	block := []byte{blockType}
+	block = binary.LittleEndian.AppendUint32(block, uint32(len(content)))
+
+	return append(block, content...)
+}
+
+// binaryFont encodes a font in the binary format with the given
+// bit field in the common block.
+func binaryFont(commonBits uint8) []byte {
+	data := []byte("BMF\x03")
+
+	// size, bit field, charset, stretchH, aa, padding, spacing, outline and the face
+	data = append(data, binaryBlock(blockInfo,
+		int16(-32), uint8(0), uint8(0), uint16(100), uint8(1),
+		[4]uint8{}, [2]uint8{}, uint8(0), []byte("Arial Bold\x00"))...)
+
+	// lineHeight, base, scaleW, scaleH, pages, bit field and the channel contents
+	data = append(data, binaryBlock(blockCommon,
+		uint16(36), uint16(29), uint16(256), uint16(256), uint16(2),
+		commonBits, [4]uint8{})...)
+
+	data = append(data, binaryBlock(blockPages, []byte("arial_0.png\x00arial_1.png\x00"))...)
+
+	data = append(data, binaryBlock(blockChars,
+		int32(65), uint16(10), uint16(20), uint16(18), uint16(22), int16(-1), int16(7), int16(17), uint8(1), uint8(4),
+		int32(-1), uint16(0), uint16(0), uint16(3), uint16(3), int16(0), int16(0), int16(8), uint8(0), uint8(15),
+	)...)
+
+	data = append(data, binaryBlock(blockKernings, int32(65), int32(86), int16(-2))...)
+
+	return data
+}
+
+func TestParseBinary(t *testing.T) {
+	notPacked := *exampleDescription
+	notPacked.packed = false
+
+	cases := []struct {
+		name     string
+		input    []byte
+		expected *description
+		err      bool
+	}{
+		{name: "example", input: binaryFont(0x01), expected: exampleDescription},
+		{name: "packed flag is the lowest bit", input: binaryFont(0x81), expected: exampleDescription},
+		{name: "highest bit is not the packed flag", input: binaryFont(0x80), expected: &notPacked},
+		{name: "unknown blocks are skipped", input: append(binaryFont(0x01), binaryBlock(9, uint32(1))...), expected: exampleDescription},
+		{name: "unsupported version", input: []byte("BMF\x02"), err: true},
+		{name: "truncated block header", input: []byte("BMF\x03\x01\x00"), err: true},
+		{name: "truncated block", input: append([]byte("BMF\x03"), binaryBlock(blockInfo, [14]uint8{})[:10]...), err: true},
+		{name: "truncated info block", input: append([]byte("BMF\x03"), binaryBlock(blockInfo, [13]uint8{})...), err: true},
+		{name: "truncated common block", input: append([]byte("BMF\x03"), binaryBlock(blockCommon, [14]uint8{})...), err: true},
+		{name: "unterminated page name", input: append([]byte("BMF\x03"), binaryBlock(blockPages, []byte("a.png"))...), err: true},
+		{name: "invalid chars size", input: append([]byte("BMF\x03"), binaryBlock(blockChars, [21]uint8{})...), err: true},
+		{name: "invalid kernings size", input: append([]byte("BMF\x03"), binaryBlock(blockKernings, [11]uint8{})...), err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			desc, err := parseBinary(tc.input)
+
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(desc, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, desc)
+			}
+		})
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		opts  *Options
+	}{
+		{name: "without file system", input: exampleText},
+		{name: "xml format", input: "  <?xml version=\"1.0\"?>\n<font></font>", opts: &Options{FS: fstest.MapFS{}}},
+		{name: "missing page", input: exampleText, opts: &Options{FS: fstest.MapFS{}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tc.input), tc.opts); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestExtractChannel(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	src.SetNRGBA(2, 1, color.NRGBA{R: 10, G: 20, B: 30, A: 40})
+
+	// extract from a sub image to check that the bounds are respected
+	sub := src.SubImage(image.Rect(1, 1, 3, 2))
+
+	cases := []struct {
+		name     string
+		channel  int
+		expected uint8
+	}{
+		{name: "blue", channel: 1, expected: 30},
+		{name: "green", channel: 2, expected: 20},
+		{name: "red", channel: 4, expected: 10},
+		{name: "alpha", channel: 8, expected: 40},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := extractChannel(sub, tc.channel)
+
+			if dst.Bounds() != image.Rect(0, 0, 2, 1) {
+				t.Fatalf("unexpected bounds %v", dst.Bounds())
+			}
+
+			expected := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: tc.expected}
+			if c := dst.NRGBAAt(1, 0); c != expected {
+				t.Errorf("expected %v, got %v", expected, c)
+			}
+
+			if c := dst.NRGBAAt(0, 0); c.A != 0 {
+				t.Errorf("expected a transparent pixel, got %v", c)
+			}
+		})
+	}
+}
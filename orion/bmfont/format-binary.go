@@ -0,0 +1,130 @@
+package bmfont
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// block types of the binary format
+const (
+	blockInfo     = 1
+	blockCommon   = 2
+	blockPages    = 3
+	blockChars    = 4
+	blockKernings = 5
+)
+
+// parseBinary parses the binary format in version 3. The file starts with
+// "BMF" and a version byte, followed by blocks of a type byte and a size.
+func parseBinary(data []byte) (*description, error) {
+	if len(data) < 4 || data[3] != 3 {
+		return nil, errors.New("unsupported binary format version")
+	}
+
+	desc := &description{}
+
+	rest := data[4:]
+
+	for len(rest) > 0 {
+		if len(rest) < 5 {
+			return nil, errors.New("truncated block header")
+		}
+
+		blockType := rest[0]
+		size := int(binary.LittleEndian.Uint32(rest[1:5]))
+		rest = rest[5:]
+
+		if size < 0 || size > len(rest) {
+			return nil, fmt.Errorf("block %d: truncated", blockType)
+		}
+
+		if err := desc.applyBinary(blockType, rest[:size]); err != nil {
+			return nil, fmt.Errorf("block %d: %w", blockType, err)
+		}
+
+		rest = rest[size:]
+	}
+
+	return desc, nil
+}
+
+func (desc *description) applyBinary(blockType byte, block []byte) error {
+	le := binary.LittleEndian
+
+	switch blockType {
+	case blockInfo:
+		if len(block) < 14 {
+			return errors.New("truncated")
+		}
+
+		size := int(int16(le.Uint16(block[0:])))
+		desc.size = max(size, -size)
+
+		name, _, _ := bytes.Cut(block[14:], []byte{0})
+		desc.face = string(name)
+
+	case blockCommon:
+		if len(block) < 15 {
+			return errors.New("truncated")
+		}
+
+		desc.lineHeight = int(le.Uint16(block[0:]))
+		desc.base = int(le.Uint16(block[2:]))
+
+		// the spec calls it bit 7 counting from the most significant bit,
+		// bmfont writes the packed flag into the lowest bit of the bit field
+		desc.packed = block[10]&0x01 != 0
+
+	case blockPages:
+		for len(block) > 0 {
+			name, rest, ok := bytes.Cut(block, []byte{0})
+			if !ok {
+				return errors.New("page name not terminated")
+			}
+
+			desc.pages = append(desc.pages, string(name))
+			block = rest
+		}
+
+	case blockChars:
+		const charSize = 20
+
+		if len(block)%charSize != 0 {
+			return errors.New("invalid size")
+		}
+
+		for ; len(block) > 0; block = block[charSize:] {
+			desc.chars = append(desc.chars, charDescription{
+				id:       int(int32(le.Uint32(block[0:]))),
+				x:        int(le.Uint16(block[4:])),
+				y:        int(le.Uint16(block[6:])),
+				width:    int(le.Uint16(block[8:])),
+				height:   int(le.Uint16(block[10:])),
+				xOffset:  int(int16(le.Uint16(block[12:]))),
+				yOffset:  int(int16(le.Uint16(block[14:]))),
+				xAdvance: int(int16(le.Uint16(block[16:]))),
+				page:     int(block[18]),
+				channel:  int(block[19]),
+			})
+		}
+
+	case blockKernings:
+		const kerningSize = 10
+
+		if len(block)%kerningSize != 0 {
+			return errors.New("invalid size")
+		}
+
+		for ; len(block) > 0; block = block[kerningSize:] {
+			desc.kernings = append(desc.kernings, kerningDescription{
+				first:  int(int32(le.Uint32(block[0:]))),
+				second: int(int32(le.Uint32(block[4:]))),
+				amount: int(int16(le.Uint16(block[8:]))),
+			})
+		}
+	}
+
+	return nil
+}
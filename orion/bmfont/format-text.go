@@ -0,0 +1,184 @@
+package bmfont
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseText parses the text format. Each line starts with a tag,
+// followed by key=value pairs. Values might be quoted.
+func parseText(data []byte) (*description, error) {
+	desc := &description{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var lineNo int
+	for scanner.Scan() {
+		lineNo += 1
+
+		tag, attrs, err := parseTextLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if err := desc.applyText(tag, attrs); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, tag, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if desc.lineHeight == 0 && len(desc.chars) == 0 {
+		return nil, fmt.Errorf("not a bmfont file")
+	}
+
+	return desc, nil
+}
+
+type textAttributes map[string]string
+
+func (a textAttributes) int(key string) (int, error) {
+	value, ok := a[key]
+	if !ok {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("attribute %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// ints reads multiple integer attributes, stopping at the first error
+func (a textAttributes) ints(keys []string, targets ...*int) error {
+	for idx, key := range keys {
+		value, err := a.int(key)
+		if err != nil {
+			return err
+		}
+
+		*targets[idx] = value
+	}
+
+	return nil
+}
+
+func (desc *description) applyText(tag string, attrs textAttributes) error {
+	switch tag {
+	case "info":
+		desc.face = attrs["face"]
+
+		size, err := attrs.int("size")
+		if err != nil {
+			return err
+		}
+
+		// a negative size indicates that the size matches the character height
+		desc.size = max(size, -size)
+
+	case "common":
+		var packed int
+
+		err := attrs.ints(
+			[]string{"lineHeight", "base", "packed"},
+			&desc.lineHeight, &desc.base, &packed,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		desc.packed = packed != 0
+
+	case "page":
+		id, err := attrs.int("id")
+		if err != nil {
+			return err
+		}
+
+		if id < 0 || id > 1024 {
+			return fmt.Errorf("invalid page id %d", id)
+		}
+
+		for len(desc.pages) <= id {
+			desc.pages = append(desc.pages, "")
+		}
+
+		desc.pages[id] = attrs["file"]
+
+	case "char":
+		var char charDescription
+
+		err := attrs.ints(
+			[]string{"id", "x", "y", "width", "height", "xoffset", "yoffset", "xadvance", "page", "chnl"},
+			&char.id, &char.x, &char.y, &char.width, &char.height,
+			&char.xOffset, &char.yOffset, &char.xAdvance, &char.page, &char.channel,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		desc.chars = append(desc.chars, char)
+
+	case "kerning":
+		var kerning kerningDescription
+
+		err := attrs.ints(
+			[]string{"first", "second", "amount"},
+			&kerning.first, &kerning.second, &kerning.amount,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		desc.kernings = append(desc.kernings, kerning)
+	}
+
+	// other tags like "chars" or "kernings" only contain counts
+	return nil
+}
+
+func parseTextLine(line string) (string, textAttributes, error) {
+	line = strings.TrimSpace(line)
+
+	tag, rest, _ := strings.Cut(line, " ")
+
+	attrs := textAttributes{}
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok || strings.ContainsAny(key, " \t") {
+			return "", nil, fmt.Errorf("expected key=value, got %q", rest)
+		}
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1:], '"')
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated string in attribute %s", key)
+			}
+
+			attrs[key] = value[1 : end+1]
+			rest = value[end+2:]
+			continue
+		}
+
+		value, rest, _ = strings.Cut(value, " ")
+		attrs[key] = value
+	}
+
+	return tag, attrs, nil
+}
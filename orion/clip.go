@@ -0,0 +1,181 @@
+package orion
+
+import (
+	"math"
+
+	"github.com/oliverbestmann/earcut-go"
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+// maximum number of nested clip paths, the stencil holds values up to 255
+// and drawing lines needs one value above the innermost clip.
+const maxClipPathDepth = 254
+
+// ClipPath is a closed shape to clip drawing to, e.g. a *vector.Path.
+type ClipPath interface {
+	// Contour returns the outline of the shape as a polygon. Curves are flattened
+	// with a precision of unitScale in the coordinate system of the shape.
+	Contour(unitScale float32) []glm.Vec2f
+}
+
+type ClipPathOptions struct {
+	// Transform to apply to the path
+	Transform glm.Mat3f
+}
+
+// clipEntry is an entry on the clip stack of a root texture
+type clipEntry struct {
+	// clip of the root texture before the entry was pushed
+	previous pulse.Clip
+
+	// texture the triangles were written for and the triangles of the
+	// clip path in pixels of the root texture. Empty for a clip rect.
+	target    *pulse.Texture
+	triangles []glm.Vec2f
+}
+
+// clip stacks by root texture
+var clipStacks global[map[*pulse.Texture][]clipEntry]
+
+// PushClipRect restricts drawing to the intersection of the rectangle and the
+// current clip until the clip is removed using PopClip. The rectangle is given in
+// pixels of this image and is extended to full pixels.
+//
+// The clip applies to all images sharing the texture of this image, e.g. other
+// sub images. Drawing sprites, meshes and vector paths honors the clip. Clearing
+// a clipped image only clears the pixels within the clip.
+func (i *Image) PushClipRect(rect pulse.Rectangle2f) {
+	SwitchToCommand(clipCommand.Get())
+
+	root := i.texture.Root()
+	clip := root.Clip()
+
+	i.pushClipEntry(clipEntry{previous: clip})
+
+	// convert to pixels of the root texture
+	rect = rect.Translate(i.texture.Offset().ToVec2f())
+
+	rw, rh := root.Size().ToVec2f().XY()
+
+	scissor := pulse.Rectangle2u{
+		Min: glm.Vec2u{
+			uint32(min(rw, max(0, float32(math.Floor(float64(rect.Min[0])))))),
+			uint32(min(rh, max(0, float32(math.Floor(float64(rect.Min[1])))))),
+		},
+		Max: glm.Vec2u{
+			uint32(min(rw, max(0, float32(math.Ceil(float64(rect.Max[0])))))),
+			uint32(min(rh, max(0, float32(math.Ceil(float64(rect.Max[1])))))),
+		},
+	}
+
+	// limit to the image and the current clip
+	scissor = scissor.Intersection(i.texture.ScissorRect())
+
+	clip.Scissor = &scissor
+	root.SetClip(clip)
+}
+
+// PushClipPath restricts drawing to the intersection of the path and the current
+// clip until the clip is removed using PopClip. The path is given in pixels of this
+// image. It is triangulated and written to the stencil of the image's depth
+// texture, up to 254 clip paths can be nested.
+//
+// The same rules as for PushClipRect apply. Prefer PushClipRect for axis aligned
+// rectangles, as it does not require the stencil.
+func (i *Image) PushClipPath(path ClipPath, opts *ClipPathOptions) {
+	if opts == nil {
+		opts = &ClipPathOptions{}
+	}
+
+	cmd := clipCommand.Get()
+	SwitchToCommand(cmd)
+
+	root := i.texture.Root()
+	clip := root.Clip()
+
+	if clip.StencilReference >= maxClipPathDepth {
+		panic("too many nested clip paths")
+	}
+
+	// transform into pixels of the root texture
+	transform := glm.TranslationMat3(i.texture.Offset().ToVec2f().XY()).Mul(opts.Transform)
+
+	triangles := triangulateClipPath(path, transform)
+
+	i.pushClipEntry(clipEntry{
+		previous:  clip,
+		target:    i.texture,
+		triangles: triangles,
+	})
+
+	// mark the pixels within the current clip and the path with the next stencil value
+	cmd.Increment(i.texture, triangles, clip.StencilReference)
+
+	clip.StencilReference += 1
+	root.SetClip(clip)
+}
+
+// PopClip removes the clip most recently pushed using PushClipRect or
+// PushClipPath to any image sharing the texture of this image.
+func (i *Image) PopClip() {
+	root := i.texture.Root()
+
+	stacks := clipStacks.Get()
+	stack := stacks[root]
+
+	if len(stack) == 0 {
+		panic("PopClip called without PushClipRect or PushClipPath")
+	}
+
+	entry := stack[len(stack)-1]
+
+	cmd := clipCommand.Get()
+	SwitchToCommand(cmd)
+
+	if len(entry.triangles) > 0 {
+		// revert the stencil values written by PushClipPath
+		cmd.Decrement(entry.target, entry.triangles, root.Clip().StencilReference)
+	}
+
+	root.SetClip(entry.previous)
+
+	if len(stack) == 1 {
+		delete(stacks, root)
+	} else {
+		stacks[root] = stack[:len(stack)-1]
+	}
+}
+
+func (i *Image) pushClipEntry(entry clipEntry) {
+	root := i.texture.Root()
+
+	stacks := clipStacks.Get()
+	stacks[root] = append(stacks[root], entry)
+}
+
+// triangulateClipPath triangulates the contour of the path and
+// transforms the triangles using the given transform.
+func triangulateClipPath(path ClipPath, transform glm.Mat3f) []glm.Vec2f {
+	// flatten curves with a precision of half a pixel
+	a := transform.Transform2(glm.Vec2f{1, 0})
+	b := transform.Transform2(glm.Vec2f{0, 0})
+	unitScale := max(0.1, 0.5/a.Sub(b).Length())
+
+	contour := path.Contour(unitScale)
+
+	points := make([]earcut.Point[float32], len(contour))
+	for idx, point := range contour {
+		points[idx] = earcut.Point[float32]{X: point[0], Y: point[1]}
+	}
+
+	points, indices := earcut.Triangulate(points, nil)
+
+	triangles := make([]glm.Vec2f, len(indices))
+	for idx, pointIdx := range indices {
+		point := points[pointIdx]
+		triangles[idx] = transform.Transform2(glm.Vec2f{point.X, point.Y})
+	}
+
+	return triangles
+}
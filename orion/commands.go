@@ -8,7 +8,11 @@ import (
 var clearCommand global[*commands.ClearCommand]
 var spriteCommand global[*commands.SpriteCommand]
 var mesh2dCommand global[*commands.Mesh2dCommand]
+var mesh3dCommand global[*commands.Mesh3dCommand]
 var textCommand global[*commands.DebugTextCommand]
+var clipCommand global[*commands.ClipCommand]
+
+var texturePool global[*pulse.TexturePool]
 
 func initializeCommands(ctx *pulse.Context) {
 	sprite := commands.NewSpriteCommand(ctx)
@@ -19,8 +23,39 @@ func initializeCommands(ctx *pulse.Context) {
 	mesh2d := commands.NewMesh2dCommand(ctx)
 	mesh2dCommand.set(mesh2d)
 
+	mesh3dCommand.set(commands.NewMesh3dCommand(ctx))
+
 	text := commands.NewDebugTextCommand(ctx, sprite)
 	textCommand.set(text)
+
+	clipCommand.set(commands.NewClipCommand(ctx))
+	clipStacks.set(map[*pulse.Texture][]clipEntry{})
+
+	pool := pulse.NewTexturePool(ctx)
+
+	pool.OnRecycle = func(texture *pulse.Texture) {
+		// clips pushed to a temporary image end with the frame
+		delete(clipStacks.Get(), texture)
+	}
+
+	texturePool.set(pool)
+}
+
+// resetCommands drops all commands, e.g. after their
+// device was lost. Call initializeCommands to recreate them.
+func resetCommands() {
+	currentCommand = nil
+
+	layered = layeredCommand{}
+
+	spriteCommand.reset()
+	clearCommand.reset()
+	mesh2dCommand.reset()
+	mesh3dCommand.reset()
+	textCommand.reset()
+	clipCommand.reset()
+	clipStacks.reset()
+	texturePool.reset()
 }
 
 type Command interface {
@@ -263,5 +263,17 @@ func (d *debugOverlay) buildText() string {
 		fmt.Sprintf("  Alive:    %d", d.objectsAliveAfterGC),
 	}
 
+	if texturePool.hasValue {
+		stats := texturePool.Get().Stats()
+
+		lines = append(lines,
+			fmt.Sprintf(""),
+			fmt.Sprintf("Texture Pool:"),
+			fmt.Sprintf("  Textures: %d", stats.Textures),
+			fmt.Sprintf("  InUse:    %d", stats.InUse),
+			fmt.Sprintf("  Memory:   %1.2fmb", float64(stats.Bytes)/(1024.0*1024.0)),
+		)
+	}
+
 	return strings.Join(lines, "\n")
 }
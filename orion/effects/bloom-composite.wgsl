@@ -0,0 +1,13 @@
+struct Params {
+    intensity: f32,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+@group(1) @binding(1) var bloom_sampler: sampler;
+@group(1) @binding(2) var bloom: texture_2d<f32>;
+
+fn effect(uv: vec2f) -> vec4f {
+    let color = sample_source(uv);
+    let glow = textureSampleLevel(bloom, bloom_sampler, uv, 0.0);
+    return vec4f(color.rgb + glow.rgb * params.intensity, color.a);
+}
@@ -0,0 +1,15 @@
+struct Params {
+    threshold: f32,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+
+fn effect(uv: vec2f) -> vec4f {
+    let color = sample_source(uv);
+
+    let luminance = dot(color.rgb, vec3f(0.2126, 0.7152, 0.0722));
+
+    // keep only the part of the color above the threshold
+    let factor = max(luminance - params.threshold, 0.0) / max(luminance, 0.0001);
+    return vec4f(color.rgb * factor, 1.0);
+}
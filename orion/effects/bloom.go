@@ -0,0 +1,74 @@
+package effects
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+//go:embed bloom-threshold.wgsl
+var bloomThresholdShader string
+
+//go:embed bloom-composite.wgsl
+var bloomCompositeShader string
+
+type bloomThresholdParams struct {
+	Threshold float32
+}
+
+type bloomCompositeParams struct {
+	Intensity float32
+}
+
+// BloomEffect lets bright parts of the image glow. The bright parts are extracted
+// and blurred at half resolution, then added on top of the image.
+type BloomEffect struct {
+	// Luminance above which pixels start to glow
+	Threshold float32
+
+	// Factor applied to the glow before adding it to the image
+	Intensity float32
+
+	// Radius of the glow in pixels of the downscaled image
+	Radius float32
+
+	threshold *orion.ShaderEffect
+	blur      *GaussianBlurEffect
+	composite *orion.ShaderEffect
+}
+
+func NewBloomEffect(threshold, intensity, radius float32) *BloomEffect {
+	return &BloomEffect{
+		Threshold: threshold,
+		Intensity: intensity,
+		Radius:    radius,
+
+		threshold: orion.NewShaderEffect(bloomThresholdShader, nil),
+		blur:      NewGaussianBlurEffect(radius),
+		composite: orion.NewShaderEffect(bloomCompositeShader, nil),
+	}
+}
+
+func (e *BloomEffect) Apply(target, source *orion.Image) {
+	opts := &orion.NewImageOptions{Format: source.Format()}
+
+	width := max(source.Width()/2, 1)
+	height := max(source.Height()/2, 1)
+
+	bright := orion.AcquireTempImage(width, height, opts)
+	orion.SetMaterialUniforms(e.threshold.Material(), bloomThresholdParams{Threshold: e.Threshold})
+	e.threshold.Apply(bright, source)
+
+	glow := orion.AcquireTempImage(width, height, opts)
+	e.blur.Radius = e.Radius
+	e.blur.Apply(glow, bright)
+
+	material := e.composite.Material()
+	if err := material.SetImage(0, glow); err != nil {
+		orion.CurrentContext().ReportError(err)
+		return
+	}
+
+	orion.SetMaterialUniforms(material, bloomCompositeParams{Intensity: e.Intensity})
+	e.composite.Apply(target, source)
+}
@@ -0,0 +1,45 @@
+// Package effects contains post processing effects to use with an orion.PostProcessChain.
+package effects
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+//go:embed blur.wgsl
+var blurShader string
+
+type blurParams struct {
+	DirectionX float32
+	DirectionY float32
+	Radius     float32
+}
+
+// GaussianBlurEffect blurs the image in two passes, first horizontally, then vertically.
+type GaussianBlurEffect struct {
+	// Radius of the blur in pixels
+	Radius float32
+
+	shader *orion.ShaderEffect
+}
+
+func NewGaussianBlurEffect(radius float32) *GaussianBlurEffect {
+	return &GaussianBlurEffect{
+		Radius: radius,
+		shader: orion.NewShaderEffect(blurShader, nil),
+	}
+}
+
+func (e *GaussianBlurEffect) Apply(target, source *orion.Image) {
+	tmp := orion.AcquireTempImage(source.Width(), source.Height(), &orion.NewImageOptions{
+		Format: source.Format(),
+	})
+
+	// setting the uniforms flushes the first pass, so both passes see their own direction
+	orion.SetMaterialUniforms(e.shader.Material(), blurParams{DirectionX: 1, Radius: e.Radius})
+	e.shader.Apply(tmp, source)
+
+	orion.SetMaterialUniforms(e.shader.Material(), blurParams{DirectionY: 1, Radius: e.Radius})
+	e.shader.Apply(target, tmp)
+}
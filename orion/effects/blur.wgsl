@@ -0,0 +1,38 @@
+struct Params {
+    // direction of the blur in pixels, either (1, 0) or (0, 1)
+    direction_x: f32,
+    direction_y: f32,
+    radius: f32,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+
+// upper bound of samples to take on each side of the center pixel
+const MAX_TAPS: f32 = 16.0;
+
+fn effect(uv: vec2f) -> vec4f {
+    let radius = max(params.radius, 0.0);
+    if radius < 0.5 {
+        return sample_source(uv);
+    }
+
+    // for large radii, we skip pixels and rely on linear filtering
+    let taps = min(ceil(radius), MAX_TAPS);
+    let step_px = radius / taps;
+
+    let sigma = radius / 3.0;
+    let step = vec2f(params.direction_x, params.direction_y) * source_texel() * step_px;
+
+    var color = sample_source(uv);
+    var total = 1.0;
+
+    for (var idx = 1.0; idx <= taps; idx += 1.0) {
+        let dist = idx * step_px;
+        let weight = exp(-(dist * dist) / (2.0 * sigma * sigma));
+
+        color += (sample_source(uv + step * idx) + sample_source(uv - step * idx)) * weight;
+        total += 2.0 * weight;
+    }
+
+    return color / total;
+}
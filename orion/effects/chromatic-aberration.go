@@ -0,0 +1,35 @@
+package effects
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+//go:embed chromatic-aberration.wgsl
+var chromaticAberrationShader string
+
+type chromaticAberrationParams struct {
+	Offset float32
+}
+
+// ChromaticAberrationEffect shifts the red and blue channel apart,
+// increasing towards the edges of the image.
+type ChromaticAberrationEffect struct {
+	// Offset of the channels at the edges of the image in pixels
+	Offset float32
+
+	shader *orion.ShaderEffect
+}
+
+func NewChromaticAberrationEffect(offset float32) *ChromaticAberrationEffect {
+	return &ChromaticAberrationEffect{
+		Offset: offset,
+		shader: orion.NewShaderEffect(chromaticAberrationShader, nil),
+	}
+}
+
+func (e *ChromaticAberrationEffect) Apply(target, source *orion.Image) {
+	orion.SetMaterialUniforms(e.shader.Material(), chromaticAberrationParams{Offset: e.Offset})
+	e.shader.Apply(target, source)
+}
@@ -0,0 +1,17 @@
+struct Params {
+    // offset of the red and blue channel at the edges in pixels
+    offset: f32,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+
+fn effect(uv: vec2f) -> vec4f {
+    // offset grows towards the edges of the image
+    let shift = (uv - 0.5) * 2.0 * params.offset * source_texel();
+
+    let color = sample_source(uv);
+    let r = sample_source(uv + shift).r;
+    let b = sample_source(uv - shift).b;
+
+    return vec4f(r, color.g, b, color.a);
+}
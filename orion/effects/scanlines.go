@@ -0,0 +1,48 @@
+package effects
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+//go:embed scanlines.wgsl
+var scanlinesShader string
+
+type scanlinesParams struct {
+	Spacing   float32
+	Intensity float32
+	Offset    float32
+}
+
+// ScanlinesEffect darkens horizontal lines of the image, like a crt screen.
+type ScanlinesEffect struct {
+	// Distance between two lines in pixels
+	Spacing float32
+
+	// Darkening of the lines, between 0 and 1
+	Intensity float32
+
+	// Vertical offset of the lines in pixels, animate it to let the lines scroll
+	Offset float32
+
+	shader *orion.ShaderEffect
+}
+
+func NewScanlinesEffect(spacing, intensity float32) *ScanlinesEffect {
+	return &ScanlinesEffect{
+		Spacing:   spacing,
+		Intensity: intensity,
+		shader:    orion.NewShaderEffect(scanlinesShader, nil),
+	}
+}
+
+func (e *ScanlinesEffect) Apply(target, source *orion.Image) {
+	orion.SetMaterialUniforms(e.shader.Material(), scanlinesParams{
+		Spacing:   e.Spacing,
+		Intensity: e.Intensity,
+		Offset:    e.Offset,
+	})
+
+	e.shader.Apply(target, source)
+}
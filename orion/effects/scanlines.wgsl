@@ -0,0 +1,18 @@
+struct Params {
+    spacing: f32,
+    intensity: f32,
+    offset: f32,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+
+const PI: f32 = 3.14159265;
+
+fn effect(uv: vec2f) -> vec4f {
+    let color = sample_source(uv);
+
+    let y_px = uv.y / source_texel().y + params.offset;
+    let line = 0.5 + 0.5 * cos(2.0 * PI * y_px / max(params.spacing, 1.0));
+
+    return vec4f(color.rgb * (1.0 - params.intensity * line), color.a);
+}
@@ -0,0 +1,59 @@
+package effects
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+//go:embed vignette.wgsl
+var vignetteShader string
+
+type vignetteParams struct {
+	Radius    float32
+	Softness  float32
+	Intensity float32
+	_         float32
+	Color     glm.Vec4f
+}
+
+// VignetteEffect darkens the image towards its corners.
+type VignetteEffect struct {
+	// Distance from the center at which the vignette starts,
+	// 0 is the center and 1 are the corners of the image.
+	Radius float32
+
+	// Distance over which the vignette fades in
+	Softness float32
+
+	// Intensity of the vignette in the corners, between 0 and 1
+	Intensity float32
+
+	// Color to fade to, defaults to black
+	Color orion.Color
+
+	shader *orion.ShaderEffect
+}
+
+func NewVignetteEffect(radius, softness, intensity float32) *VignetteEffect {
+	return &VignetteEffect{
+		Radius:    radius,
+		Softness:  softness,
+		Intensity: intensity,
+		Color:     pulse.ColorBlack,
+		shader:    orion.NewShaderEffect(vignetteShader, nil),
+	}
+}
+
+func (e *VignetteEffect) Apply(target, source *orion.Image) {
+	orion.SetMaterialUniforms(e.shader.Material(), vignetteParams{
+		Radius:    e.Radius,
+		Softness:  e.Softness,
+		Intensity: e.Intensity,
+		Color:     e.Color.ToVec(),
+	})
+
+	e.shader.Apply(target, source)
+}
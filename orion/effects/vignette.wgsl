@@ -0,0 +1,18 @@
+struct Params {
+    radius: f32,
+    softness: f32,
+    intensity: f32,
+    color: vec4f,
+};
+
+@group(1) @binding(0) var<uniform> params: Params;
+
+fn effect(uv: vec2f) -> vec4f {
+    let color = sample_source(uv);
+
+    // distance to the center, 1 in the corners
+    let dist = length(uv - 0.5) * sqrt(2.0);
+
+    let amount = smoothstep(params.radius, params.radius + params.softness, dist) * params.intensity;
+    return vec4f(mix(color.rgb, params.color.rgb, amount * params.color.a), color.a);
+}
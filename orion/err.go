@@ -1,6 +1,13 @@
 package orion
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
 
 func Handle(err error, desc string, args ...any) {
 	if err != nil {
@@ -8,3 +15,61 @@ func Handle(err error, desc string, args ...any) {
 		panic(text + ": " + err.Error())
 	}
 }
+
+// the last error reported by the context while rendering
+var renderError error
+
+func recordRenderError(err error) {
+	slog.Error("Error while rendering", slog.Any("err", err))
+	renderError = err
+}
+
+// RenderError returns the last error that occurred while rendering, e.g. a
+// custom shader that failed to compile. A shader error is returned as *pulse.ShaderError.
+// The error is shown on top of the screen until ClearRenderError is called.
+func RenderError() error {
+	return renderError
+}
+
+// ClearRenderError removes the error shown on screen.
+func ClearRenderError() {
+	renderError = nil
+}
+
+// drawRenderError draws the current render error, if any, to the target.
+func drawRenderError(target *Image) {
+	if renderError == nil {
+		return
+	}
+
+	// number of characters that fit into one line of the debug font
+	const margin = 16
+	columns := max(16, (int(target.Width())-2*margin)/6)
+
+	text := "Render error:\n" + wrapText(renderError.Error(), columns)
+
+	DebugText(target, text, &DebugTextOptions{
+		Transform:  glm.TranslationMat3[float32](margin, margin),
+		ColorScale: pulse.ColorLinearRGBA(1, 0.2, 0.2, 1),
+	})
+}
+
+// wrapText hard wraps each line of the text after the given number of columns.
+func wrapText(text string, columns int) string {
+	var wrapped strings.Builder
+
+	for line := range strings.Lines(text) {
+		line = strings.TrimRight(line, "\n")
+
+		for len(line) > columns {
+			wrapped.WriteString(line[:columns])
+			wrapped.WriteByte('\n')
+			line = line[columns:]
+		}
+
+		wrapped.WriteString(line)
+		wrapped.WriteByte('\n')
+	}
+
+	return wrapped.String()
+}
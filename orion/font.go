@@ -0,0 +1,304 @@
+package orion
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font is a TrueType or OpenType font. Glyphs are rasterized on demand for each
+// requested pixel size and cached in an atlas owned by the font. If the device
+// is recreated, the glyphs are rasterized again.
+type Font struct {
+	font  *opentype.Font
+	sizes map[float32]*fontFace
+	atlas *pulse.Atlas
+
+	// generation of the device the atlas was created with
+	generation uint32
+}
+
+// FontMetrics describes the vertical metrics of a font at a specific size in pixels.
+type FontMetrics struct {
+	// Ascent is the distance from the top of a line to the baseline.
+	Ascent float32
+
+	// Descent is the distance from the baseline to the bottom of a line.
+	Descent float32
+
+	// LineHeight is the recommended distance between the baselines of two lines.
+	LineHeight float32
+
+	// CapHeight and XHeight are the heights of upper and lower case letters
+	// above the baseline. They are zero, if the font does not define them.
+	CapHeight float32
+	XHeight   float32
+}
+
+// fontFace holds the rasterized glyphs of a font at one pixel size.
+type fontFace struct {
+	font    *Font
+	face    font.Face
+	metrics FontMetrics
+	glyphs  map[rune]*fontGlyph
+}
+
+type fontGlyph struct {
+	// the rasterized glyph, nil for glyphs without any pixels, e.g. a space
+	image *Image
+
+	// coverage of the glyph that is not yet uploaded into the atlas
+	mask *image.Alpha
+
+	// offset of the image relative to the glyph origin on the baseline
+	offset glm.Vec2f
+
+	advance float32
+}
+
+// NewFont parses a TrueType or OpenType font. For font collections,
+// the first font of the collection is used.
+func NewFont(data []byte) (*Font, error) {
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+
+	parsed, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+
+	return &Font{
+		font:  parsed,
+		sizes: map[float32]*fontFace{},
+	}, nil
+}
+
+// Metrics returns the vertical metrics of the font at the given size in pixels.
+func (f *Font) Metrics(size float32) FontMetrics {
+	return f.face(size).metrics
+}
+
+// Face returns the font at the given size in pixels, e.g. to be used with LayoutText.
+func (f *Font) Face(size float32) FontFace {
+	return f.face(size)
+}
+
+// Measure returns the size of the text when drawn with DrawText using the same
+// options. The width is the width of the longest line, the height is the number
+// of lines multiplied by the line height.
+func (f *Font) Measure(text string, opts *DrawTextOptions) glm.Vec2f {
+	return MeasureText(text, f.layoutOptions(opts))
+}
+
+// Release releases the glyph atlas of the font. The font can still
+// be used afterward, glyphs are rasterized again when needed.
+func (f *Font) Release() {
+	if f.atlas != nil {
+		f.atlas.Release()
+		f.atlas = nil
+	}
+
+	for _, face := range f.sizes {
+		clear(face.glyphs)
+	}
+}
+
+func (f *Font) face(size float32) *fontFace {
+	if face, ok := f.sizes[size]; ok {
+		return face
+	}
+
+	face, err := opentype.NewFace(f.font, &opentype.FaceOptions{
+		// with 72 dpi one point is exactly one pixel
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+
+	if err != nil {
+		// only fails for invalid options
+		panic(err)
+	}
+
+	metrics := face.Metrics()
+
+	cached := &fontFace{
+		font:   f,
+		face:   face,
+		glyphs: map[rune]*fontGlyph{},
+		metrics: FontMetrics{
+			Ascent:     fixedToFloat(metrics.Ascent),
+			Descent:    fixedToFloat(metrics.Descent),
+			LineHeight: fixedToFloat(metrics.Height),
+			CapHeight:  fixedToFloat(metrics.CapHeight),
+			XHeight:    fixedToFloat(metrics.XHeight),
+		},
+	}
+
+	f.sizes[size] = cached
+
+	return cached
+}
+
+// glyph returns the glyph for the given rune. Runes not contained in
+// the font are rendered using the fonts replacement glyph.
+func (face *fontFace) glyph(ch rune) *fontGlyph {
+	if glyph, ok := face.glyphs[ch]; ok {
+		return glyph
+	}
+
+	dr, mask, maskp, advance, _ := face.face.Glyph(fixed.Point26_6{}, ch)
+
+	glyph := &fontGlyph{
+		offset:  glm.Vec2f{float32(dr.Min.X), float32(dr.Min.Y)},
+		advance: fixedToFloat(advance),
+	}
+
+	if mask != nil && !dr.Empty() {
+		// the face reuses its mask for the next glyph, keep a copy until
+		// the glyph is drawn for the first time
+		glyph.mask = image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+		draw.Draw(glyph.mask, glyph.mask.Rect, mask, maskp, draw.Src)
+	}
+
+	face.glyphs[ch] = glyph
+
+	return glyph
+}
+
+func (face *fontFace) Metrics() FontMetrics {
+	return face.metrics
+}
+
+func (face *fontFace) Advance(ch rune) float32 {
+	return face.glyph(ch).advance
+}
+
+func (face *fontFace) Kern(prev, ch rune) float32 {
+	return fixedToFloat(face.face.Kern(prev, ch))
+}
+
+func (face *fontFace) DrawGlyph(target *Image, ch rune, origin glm.Vec2f, opts *DrawImageOptions) {
+	if face.font.atlas != nil && face.font.generation != currentContext.Get().Generation() {
+		// the atlas belongs to a previous device
+		face.font.Release()
+	}
+
+	glyph := face.glyph(ch)
+
+	glyphImage := face.font.imageOf(glyph)
+	if glyphImage == nil {
+		return
+	}
+
+	// snap glyphs to full pixels to keep them sharp
+	x := float32(math.Round(float64(origin[0]))) + glyph.offset[0]
+	y := float32(math.Round(float64(origin[1]))) + glyph.offset[1]
+
+	glyphOpts := *opts
+	glyphOpts.Transform = opts.Transform.Translate(x, y)
+
+	target.DrawImage(glyphImage, &glyphOpts)
+}
+
+// imageOf returns the image of the glyph, adding it to the atlas if it was
+// not drawn before. Returns nil if the glyph has no pixels.
+func (f *Font) imageOf(glyph *fontGlyph) *Image {
+	if glyph.mask == nil {
+		return glyph.image
+	}
+
+	if f.atlas == nil {
+		f.atlas = pulse.NewAtlas(currentContext.Get(), pulse.AtlasOptions{
+			// coverage is stored in the alpha channel of a white image
+			Format:  wgpu.TextureFormatRGBA8Unorm,
+			Padding: 1,
+			Label:   "FontAtlas",
+		})
+
+		f.generation = currentContext.Get().Generation()
+	}
+
+	texture, err := f.atlas.Add(glyph.mask)
+	if err != nil {
+		currentContext.Get().ReportError(fmt.Errorf("add glyph to font atlas: %w", err))
+	} else {
+		glyph.image = asImage(texture)
+	}
+
+	glyph.mask = nil
+
+	return glyph.image
+}
+
+type DrawTextOptions struct {
+	// Size of the font in pixels. Defaults to 16.
+	Size float32
+
+	// Transform to apply to the text. The origin of the text is the top left
+	// corner of the first line, the baseline is at FontMetrics.Ascent.
+	Transform glm.Mat3f
+
+	// Color of the text
+	ColorScale ColorScale
+
+	// BlendState defines how to blend the text with the
+	// existing framebuffer. The default is BlendStateDefault.
+	BlendState wgpu.BlendState
+
+	// TabWidth is the width of a tab in multiples of the width of a space.
+	// Defaults to 4.
+	TabWidth uint
+
+	// Layer to draw the text on, see DrawImageOptions.
+	Layer float32
+}
+
+// DrawText draws the text using the given font. Lines are separated by '\n',
+// other control characters are skipped. The glyphs are drawn as sprites, text
+// batches with images drawn before and after, as long as the glyphs fit onto
+// one page of the font atlas. Use LayoutText for wrapping, alignment and markup.
+func (i *Image) DrawText(font *Font, text string, opts *DrawTextOptions) {
+	if opts == nil {
+		opts = &DrawTextOptions{}
+	}
+
+	layout := LayoutText(text, font.layoutOptions(opts))
+
+	i.DrawTextLayout(layout, &DrawTextLayoutOptions{
+		Transform:  opts.Transform,
+		ColorScale: opts.ColorScale,
+		BlendState: opts.BlendState,
+		Layer:      opts.Layer,
+	})
+}
+
+func (f *Font) layoutOptions(opts *DrawTextOptions) *LayoutTextOptions {
+	if opts == nil {
+		opts = &DrawTextOptions{}
+	}
+
+	size := opts.Size
+	if size <= 0 {
+		size = 16
+	}
+
+	return &LayoutTextOptions{
+		Face:     f.Face(size),
+		TabWidth: opts.TabWidth,
+	}
+}
+
+func fixedToFloat(value fixed.Int26_6) float32 {
+	return float32(value) / 64
+}
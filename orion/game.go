@@ -18,9 +18,21 @@ type Game interface {
 	Draw(screen *Image)
 
 	// DrawToSurface draws the offscreen texture to the actual window surface.
+	// The offscreen texture has LayoutOptions.PostProcess applied.
 	DrawToSurface(surface, offscreen *Image)
 }
 
+// DeviceRecreatedHandler can be implemented by a Game to restore its gpu
+// resources after the device was lost and recreated. Images created before
+// the device was lost must not be used anymore. This includes images loaded
+// from files, atlases, meshes, images of materials as well as scenes and
+// fonts loaded using the gltf and bmfont packages.
+//
+// Glyphs of a Font and the uniforms of a Material are restored automatically.
+type DeviceRecreatedHandler interface {
+	DeviceRecreated() error
+}
+
 type LayoutOptions struct {
 	// Defaults to the size of the surface
 	Width  uint32
@@ -32,6 +44,15 @@ type LayoutOptions struct {
 
 	// Enable MSAA on the offscreen render target
 	MSAA bool
+
+	// SortByLayer records all images and triangles drawn in Game.Draw and draws
+	// them sorted by their layer, as if Game.Draw was wrapped in BeginLayered
+	// and EndLayered.
+	SortByLayer bool
+
+	// PostProcess is applied to the offscreen canvas after Game.Draw. The
+	// result is passed to Game.DrawToSurface instead of the canvas.
+	PostProcess *PostProcessChain
 }
 
 func (o LayoutOptions) withDefaults(surfaceWidth, surfaceHeight uint32) LayoutOptions {
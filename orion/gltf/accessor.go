@@ -0,0 +1,257 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+func componentCountOf(accessorType string) (int, error) {
+	switch accessorType {
+	case "SCALAR":
+		return 1, nil
+	case "VEC2":
+		return 2, nil
+	case "VEC3":
+		return 3, nil
+	case "VEC4", "MAT2":
+		return 4, nil
+	case "MAT3":
+		return 9, nil
+	case "MAT4":
+		return 16, nil
+	default:
+		return 0, fmt.Errorf("unknown accessor type %q", accessorType)
+	}
+}
+
+func componentSizeOf(componentType int) (int, error) {
+	switch componentType {
+	case componentByte, componentUnsignedByte:
+		return 1, nil
+	case componentShort, componentUnsignedShort:
+		return 2, nil
+	case componentUnsignedInt, componentFloat:
+		return 4, nil
+	default:
+		return 0, fmt.Errorf("unknown component type %d", componentType)
+	}
+}
+
+// accessorView describes where the elements of an accessor are stored
+type accessorView struct {
+	accessor *gltfAccessor
+
+	// data starting at the first element, nil if the accessor has no buffer view
+	data []byte
+
+	stride         int
+	componentSize  int
+	componentCount int
+}
+
+// component reads a single component of an element. Normalized integers are
+// mapped to 0 to 1, or -1 to 1 for signed integers.
+func (v *accessorView) component(element, component int) float64 {
+	if v.data == nil {
+		// an accessor without buffer view is initialized with zeros
+		return 0
+	}
+
+	buf := v.data[element*v.stride+component*v.componentSize:]
+
+	var value, scale float64
+
+	switch v.accessor.ComponentType {
+	case componentByte:
+		value, scale = float64(int8(buf[0])), 127
+	case componentUnsignedByte:
+		value, scale = float64(buf[0]), 255
+	case componentShort:
+		value, scale = float64(int16(binary.LittleEndian.Uint16(buf))), 32767
+	case componentUnsignedShort:
+		value, scale = float64(binary.LittleEndian.Uint16(buf)), 65535
+	case componentUnsignedInt:
+		value, scale = float64(binary.LittleEndian.Uint32(buf)), math.MaxUint32
+	case componentFloat:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(buf)))
+	}
+
+	if v.accessor.Normalized {
+		return max(value/scale, -1)
+	}
+
+	return value
+}
+
+func (l *loader) accessorView(idx int) (*accessorView, error) {
+	if idx < 0 || idx >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d does not exist", idx)
+	}
+
+	accessor := &l.doc.Accessors[idx]
+
+	if accessor.Sparse != nil {
+		return nil, fmt.Errorf("accessor %d: sparse accessors are not supported", idx)
+	}
+
+	if accessor.Count < 0 {
+		return nil, fmt.Errorf("accessor %d: negative count %d", idx, accessor.Count)
+	}
+
+	componentCount, err := componentCountOf(accessor.Type)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %w", idx, err)
+	}
+
+	componentSize, err := componentSizeOf(accessor.ComponentType)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %w", idx, err)
+	}
+
+	view := &accessorView{
+		accessor:       accessor,
+		componentSize:  componentSize,
+		componentCount: componentCount,
+		stride:         componentSize * componentCount,
+	}
+
+	if accessor.BufferView == nil {
+		return view, nil
+	}
+
+	bufferView, data, err := l.bufferView(*accessor.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %w", idx, err)
+	}
+
+	if bufferView.ByteStride < 0 {
+		return nil, fmt.Errorf("accessor %d: negative byte stride %d", idx, bufferView.ByteStride)
+	}
+
+	if bufferView.ByteStride != 0 {
+		view.stride = bufferView.ByteStride
+	}
+
+	if accessor.ByteOffset < 0 || accessor.ByteOffset > len(data) {
+		return nil, fmt.Errorf("accessor %d: exceeds its buffer view", idx)
+	}
+
+	if accessor.Count > 0 {
+		end := accessor.ByteOffset + (accessor.Count-1)*view.stride + componentSize*componentCount
+		if end > len(data) {
+			return nil, fmt.Errorf("accessor %d: exceeds its buffer view", idx)
+		}
+	}
+
+	view.data = data[accessor.ByteOffset:]
+
+	return view, nil
+}
+
+// readFloats reads the accessor as float32 values. The accessor must have
+// the given number of components per element.
+func (l *loader) readFloats(idx int, componentCounts ...int) ([]float32, int, error) {
+	view, err := l.accessorView(idx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	valid := false
+	for _, count := range componentCounts {
+		valid = valid || view.componentCount == count
+	}
+
+	if !valid {
+		return nil, 0, fmt.Errorf("accessor %d: unexpected type %s", idx, view.accessor.Type)
+	}
+
+	values := make([]float32, 0, view.accessor.Count*view.componentCount)
+
+	for element := range view.accessor.Count {
+		for component := range view.componentCount {
+			values = append(values, float32(view.component(element, component)))
+		}
+	}
+
+	return values, view.componentCount, nil
+}
+
+func (l *loader) readIndices(idx int) ([]uint32, error) {
+	view, err := l.accessorView(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	if view.componentCount != 1 || view.accessor.ComponentType == componentFloat {
+		return nil, fmt.Errorf("accessor %d: indices must be unsigned integer scalars", idx)
+	}
+
+	indices := make([]uint32, view.accessor.Count)
+	for element := range indices {
+		indices[element] = uint32(view.component(element, 0))
+	}
+
+	return indices, nil
+}
+
+func (l *loader) bufferView(idx int) (*gltfBufferView, []byte, error) {
+	if idx < 0 || idx >= len(l.doc.BufferViews) {
+		return nil, nil, fmt.Errorf("buffer view %d does not exist", idx)
+	}
+
+	view := &l.doc.BufferViews[idx]
+
+	buffer, err := l.buffer(view.Buffer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("buffer view %d: %w", idx, err)
+	}
+
+	end := view.ByteOffset + view.ByteLength
+	if view.ByteOffset < 0 || view.ByteLength < 0 || end > len(buffer) {
+		return nil, nil, fmt.Errorf("buffer view %d: exceeds buffer %d", idx, view.Buffer)
+	}
+
+	return view, buffer[view.ByteOffset:end], nil
+}
+
+func (l *loader) buffer(idx int) ([]byte, error) {
+	if idx < 0 || idx >= len(l.doc.Buffers) {
+		return nil, fmt.Errorf("buffer %d does not exist", idx)
+	}
+
+	if data, ok := l.buffers[idx]; ok {
+		return data, nil
+	}
+
+	buffer := &l.doc.Buffers[idx]
+
+	var data []byte
+
+	if buffer.URI == "" {
+		// the first buffer without uri refers to the binary chunk of a glb file
+		if idx != 0 || l.binaryChunk == nil {
+			return nil, fmt.Errorf("buffer %d: no uri and no binary chunk", idx)
+		}
+
+		data = l.binaryChunk
+	} else {
+		var err error
+
+		data, err = l.readURI(buffer.URI)
+		if err != nil {
+			return nil, fmt.Errorf("buffer %d: %w", idx, err)
+		}
+	}
+
+	if len(data) < buffer.ByteLength {
+		return nil, fmt.Errorf("buffer %d: expected %d bytes, got %d", idx, buffer.ByteLength, len(data))
+	}
+
+	l.buffers[idx] = data
+
+	return data, nil
+}
+
+var errNoFS = errors.New("no file system to load external resources from")
@@ -0,0 +1,68 @@
+package gltf
+
+import (
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// Draw draws the default scene of the document, see Scene.Draw.
+// Nothing is drawn, if the document has no default scene.
+func (d *Document) Draw(target *orion.Image, opts *orion.DrawMesh3dOptions) {
+	if d.Scene != nil {
+		d.Scene.Draw(target, opts)
+	}
+}
+
+// Draw draws the meshes of all nodes in the scene. The transform of the options is
+// applied on top of the world transform of each node. Texture, color and sampler of
+// each primitive are taken from its material.
+func (s *Scene) Draw(target *orion.Image, opts *orion.DrawMesh3dOptions) {
+	for _, node := range s.Nodes {
+		node.Draw(target, opts)
+	}
+}
+
+// Draw draws the mesh of the node and of its children, see Scene.Draw.
+// The node is drawn with its world transform.
+func (n *Node) Draw(target *orion.Image, opts *orion.DrawMesh3dOptions) {
+	if opts == nil {
+		opts = &orion.DrawMesh3dOptions{}
+	}
+
+	var parentTransform glm.Mat4f
+	if n.Parent != nil {
+		parentTransform = n.Parent.WorldTransform()
+	}
+
+	n.draw(target, opts, opts.Transform.Mul(parentTransform))
+}
+
+func (n *Node) draw(target *orion.Image, opts *orion.DrawMesh3dOptions, parentTransform glm.Mat4f) {
+	transform := parentTransform.Mul(n.Transform)
+
+	if n.Mesh != nil {
+		for _, primitive := range n.Mesh.Primitives {
+			primitiveOpts := *opts
+			primitiveOpts.Transform = transform
+
+			if material := primitive.Material; material != nil {
+				primitiveOpts.ColorScale = opts.ColorScale.Scaled(material.BaseColor.ToVec())
+				primitiveOpts.Texture = material.BaseColorTexture
+				primitiveOpts.FilterMode = material.FilterMode
+				primitiveOpts.AddressModeU = material.AddressModeU
+				primitiveOpts.AddressModeV = material.AddressModeV
+
+				if material.DoubleSided {
+					primitiveOpts.CullMode = wgpu.CullModeNone
+				}
+			}
+
+			target.DrawMesh3d(primitive.Vertices, primitive.Indices, &primitiveOpts)
+		}
+	}
+
+	for _, child := range n.Children {
+		child.draw(target, opts, transform)
+	}
+}
@@ -0,0 +1,663 @@
+// Package gltf loads glTF 2.0 scenes from .gltf files with external or embedded
+// buffers and from binary .glb files. Meshes can be drawn using orion.Image.DrawMesh3d,
+// or the whole scene at once using Document.Draw.
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+type Document struct {
+	Meshes    []*Mesh
+	Materials []*Material
+	Nodes     []*Node
+
+	// Scenes of the document, each one is a list of root nodes
+	Scenes []*Scene
+
+	// Scene to display by default, nil if the document does not define one
+	Scene *Scene
+}
+
+type Scene struct {
+	Name  string
+	Nodes []*Node
+}
+
+type Mesh struct {
+	Name       string
+	Primitives []Primitive
+}
+
+// Primitive is a part of a mesh drawn with a single material.
+type Primitive struct {
+	// Vertices and indices of the triangles, each three indices form a triangle
+	Vertices []orion.Vertex3d
+	Indices  []uint32
+
+	// Material of the primitive, nil to use the default material
+	Material *Material
+}
+
+type Material struct {
+	Name string
+
+	// BaseColor is multiplied with the color of the base color texture
+	BaseColor orion.Color
+
+	// BaseColorTexture is nil, if the material has no texture
+	BaseColorTexture *orion.Image
+
+	// Sampler configuration of the base color texture
+	FilterMode   wgpu.FilterMode
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// AlphaMode is one of OPAQUE, MASK or BLEND
+	AlphaMode   string
+	AlphaCutoff float32
+
+	// DoubleSided materials must not be culled
+	DoubleSided bool
+}
+
+type Node struct {
+	Name string
+
+	// Mesh of this node, might be nil
+	Mesh *Mesh
+
+	Parent   *Node
+	Children []*Node
+
+	// Local transform of the node relative to its parent. If the
+	// document specified a matrix, Translation, Rotation and Scale
+	// are left at identity and only Transform is set.
+	Translation glm.Vec3f
+	Rotation    glm.Quaternion[float32]
+	Scale       glm.Vec3f
+	Transform   glm.Mat4f
+}
+
+// WorldTransform returns the transform of the node relative to the root of the scene.
+func (n *Node) WorldTransform() glm.Mat4f {
+	if n.Parent == nil {
+		return n.Transform
+	}
+
+	return n.Parent.WorldTransform().Mul(n.Transform)
+}
+
+// Load loads a .gltf or .glb file. External buffers and images
+// are loaded relative to the file from the same file system.
+func Load(fsys fs.FS, name string) (*Document, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := Parse(data, &Options{FS: fsys, Dir: path.Dir(name)})
+	if err != nil {
+		return nil, fmt.Errorf("load %q: %w", name, err)
+	}
+
+	return doc, nil
+}
+
+type Options struct {
+	// FS to load external buffers and images from. If nil, only
+	// embedded buffers and images are supported.
+	FS fs.FS
+
+	// Dir is the directory of the gltf file within FS
+	Dir string
+}
+
+// Parse parses a .gltf or a .glb file. Images are decoded and uploaded
+// to the gpu, this requires an active orion context.
+func Parse(data []byte, opts *Options) (*Document, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	l := &loader{
+		opts:     opts,
+		buffers:  map[int][]byte{},
+		textures: map[int]*orion.Image{},
+	}
+
+	jsonChunk := data
+
+	if bytes.HasPrefix(data, []byte("glTF")) {
+		var err error
+
+		jsonChunk, l.binaryChunk, err = parseBinary(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(jsonChunk, &l.doc); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+
+	if !strings.HasPrefix(l.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("unsupported glTF version %q", l.doc.Asset.Version)
+	}
+
+	return l.build()
+}
+
+// parseBinary splits a glb file into its json and binary chunk
+func parseBinary(data []byte) (jsonChunk, binaryChunk []byte, err error) {
+	const chunkTypeJSON = 0x4E4F534A
+	const chunkTypeBIN = 0x004E4942
+
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb: header too short")
+	}
+
+	version := binary.LittleEndian.Uint32(data[4:])
+	if version != 2 {
+		return nil, nil, fmt.Errorf("glb: unsupported version %d", version)
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length < 12 {
+		return nil, nil, fmt.Errorf("glb: invalid length %d", length)
+	}
+
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("glb: expected %d bytes, got %d", length, len(data))
+	}
+
+	chunks := data[12:length]
+
+	for len(chunks) >= 8 {
+		chunkLength := int(binary.LittleEndian.Uint32(chunks))
+		chunkType := binary.LittleEndian.Uint32(chunks[4:])
+
+		if chunkLength > len(chunks)-8 {
+			return nil, nil, errors.New("glb: chunk exceeds file")
+		}
+
+		chunk := chunks[8 : 8+chunkLength]
+
+		switch {
+		case chunkType == chunkTypeJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case chunkType == chunkTypeBIN && binaryChunk == nil:
+			binaryChunk = chunk
+		}
+
+		chunks = chunks[8+chunkLength:]
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb: no json chunk")
+	}
+
+	return jsonChunk, binaryChunk, nil
+}
+
+type loader struct {
+	opts *Options
+	doc  gltfDocument
+
+	binaryChunk []byte
+
+	// buffers by index, loaded on first use
+	buffers map[int][]byte
+
+	// decoded images by index
+	textures map[int]*orion.Image
+}
+
+func (l *loader) readURI(uri string) ([]byte, error) {
+	if data, ok := strings.CutPrefix(uri, "data:"); ok {
+		_, encoded, ok := strings.Cut(data, ";base64,")
+		if !ok {
+			return nil, errors.New("only base64 data uris are supported")
+		}
+
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+
+	if l.opts.FS == nil {
+		return nil, errNoFS
+	}
+
+	// uris are relative and might be percent encoded
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri %q: %w", uri, err)
+	}
+
+	return fs.ReadFile(l.opts.FS, path.Join(l.opts.Dir, name))
+}
+
+func (l *loader) build() (*Document, error) {
+	doc := &Document{}
+
+	for idx := range l.doc.Materials {
+		material, err := l.material(idx)
+		if err != nil {
+			return nil, fmt.Errorf("material %d: %w", idx, err)
+		}
+
+		doc.Materials = append(doc.Materials, material)
+	}
+
+	for idx, gMesh := range l.doc.Meshes {
+		mesh := &Mesh{Name: gMesh.Name}
+
+		for primIdx, gPrimitive := range gMesh.Primitives {
+			primitive, ok, err := l.primitive(gPrimitive)
+			if err != nil {
+				return nil, fmt.Errorf("mesh %d, primitive %d: %w", idx, primIdx, err)
+			}
+
+			if !ok {
+				// points and lines are not supported
+				continue
+			}
+
+			if gPrimitive.Material != nil {
+				if *gPrimitive.Material < 0 || *gPrimitive.Material >= len(doc.Materials) {
+					return nil, fmt.Errorf("mesh %d, primitive %d: material %d does not exist", idx, primIdx, *gPrimitive.Material)
+				}
+
+				primitive.Material = doc.Materials[*gPrimitive.Material]
+			}
+
+			mesh.Primitives = append(mesh.Primitives, primitive)
+		}
+
+		doc.Meshes = append(doc.Meshes, mesh)
+	}
+
+	for _, gNode := range l.doc.Nodes {
+		node := &Node{
+			Name:      gNode.Name,
+			Rotation:  glm.Quaternion[float32]{S: 1},
+			Scale:     glm.Vec3f{1, 1, 1},
+			Transform: glm.Mat4f{},
+		}
+
+		if gNode.Mesh != nil {
+			if *gNode.Mesh < 0 || *gNode.Mesh >= len(doc.Meshes) {
+				return nil, fmt.Errorf("node %q: mesh %d does not exist", gNode.Name, *gNode.Mesh)
+			}
+
+			node.Mesh = doc.Meshes[*gNode.Mesh]
+		}
+
+		if gNode.Matrix != nil {
+			var values [4][4]float32
+			for idx, value := range gNode.Matrix {
+				// the matrix is stored in column major order
+				values[idx/4][idx%4] = value
+			}
+
+			node.Transform = glm.Mat4Of(values)
+		} else {
+			if t := gNode.Translation; t != nil {
+				node.Translation = glm.Vec3f{t[0], t[1], t[2]}
+			}
+
+			if r := gNode.Rotation; r != nil {
+				node.Rotation = glm.Quaternion[float32]{V: glm.Vec3f{r[0], r[1], r[2]}, S: r[3]}
+			}
+
+			if s := gNode.Scale; s != nil {
+				node.Scale = glm.Vec3f{s[0], s[1], s[2]}
+			}
+
+			node.Transform = glm.TranslationMat4(node.Translation[0], node.Translation[1], node.Translation[2]).
+				Mul(node.Rotation.ToMat4()).
+				Scale(node.Scale[0], node.Scale[1], node.Scale[2])
+		}
+
+		doc.Nodes = append(doc.Nodes, node)
+	}
+
+	for idx, gNode := range l.doc.Nodes {
+		for _, childIdx := range gNode.Children {
+			if childIdx < 0 || childIdx >= len(doc.Nodes) || doc.Nodes[childIdx].Parent != nil {
+				return nil, fmt.Errorf("node %d: invalid child %d", idx, childIdx)
+			}
+
+			child := doc.Nodes[childIdx]
+			child.Parent = doc.Nodes[idx]
+			doc.Nodes[idx].Children = append(doc.Nodes[idx].Children, child)
+		}
+	}
+
+	for idx, gScene := range l.doc.Scenes {
+		scene := &Scene{Name: gScene.Name}
+
+		for _, nodeIdx := range gScene.Nodes {
+			if nodeIdx < 0 || nodeIdx >= len(doc.Nodes) {
+				return nil, fmt.Errorf("scene %d: node %d does not exist", idx, nodeIdx)
+			}
+
+			scene.Nodes = append(scene.Nodes, doc.Nodes[nodeIdx])
+		}
+
+		doc.Scenes = append(doc.Scenes, scene)
+	}
+
+	switch {
+	case l.doc.Scene != nil && *l.doc.Scene >= 0 && *l.doc.Scene < len(doc.Scenes):
+		doc.Scene = doc.Scenes[*l.doc.Scene]
+
+	case len(doc.Scenes) > 0:
+		doc.Scene = doc.Scenes[0]
+	}
+
+	return doc, nil
+}
+
+func (l *loader) material(idx int) (*Material, error) {
+	gMaterial := l.doc.Materials[idx]
+
+	material := &Material{
+		Name:         gMaterial.Name,
+		BaseColor:    pulse.ColorWhite,
+		FilterMode:   wgpu.FilterModeLinear,
+		AddressModeU: wgpu.AddressModeRepeat,
+		AddressModeV: wgpu.AddressModeRepeat,
+		AlphaMode:    gMaterial.AlphaMode,
+		AlphaCutoff:  0.5,
+		DoubleSided:  gMaterial.DoubleSided,
+	}
+
+	if material.AlphaMode == "" {
+		material.AlphaMode = "OPAQUE"
+	}
+
+	if gMaterial.AlphaCutoff != nil {
+		material.AlphaCutoff = *gMaterial.AlphaCutoff
+	}
+
+	pbr := gMaterial.PbrMetallicRoughness
+
+	if c := pbr.BaseColorFactor; c != nil {
+		material.BaseColor = pulse.ColorLinearRGBA(c[0], c[1], c[2], c[3])
+	}
+
+	if info := pbr.BaseColorTexture; info != nil {
+		if info.TexCoord != 0 {
+			return nil, fmt.Errorf("texture coordinates %d are not supported", info.TexCoord)
+		}
+
+		if info.Index < 0 || info.Index >= len(l.doc.Textures) {
+			return nil, fmt.Errorf("texture %d does not exist", info.Index)
+		}
+
+		texture := l.doc.Textures[info.Index]
+
+		if texture.Source != nil {
+			image, err := l.image(*texture.Source)
+			if err != nil {
+				return nil, err
+			}
+
+			material.BaseColorTexture = image
+		}
+
+		if texture.Sampler != nil && *texture.Sampler >= 0 && *texture.Sampler < len(l.doc.Samplers) {
+			sampler := l.doc.Samplers[*texture.Sampler]
+
+			if sampler.MagFilter == filterNearest {
+				material.FilterMode = wgpu.FilterModeNearest
+			}
+
+			material.AddressModeU = addressModeOf(sampler.WrapS)
+			material.AddressModeV = addressModeOf(sampler.WrapT)
+		}
+	}
+
+	return material, nil
+}
+
+func addressModeOf(wrap *int) wgpu.AddressMode {
+	if wrap == nil {
+		return wgpu.AddressModeRepeat
+	}
+
+	switch *wrap {
+	case wrapClampToEdge:
+		return wgpu.AddressModeClampToEdge
+	case wrapMirroredRepeat:
+		return wgpu.AddressModeMirrorRepeat
+	default:
+		return wgpu.AddressModeRepeat
+	}
+}
+
+// image decodes the image with the given index and uploads it to the gpu.
+// Images are interpreted as srgb, as they are used as base color textures.
+func (l *loader) image(idx int) (*orion.Image, error) {
+	if cached, ok := l.textures[idx]; ok {
+		return cached, nil
+	}
+
+	if idx < 0 || idx >= len(l.doc.Images) {
+		return nil, fmt.Errorf("image %d does not exist", idx)
+	}
+
+	gImage := l.doc.Images[idx]
+
+	var data []byte
+	var err error
+
+	if gImage.BufferView != nil {
+		_, data, err = l.bufferView(*gImage.BufferView)
+	} else {
+		data, err = l.readURI(gImage.URI)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", idx, err)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %d: decode: %w", idx, err)
+	}
+
+	texture := pulse.NewTextureFromImage(orion.CurrentContext(), decoded, true)
+
+	result := orion.NewImageFromTexture(texture)
+	l.textures[idx] = result
+
+	return result, nil
+}
+
+// primitive reads the vertices and indices of a primitive. Returns false,
+// if the primitive is not made of triangles.
+func (l *loader) primitive(gPrimitive gltfPrimitive) (Primitive, bool, error) {
+	mode := modeTriangles
+	if gPrimitive.Mode != nil {
+		mode = *gPrimitive.Mode
+	}
+
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		return Primitive{}, false, nil
+	}
+
+	positionIdx, ok := gPrimitive.Attributes["POSITION"]
+	if !ok {
+		return Primitive{}, false, errors.New("no POSITION attribute")
+	}
+
+	positions, _, err := l.readFloats(positionIdx, 3)
+	if err != nil {
+		return Primitive{}, false, fmt.Errorf("POSITION: %w", err)
+	}
+
+	vertices := make([]orion.Vertex3d, len(positions)/3)
+
+	for idx := range vertices {
+		vertices[idx] = orion.Vertex3d{
+			Position: glm.Vec3f{positions[idx*3], positions[idx*3+1], positions[idx*3+2]},
+			Color:    pulse.ColorWhite,
+		}
+	}
+
+	if normalIdx, ok := gPrimitive.Attributes["NORMAL"]; ok {
+		normals, _, err := l.readFloats(normalIdx, 3)
+		if err != nil {
+			return Primitive{}, false, fmt.Errorf("NORMAL: %w", err)
+		}
+
+		if len(normals) != len(positions) {
+			return Primitive{}, false, errors.New("NORMAL: count differs from POSITION")
+		}
+
+		for idx := range vertices {
+			vertices[idx].Normal = glm.Vec3f{normals[idx*3], normals[idx*3+1], normals[idx*3+2]}
+		}
+	}
+
+	if uvIdx, ok := gPrimitive.Attributes["TEXCOORD_0"]; ok {
+		uvs, _, err := l.readFloats(uvIdx, 2)
+		if err != nil {
+			return Primitive{}, false, fmt.Errorf("TEXCOORD_0: %w", err)
+		}
+
+		if len(uvs)/2 != len(vertices) {
+			return Primitive{}, false, errors.New("TEXCOORD_0: count differs from POSITION")
+		}
+
+		for idx := range vertices {
+			// gltf uv coordinates already have their origin in the top left
+			vertices[idx].UV = glm.Vec2f{uvs[idx*2], uvs[idx*2+1]}
+		}
+	}
+
+	if colorIdx, ok := gPrimitive.Attributes["COLOR_0"]; ok {
+		colors, components, err := l.readFloats(colorIdx, 3, 4)
+		if err != nil {
+			return Primitive{}, false, fmt.Errorf("COLOR_0: %w", err)
+		}
+
+		if len(colors)/components != len(vertices) {
+			return Primitive{}, false, errors.New("COLOR_0: count differs from POSITION")
+		}
+
+		for idx := range vertices {
+			c := colors[idx*components:]
+
+			alpha := float32(1)
+			if components == 4 {
+				alpha = c[3]
+			}
+
+			vertices[idx].Color = pulse.ColorLinearRGBA(c[0], c[1], c[2], alpha)
+		}
+	}
+
+	var indices []uint32
+
+	if gPrimitive.Indices != nil {
+		indices, err = l.readIndices(*gPrimitive.Indices)
+		if err != nil {
+			return Primitive{}, false, fmt.Errorf("indices: %w", err)
+		}
+
+		for _, index := range indices {
+			if int(index) >= len(vertices) {
+				return Primitive{}, false, fmt.Errorf("indices: index %d out of range", index)
+			}
+		}
+	} else {
+		indices = make([]uint32, len(vertices))
+		for idx := range indices {
+			indices[idx] = uint32(idx)
+		}
+	}
+
+	indices = triangleListOf(mode, indices)
+
+	if _, ok := gPrimitive.Attributes["NORMAL"]; !ok {
+		vertices, indices = flatShaded(vertices, indices)
+	}
+
+	return Primitive{Vertices: vertices, Indices: indices}, true, nil
+}
+
+// triangleListOf converts strips and fans into a list of triangles
+func triangleListOf(mode int, indices []uint32) []uint32 {
+	if len(indices) < 3 {
+		return nil
+	}
+
+	var triangles []uint32
+
+	switch mode {
+	case modeTriangleStrip:
+		for idx := 0; idx+2 < len(indices); idx++ {
+			if idx%2 == 0 {
+				triangles = append(triangles, indices[idx], indices[idx+1], indices[idx+2])
+			} else {
+				// keep the winding order of every second triangle
+				triangles = append(triangles, indices[idx+1], indices[idx], indices[idx+2])
+			}
+		}
+
+	case modeTriangleFan:
+		for idx := 1; idx+1 < len(indices); idx++ {
+			triangles = append(triangles, indices[0], indices[idx], indices[idx+1])
+		}
+
+	default:
+		triangles = indices[:len(indices)/3*3]
+	}
+
+	return triangles
+}
+
+// flatShaded splits the triangles into separate vertices with face normals,
+// as required by the specification for primitives without normals.
+func flatShaded(vertices []orion.Vertex3d, indices []uint32) ([]orion.Vertex3d, []uint32) {
+	result := make([]orion.Vertex3d, len(indices))
+	resultIndices := make([]uint32, len(indices))
+
+	for idx := 0; idx+3 <= len(indices); idx += 3 {
+		a := vertices[indices[idx]]
+		b := vertices[indices[idx+1]]
+		c := vertices[indices[idx+2]]
+
+		normal := b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position))
+		if normal.LengthSqr() > 0 {
+			normal = normal.Normalize()
+		}
+
+		a.Normal, b.Normal, c.Normal = normal, normal, normal
+
+		result[idx], result[idx+1], result[idx+2] = a, b, c
+		resultIndices[idx], resultIndices[idx+1], resultIndices[idx+2] = uint32(idx), uint32(idx+1), uint32(idx+2)
+	}
+
+	return result, resultIndices
+}
@@ -0,0 +1,326 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+// glbOf builds a glb file from its chunks, each chunk is given
+// by its type followed by its content.
+func glbOf(version uint32, chunks ...any) []byte {
+	var body []byte
+
+	for idx := 0; idx+1 < len(chunks); idx += 2 {
+		content := chunks[idx+1].([]byte)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(content)))
+		body = binary.LittleEndian.AppendUint32(body, chunks[idx].(uint32))
+		body = append(body, content...)
+	}
+
+	data := []byte("glTF")
+	data = binary.LittleEndian.AppendUint32(data, version)
+	data = binary.LittleEndian.AppendUint32(data, uint32(12+len(body)))
+
+	return append(data, body...)
+}
+
+const (
+	chunkJSON = uint32(0x4E4F534A)
+	chunkBIN  = uint32(0x004E4942)
+)
+
+func TestParseBinary(t *testing.T) {
+	valid := glbOf(2, chunkJSON, []byte(`{}`), chunkBIN, []byte{1, 2, 3, 4})
+
+	withLength := func(data []byte, length uint32) []byte {
+		data = bytes.Clone(data)
+		binary.LittleEndian.PutUint32(data[8:], length)
+		return data
+	}
+
+	cases := []struct {
+		name   string
+		data   []byte
+		json   string
+		binary []byte
+		err    bool
+	}{
+		{name: "json and binary chunk", data: valid, json: `{}`, binary: []byte{1, 2, 3, 4}},
+		{name: "json chunk only", data: glbOf(2, chunkJSON, []byte(`{"a":1}`)), json: `{"a":1}`},
+		{name: "unknown chunks are skipped", data: glbOf(2, uint32(1), []byte{9}, chunkJSON, []byte(`{}`)), json: `{}`},
+		{name: "first chunk of a type wins", data: glbOf(2, chunkJSON, []byte(`{}`), chunkJSON, []byte(`[]`)), json: `{}`},
+		{name: "trailing data is ignored", data: append(bytes.Clone(valid), 0xff), json: `{}`, binary: []byte{1, 2, 3, 4}},
+		{name: "header too short", data: []byte("glTF\x02\x00\x00\x00"), err: true},
+		{name: "unsupported version", data: glbOf(1, chunkJSON, []byte(`{}`)), err: true},
+		{name: "length shorter than header", data: withLength(valid, 8), err: true},
+		{name: "length exceeds data", data: withLength(valid, uint32(len(valid)+1)), err: true},
+		{name: "chunk exceeds file", data: withLength(valid, uint32(len(valid)-1)), err: true},
+		{name: "no json chunk", data: glbOf(2, chunkBIN, []byte{1}), err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonChunk, binaryChunk, err := parseBinary(tc.data)
+
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(jsonChunk) != tc.json {
+				t.Errorf("expected json chunk %q, got %q", tc.json, jsonChunk)
+			}
+
+			if !bytes.Equal(binaryChunk, tc.binary) {
+				t.Errorf("expected binary chunk %v, got %v", tc.binary, binaryChunk)
+			}
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	cases := []struct {
+		name          string
+		componentType int
+		normalized    bool
+		data          []byte
+		expected      float64
+	}{
+		{name: "byte", componentType: componentByte, data: []byte{0xfe}, expected: -2},
+		{name: "normalized byte", componentType: componentByte, normalized: true, data: []byte{0x81}, expected: -1},
+		{name: "unsigned byte", componentType: componentUnsignedByte, data: []byte{200}, expected: 200},
+		{name: "normalized unsigned byte", componentType: componentUnsignedByte, normalized: true, data: []byte{255}, expected: 1},
+		{name: "short", componentType: componentShort, data: []byte{0x00, 0x80}, expected: -32768},
+		{name: "normalized short", componentType: componentShort, normalized: true, data: []byte{0xff, 0x7f}, expected: 1},
+		{name: "unsigned short", componentType: componentUnsignedShort, data: []byte{0x34, 0x12}, expected: 0x1234},
+		{name: "unsigned int", componentType: componentUnsignedInt, data: []byte{1, 0, 1, 0}, expected: 65537},
+		{name: "float", componentType: componentFloat, data: binary.LittleEndian.AppendUint32(nil, math.Float32bits(1.5)), expected: 1.5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			view := &accessorView{
+				accessor: &gltfAccessor{ComponentType: tc.componentType, Normalized: tc.normalized},
+				data:     tc.data,
+			}
+
+			if value := view.component(0, 0); value != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestTriangleListOf(t *testing.T) {
+	cases := []struct {
+		name     string
+		mode     int
+		indices  []uint32
+		expected []uint32
+	}{
+		{name: "triangles", mode: modeTriangles, indices: []uint32{0, 1, 2, 2, 1, 3}, expected: []uint32{0, 1, 2, 2, 1, 3}},
+		{name: "incomplete triangle", mode: modeTriangles, indices: []uint32{0, 1, 2, 3}, expected: []uint32{0, 1, 2}},
+		{name: "strip", mode: modeTriangleStrip, indices: []uint32{0, 1, 2, 3, 4}, expected: []uint32{0, 1, 2, 2, 1, 3, 2, 3, 4}},
+		{name: "fan", mode: modeTriangleFan, indices: []uint32{0, 1, 2, 3, 4}, expected: []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}},
+		{name: "too few indices", mode: modeTriangleFan, indices: []uint32{0, 1}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if triangles := triangleListOf(tc.mode, tc.indices); !slices.Equal(triangles, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, triangles)
+			}
+		})
+	}
+}
+
+// triangleBuffer holds the positions of a triangle, followed by the indices
+// 0, 1, 2 as unsigned shorts and a padding of two bytes.
+func triangleBuffer() []byte {
+	var data []byte
+
+	for _, value := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(value))
+	}
+
+	for _, index := range []uint16{0, 1, 2, 0} {
+		data = binary.LittleEndian.AppendUint16(data, index)
+	}
+
+	return data
+}
+
+const triangleDocument = `{
+	"asset": {"version": "2.0"},
+	"scene": 0,
+	"scenes": [{"name": "scene", "nodes": [0]}],
+	"nodes": [
+		{"name": "root", "children": [1], "translation": [1, 2, 3]},
+		{"name": "child", "mesh": 0}
+	],
+	"meshes": [{"name": "triangle", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}]}],
+	"materials": [{"name": "red", "pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 1]}}],
+	"buffers": [{%s"byteLength": 44}],
+	"bufferViews": [
+		{"buffer": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 6}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	]
+}`
+
+func TestParse(t *testing.T) {
+	buffer := triangleBuffer()
+
+	dataURI := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buffer)
+
+	fsys := fstest.MapFS{
+		"models/triangle.gltf":     {Data: fmt.Appendf(nil, triangleDocument, `"uri": "triangle%20data.bin", `)},
+		"models/triangle data.bin": {Data: buffer},
+	}
+
+	cases := []struct {
+		name  string
+		parse func() (*Document, error)
+	}{
+		{
+			name: "embedded buffer",
+			parse: func() (*Document, error) {
+				return Parse(fmt.Appendf(nil, triangleDocument, `"uri": "`+dataURI+`", `), nil)
+			},
+		},
+		{
+			name: "glb",
+			parse: func() (*Document, error) {
+				return Parse(glbOf(2, chunkJSON, fmt.Appendf(nil, triangleDocument, ""), chunkBIN, buffer), nil)
+			},
+		},
+		{
+			name: "external buffer",
+			parse: func() (*Document, error) {
+				return Load(fsys, "models/triangle.gltf")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := tc.parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(doc.Nodes) != 2 || doc.Scene == nil || len(doc.Scene.Nodes) != 1 {
+				t.Fatalf("unexpected structure of the document")
+			}
+
+			root, child := doc.Nodes[0], doc.Nodes[1]
+
+			if doc.Scene.Nodes[0] != root || child.Parent != root || !slices.Equal(root.Children, []*Node{child}) {
+				t.Errorf("unexpected node hierarchy")
+			}
+
+			if root.Translation != (glm.Vec3f{1, 2, 3}) || root.Scale != (glm.Vec3f{1, 1, 1}) {
+				t.Errorf("unexpected transform of the root node")
+			}
+
+			if child.Mesh == nil || len(child.Mesh.Primitives) != 1 {
+				t.Fatalf("expected the child node to have a mesh with a single primitive")
+			}
+
+			primitive := child.Mesh.Primitives[0]
+
+			if primitive.Material != doc.Materials[0] || primitive.Material.BaseColor != pulse.ColorLinearRGBA(1, 0, 0, 1) {
+				t.Errorf("unexpected material of the primitive")
+			}
+
+			if !slices.Equal(primitive.Indices, []uint32{0, 1, 2}) || len(primitive.Vertices) != 3 {
+				t.Fatalf("unexpected indices %v", primitive.Indices)
+			}
+
+			for idx, expected := range []glm.Vec3f{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}} {
+				vertex := primitive.Vertices[idx]
+
+				if vertex.Position != expected {
+					t.Errorf("vertex %d: expected position %v, got %v", idx, expected, vertex.Position)
+				}
+
+				// the primitive has no normals and is flat shaded
+				if vertex.Normal != (glm.Vec3f{0, 0, 1}) {
+					t.Errorf("vertex %d: expected a face normal, got %v", idx, vertex.Normal)
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	// buffer with the positions of a triangle, 36 bytes
+	data := base64.StdEncoding.EncodeToString(triangleBuffer()[:36])
+
+	const validView = `{"buffer": 0, "byteLength": 36}`
+	const validAccessor = `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`
+
+	cases := []struct {
+		name       string
+		bufferView string
+		accessor   string
+		valid      bool
+	}{
+		{name: "valid", bufferView: validView, accessor: validAccessor, valid: true},
+		{name: "empty accessor at the end of the view", bufferView: validView, accessor: `{"bufferView": 0, "byteOffset": 36, "componentType": 5126, "count": 0, "type": "VEC3"}`, valid: true},
+		{name: "missing accessor", bufferView: validView},
+		{name: "negative count", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 5126, "count": -1, "type": "VEC3"}`},
+		{name: "count exceeds view", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}`},
+		{name: "byte offset exceeds view", bufferView: validView, accessor: `{"bufferView": 0, "byteOffset": 40, "componentType": 5126, "count": 0, "type": "VEC3"}`},
+		{name: "negative byte offset", bufferView: validView, accessor: `{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 1, "type": "VEC3"}`},
+		{name: "negative byte stride", bufferView: `{"buffer": 0, "byteLength": 36, "byteStride": -12}`, accessor: validAccessor},
+		{name: "byte stride exceeds view", bufferView: `{"buffer": 0, "byteLength": 36, "byteStride": 16}`, accessor: validAccessor},
+		{name: "unknown type", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC5"}`},
+		{name: "unexpected type", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC2"}`},
+		{name: "unknown component type", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 1234, "count": 3, "type": "VEC3"}`},
+		{name: "sparse accessor", bufferView: validView, accessor: `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3", "sparse": {}}`},
+		{name: "view exceeds buffer", bufferView: `{"buffer": 0, "byteLength": 40}`, accessor: validAccessor},
+		{name: "missing buffer", bufferView: `{"buffer": 1, "byteLength": 36}`, accessor: validAccessor},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := fmt.Sprintf(`{
+				"asset": {"version": "2.0"},
+				"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+				"buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": 36}],
+				"bufferViews": [%s],
+				"accessors": [%s]
+			}`, data, tc.bufferView, tc.accessor)
+
+			_, err := Parse([]byte(doc), nil)
+
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+
+			if !tc.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package orion
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/oliverbestmann/pulse/glimpse"
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+type HeadlessOptions struct {
+	// game to run. This is the only field that is required
+	Game Game
+
+	// Size of the virtual surface. The surface size is passed to
+	// Game.Layout and defaults to 1000x600, same as the window size in RunGame.
+	SurfaceWidth  uint32
+	SurfaceHeight uint32
+}
+
+// Headless runs a Game without a window. Frames are not presented to
+// any surface, instead the canvas can be inspected after each frame.
+// This is useful to test the rendering of a game.
+type Headless struct {
+	loopState     *LoopState
+	surfaceWidth  uint32
+	surfaceHeight uint32
+}
+
+// NewHeadless prepares the given game to run without a window. If no context has been
+// initialized yet, a headless pulse.Context is created. The context is kept
+// for the lifetime of the process and shared by all Headless instances.
+func NewHeadless(opts HeadlessOptions) (*Headless, error) {
+	if opts.Game == nil {
+		return nil, errors.New("game must not be nil")
+	}
+
+	if opts.SurfaceWidth == 0 {
+		opts.SurfaceWidth = 1000
+	}
+
+	if opts.SurfaceHeight == 0 {
+		opts.SurfaceHeight = 600
+	}
+
+	if !currentContext.hasValue {
+		ctx, err := pulse.NewHeadless()
+		if err != nil {
+			return nil, fmt.Errorf("initializing wgpu: %w", err)
+		}
+
+		ctx.OnError = recordRenderError
+		currentContext.set(ctx)
+
+		initializeCommands(ctx)
+	}
+
+	h := &Headless{
+		loopState:     &LoopState{Game: opts.Game},
+		surfaceWidth:  opts.SurfaceWidth,
+		surfaceHeight: opts.SurfaceHeight,
+	}
+
+	return h, nil
+}
+
+// Frame runs a single frame of the game using the given input state. Game.Update and
+// Game.Draw are called, Game.DrawToSurface is not called, as there is no surface.
+// All draw commands are flushed before Frame returns. Errors reported while
+// rendering the frame, e.g. a broken shader, are returned too.
+func (h *Headless) Frame(inputState glimpse.InputState) error {
+	loopState := h.loopState
+
+	if CurrentContext().DeviceLost() {
+		return errors.New("device lost")
+	}
+
+	ClearRenderError()
+
+	// get requested layout
+	layout := loopState.Game.
+		Layout(h.surfaceWidth, h.surfaceHeight).
+		withDefaults(h.surfaceWidth, h.surfaceHeight)
+
+	allocateCanvas(CurrentContext(), loopState, layout)
+
+	currentInputState.reset()
+	currentInputState.set(inputState)
+
+	surfaceSize := glm.Vec2[uint32]{h.surfaceWidth, h.surfaceHeight}.ToVec2f()
+	updateScreenTransform(surfaceSize, loopState.Canvas.Sizef())
+
+	// run game.Initialize and game.Update
+	err := performGameUpdate(loopState)
+	if err != nil {
+		return fmt.Errorf("update game: %w", err)
+	}
+
+	performGameDraw(loopState, layout)
+
+	// flushes any outstanding pipelines
+	SwitchToCommand(nil)
+
+	// recycle temporary textures for the next frame
+	texturePool.Get().EndFrame()
+
+	if err := RenderError(); err != nil {
+		return fmt.Errorf("render frame: %w", err)
+	}
+
+	return nil
+}
+
+// Canvas returns the offscreen canvas the game has drawn to in the last frame.
+// Returns nil if no frame was run yet.
+func (h *Headless) Canvas() *Image {
+	return h.loopState.Canvas
+}
+
+// Release releases the canvas. The Headless instance must not be used afterward.
+func (h *Headless) Release() {
+	if h.loopState.Canvas != nil {
+		h.loopState.Canvas.Texture().Release()
+		h.loopState.Canvas = nil
+	}
+}
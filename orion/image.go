@@ -1,7 +1,9 @@
 package orion
 
 import (
+	"bytes"
 	"fmt"
+	"image"
 
 	"github.com/oliverbestmann/pulse/glm"
 	"github.com/oliverbestmann/pulse/pulse"
@@ -41,6 +43,35 @@ type DrawImageOptions struct {
 
 	// FilterMode defaults to linear
 	FilterMode wgpu.FilterMode
+
+	// MipmapFilter defines how to filter between mip levels if the source
+	// image has mipmaps. Defaults to linear, which results in trilinear
+	// filtering if FilterMode is also linear.
+	MipmapFilter wgpu.MipmapFilterMode
+
+	// AddressModeU and AddressModeV define how to sample outside the source
+	// image, defaults to clamp to edge. Use repeat together with a SourceRegion
+	// larger than the image to draw tiled backgrounds or scrolling textures.
+	// Addressing applies to the whole texture, so repeating a sub image
+	// repeats the full texture the image was cut from.
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// SourceRegion is the region of the source image to draw in pixels. The image is
+	// drawn with the size of the region. Defaults to the full image if empty.
+	SourceRegion pulse.Rectangle2f
+
+	// FlipX and FlipY mirror the image horizontally or vertically.
+	FlipX, FlipY bool
+
+	// Material to draw the image with a custom shader, might be nil. Custom shaders
+	// use the bindings in group 0 of the single texture pulse/commands/sprite2d.wgsl.
+	Material *Material
+
+	// Layer to draw the image on. Images on higher layers are drawn on top of images
+	// on lower layers. Only used within BeginLayered and EndLayered, or if
+	// LayoutOptions.SortByLayer is set. A common choice is the y coordinate of a sprite.
+	Layer float32
 }
 
 func (i *Image) DrawImage(source *Image, opts *DrawImageOptions) {
@@ -58,17 +89,41 @@ func (i *Image) DrawImage(source *Image, opts *DrawImageOptions) {
 		filterMode = wgpu.FilterModeLinear
 	}
 
-	sprites := spriteCommand.Get()
-	SwitchToCommand(sprites)
+	var mipmapFilter = opts.MipmapFilter
+	if mipmapFilter == wgpu.MipmapFilterModeUndefined {
+		mipmapFilter = wgpu.MipmapFilterModeLinear
+	}
+
+	addressModeU := addressModeOrDefault(opts.AddressModeU)
+	addressModeV := addressModeOrDefault(opts.AddressModeV)
 
-	sprites.Draw(i.texture, source.texture, commands.DrawSpriteOptions{
+	spriteOpts := commands.DrawSpriteOptions{
 		Transform:    opts.Transform,
 		Color:        opts.ColorScale,
 		FilterMode:   filterMode,
+		MipmapFilter: mipmapFilter,
 		BlendState:   blendState,
-		AddressModeU: wgpu.AddressModeClampToEdge,
-		AddressModeV: wgpu.AddressModeClampToEdge,
-	})
+		AddressModeU: addressModeU,
+		AddressModeV: addressModeV,
+		SourceRegion: opts.SourceRegion,
+		FlipX:        opts.FlipX,
+		FlipY:        opts.FlipY,
+	}
+
+	if opts.Material != nil {
+		spriteOpts.Shader = opts.Material.shader
+		spriteOpts.Material = opts.Material.resolve()
+	}
+
+	if layered.active() {
+		layered.drawSprite(opts.Layer, i.texture, source.texture, spriteOpts)
+		return
+	}
+
+	sprites := spriteCommand.Get()
+	SwitchToCommand(sprites)
+
+	sprites.Draw(i.texture, source.texture, spriteOpts)
 }
 
 func (i *Image) DrawImagesFromGPU(source *Image, buf *wgpu.Buffer, count uint, opts *DrawImageOptions) {
@@ -86,22 +141,50 @@ func (i *Image) DrawImagesFromGPU(source *Image, buf *wgpu.Buffer, count uint, o
 		filterMode = wgpu.FilterModeLinear
 	}
 
+	var mipmapFilter = opts.MipmapFilter
+	if mipmapFilter == wgpu.MipmapFilterModeUndefined {
+		mipmapFilter = wgpu.MipmapFilterModeLinear
+	}
+
+	addressModeU := addressModeOrDefault(opts.AddressModeU)
+	addressModeV := addressModeOrDefault(opts.AddressModeV)
+
 	sprites := spriteCommand.Get()
 	SwitchToCommand(sprites)
 
-	sprites.DrawFromGPU(i.texture, source.texture, commands.DrawSpriteFromGPUOptions{
+	gpuOpts := commands.DrawSpriteFromGPUOptions{
 		Buffer:        buf,
 		InstanceCount: count,
 		FilterMode:    filterMode,
+		MipmapFilter:  mipmapFilter,
 		BlendState:    blendState,
-		AddressModeU:  wgpu.AddressModeClampToEdge,
-		AddressModeV:  wgpu.AddressModeClampToEdge,
-	})
+		AddressModeU:  addressModeU,
+		AddressModeV:  addressModeV,
+	}
+
+	if opts.Material != nil {
+		gpuOpts.Shader = opts.Material.shader
+		gpuOpts.Material = opts.Material.resolve()
+	}
+
+	sprites.DrawFromGPU(i.texture, source.texture, gpuOpts)
+}
+
+func addressModeOrDefault(mode wgpu.AddressMode) wgpu.AddressMode {
+	if mode == wgpu.AddressModeUndefined {
+		return wgpu.AddressModeClampToEdge
+	}
+
+	return mode
 }
 
 type Vertex2d struct {
 	Position glm.Vec2f
 	Color    Color
+
+	// UV coordinates within the texture, in range 0 to 1. Only used
+	// if DrawTrianglesOptions.Texture is set.
+	UV glm.Vec2f
 }
 
 type DrawTrianglesOptions struct {
@@ -109,38 +192,87 @@ type DrawTrianglesOptions struct {
 	ColorScale Color
 	BlendState wgpu.BlendState
 	Shader     string
+
+	// Texture to sample from using the uv coordinates of the vertices. The sampled
+	// color is multiplied with the vertex color. A sub image maps uv 0 to 1 to its region.
+	Texture *Image
+
+	// Sampler configuration for the texture, see DrawImageOptions
+	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// Material to draw the triangles with, replaces Shader if set
+	Material *Material
+
+	// Layer to draw the triangles on, see DrawImageOptions.Layer
+	Layer float32
 }
 
+// DrawTriangles draws a list of triangles, each three vertices form a triangle.
 func (i *Image) DrawTriangles(vertices []Vertex2d, opts *DrawTrianglesOptions) {
-	mesh := mesh2dCommand.Get()
-	SwitchToCommand(mesh)
+	i.drawTriangles(vertices, nil, opts)
+}
+
+// DrawIndexedTriangles draws triangles made of the vertices referenced by indices, each
+// three indices form a triangle. Use this to share vertices between triangles,
+// e.g. to draw deformable sprites.
+func (i *Image) DrawIndexedTriangles(vertices []Vertex2d, indices []uint32, opts *DrawTrianglesOptions) {
+	i.drawTriangles(vertices, indices, opts)
+}
 
+func (i *Image) drawTriangles(vertices []Vertex2d, indices []uint32, opts *DrawTrianglesOptions) {
 	if opts == nil {
 		opts = &DrawTrianglesOptions{}
 	}
 
+	meshOpts := meshOptionsOf(opts)
+	meshOpts.Vertices = toMeshVertices(vertices)
+
+	if layered.active() {
+		layered.drawMesh(opts.Layer, i.texture, indices, meshOpts)
+		return
+	}
+
+	mesh := mesh2dCommand.Get()
+	SwitchToCommand(mesh)
+
+	if indices != nil {
+		mesh.DrawIndexedTriangles(i.texture, indices, meshOpts)
+	} else {
+		mesh.DrawTriangles(i.texture, meshOpts)
+	}
+}
+
+func meshOptionsOf(opts *DrawTrianglesOptions) commands.DrawMesh2dOptions {
 	blendState := BlendStateDefault
 
 	if opts.BlendState != (wgpu.BlendState{}) {
 		blendState = opts.BlendState
 	}
 
-	transformed := make([]commands.MeshVertex, len(vertices))
+	meshOpts := commands.DrawMesh2dOptions{
+		Transform:    opts.Transform,
+		BlendState:   blendState,
+		Color:        opts.ColorScale.ToVec(),
+		Shader:       opts.Shader,
+		FilterMode:   opts.FilterMode,
+		MipmapFilter: opts.MipmapFilter,
+		AddressModeU: opts.AddressModeU,
+		AddressModeV: opts.AddressModeV,
+	}
 
-	for idx := range vertices {
-		transformed[idx] = commands.MeshVertex{
-			Position: vertices[idx].Position,
-			Color:    vertices[idx].Color.ToVec(),
-		}
+	if opts.Texture != nil {
+		meshOpts.Texture = opts.Texture.texture
 	}
 
-	mesh.DrawTriangles(i.texture, commands.DrawMesh2dOptions{
-		Transform:  opts.Transform,
-		BlendState: blendState,
-		Vertices:   transformed,
-		Color:      opts.ColorScale.ToVec(),
-		Shader:     opts.Shader,
-	})
+	if opts.Material != nil {
+		meshOpts.Shader = opts.Material.shader
+		meshOpts.Material = opts.Material.resolve()
+	}
+
+	return meshOpts
 }
 
 func (i *Image) Sizef() glm.Vec2f {
@@ -177,6 +309,39 @@ func (i *Image) WritePixels(pixels []byte) {
 	i.texture.WritePixels(CurrentContext(), pixels)
 }
 
+// ReadPixels reads the raw pixels of the given region of the image back into cpu memory.
+// The region is relative to the image. See pulse.Texture.ReadPixels for more details.
+// This flushes all outstanding draw commands and waits for the gpu to finish.
+func (i *Image) ReadPixels(region pulse.Rectangle2u) ([]byte, error) {
+	// ensure that all draw calls have been submitted
+	SwitchToCommand(nil)
+
+	return i.texture.ReadPixels(CurrentContext(), region)
+}
+
+// ToImage reads the content of the image back into cpu memory. See pulse.Texture.ToImage
+// on how the pixels are converted.
+// This flushes all outstanding draw commands and waits for the gpu to finish.
+func (i *Image) ToImage() (image.Image, error) {
+	// ensure that all draw calls have been submitted
+	SwitchToCommand(nil)
+
+	img, err := i.texture.ToImage(CurrentContext())
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+
+	return img, nil
+}
+
+// GenerateMipmaps renders all mip levels of the image from its first level.
+func (i *Image) GenerateMipmaps() {
+	// ensure that all draw calls have been submitted
+	SwitchToCommand(nil)
+
+	i.texture.GenerateMipmaps(CurrentContext())
+}
+
 func (i *Image) Texture() *pulse.Texture {
 	return i.texture
 }
@@ -186,13 +351,30 @@ type DecodeImageOptions struct {
 	// This is useful if the color values are representing data, e.g. a normal map or a height map.
 	// The default for image files like jpeg or png is non linear srgb.
 	LinearRGBA bool
+
+	// Mipmaps generates a full mip chain for the image. This reduces aliasing
+	// when the image is drawn downscaled.
+	Mipmaps bool
 }
 
 func DecodeImageFromBytes(buf []byte, opts *DecodeImageOptions) (*Image, error) {
-	srgb := opts == nil || !opts.LinearRGBA
+	if opts == nil {
+		opts = &DecodeImageOptions{}
+	}
+
+	srgb := !opts.LinearRGBA
 
 	ctx := currentContext.Get()
 
+	if opts.Mipmaps {
+		src, _, err := image.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return nil, fmt.Errorf("decoding image: %w", err)
+		}
+
+		return asImage(pulse.NewMipmappedTextureFromImage(ctx, src, srgb)), nil
+	}
+
 	texture, err := pulse.DecodeTextureFromMemory(ctx, buf, srgb)
 	if err != nil {
 		return nil, fmt.Errorf("decoding image: %w", err)
@@ -210,6 +392,42 @@ type NewImageOptions struct {
 
 	// Helpful label for wgpu error messages
 	Label string
+
+	// Number of mip levels, see pulse.NewTextureOptions. Call Image.GenerateMipmaps
+	// after drawing to the image to fill the mip levels.
+	MipLevels uint32
+}
+
+// AcquireTempImage returns an image from the frame scoped texture pool. The image is
+// recycled at the end of the frame, it must not be used in later frames. Use this for
+// intermediate render targets that are needed every frame, e.g. for post processing.
+// Call Image.Clear before drawing to it, the image may contain content of a previous frame.
+// Clips pushed to the image are dropped when it is recycled.
+func AcquireTempImage(width, height uint32, opts *NewImageOptions) *Image {
+	// copy the options, we must not modify the callers value
+	var imageOpts NewImageOptions
+	if opts != nil {
+		imageOpts = *opts
+	}
+
+	if imageOpts.Format == 0 {
+		imageOpts.Format = wgpu.TextureFormatRGBA8Unorm
+	}
+
+	texture := texturePool.Get().Acquire(pulse.NewTextureOptions{
+		Width:     width,
+		Height:    height,
+		Format:    imageOpts.Format,
+		MSAA:      imageOpts.MSAA,
+		MipLevels: imageOpts.MipLevels,
+	})
+
+	return asImage(texture)
+}
+
+// TexturePool returns the frame scoped texture pool backing AcquireTempImage.
+func TexturePool() *pulse.TexturePool {
+	return texturePool.Get()
 }
 
 func NewImage(width, height uint32, opts *NewImageOptions) *Image {
@@ -222,12 +440,19 @@ func NewImage(width, height uint32, opts *NewImageOptions) *Image {
 	}
 
 	texture := pulse.NewTexture(currentContext.Get(), pulse.NewTextureOptions{
-		Width:  width,
-		Height: height,
-		Format: opts.Format,
-		MSAA:   opts.MSAA,
-		Label:  opts.Label,
+		Width:     width,
+		Height:    height,
+		Format:    opts.Format,
+		MSAA:      opts.MSAA,
+		Label:     opts.Label,
+		MipLevels: opts.MipLevels,
 	})
 
 	return asImage(texture)
 }
+
+// NewImageFromTexture creates an image drawing to and from an existing texture,
+// e.g. one created using the pulse package.
+func NewImageFromTexture(texture *pulse.Texture) *Image {
+	return asImage(texture)
+}
@@ -0,0 +1,222 @@
+package orion
+
+import (
+	"slices"
+
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/pulse/pulse/commands"
+)
+
+// layeredCommand records sprites and meshes and draws them sorted by their
+// layer when flushed. Draws with the same layer keep their order.
+type layeredCommand struct {
+	// number of nested BeginLayered calls
+	depth int
+
+	draws []layeredDraw
+}
+
+type layeredDraw struct {
+	layer  float32
+	target *pulse.Texture
+
+	// either a sprite or a mesh
+	source *pulse.Texture
+	sprite commands.DrawSpriteOptions
+	mesh   *commands.DrawMesh2dOptions
+
+	// indices of an indexed mesh
+	indices []uint32
+
+	// set instead of the vertices of mesh for a static mesh
+	staticMesh *commands.Mesh2dBuffer
+}
+
+var layered layeredCommand
+
+// BeginLayered starts recording sprites and meshes drawn by Image.DrawImage,
+// Image.DrawTriangles and Image.DrawMesh. The recorded draws are sorted by their
+// Layer and drawn when EndLayered is called, draws with the same layer keep their order.
+// Draws into an image are not sorted past draws that use the image as a source.
+//
+// Any other operation, like Image.Clear, DebugText or vector drawing, draws
+// everything recorded so far before it is executed. Calls can be nested, sorting
+// happens when the outermost scope ends.
+func BeginLayered() {
+	layered.depth += 1
+}
+
+// EndLayered ends the scope started with BeginLayered and draws
+// all recorded sprites and meshes in the order of their layer.
+func EndLayered() {
+	if layered.depth == 0 {
+		panic("EndLayered called without BeginLayered")
+	}
+
+	layered.depth -= 1
+
+	if layered.depth == 0 && currentCommand == &layered {
+		flushCurrentCommand()
+	}
+}
+
+func (l *layeredCommand) active() bool {
+	return l.depth > 0
+}
+
+func (l *layeredCommand) drawSprite(layer float32, target, source *pulse.Texture, opts commands.DrawSpriteOptions) {
+	SwitchToCommand(l)
+
+	l.draws = append(l.draws, layeredDraw{
+		layer:  layer,
+		target: target,
+		source: source,
+		sprite: opts,
+	})
+}
+
+func (l *layeredCommand) drawMesh(layer float32, target *pulse.Texture, indices []uint32, opts commands.DrawMesh2dOptions) {
+	SwitchToCommand(l)
+
+	l.draws = append(l.draws, layeredDraw{
+		layer:   layer,
+		target:  target,
+		mesh:    &opts,
+		indices: slices.Clone(indices),
+	})
+}
+
+func (l *layeredCommand) drawStaticMesh(layer float32, target *pulse.Texture, mesh *commands.Mesh2dBuffer, opts commands.DrawMesh2dOptions) {
+	SwitchToCommand(l)
+
+	l.draws = append(l.draws, layeredDraw{
+		layer:      layer,
+		target:     target,
+		mesh:       &opts,
+		staticMesh: mesh,
+	})
+}
+
+func (l *layeredCommand) Flush() {
+	defer l.reset()
+
+	// Draws are sorted within segments only. A segment ends before a draw that reads
+	// a texture written within the segment, or writes a texture read within the
+	// segment. This way, drawing into an image and then drawing that image keeps
+	// working, even if the layers of the draws are in a different order.
+	written := map[*pulse.Texture]bool{}
+	read := map[*pulse.Texture]bool{}
+
+	start := 0
+
+	for idx := range l.draws {
+		draw := &l.draws[idx]
+
+		target := draw.target.Root()
+		sources := draw.sources()
+
+		conflict := read[target]
+		for _, source := range sources {
+			conflict = conflict || written[source]
+		}
+
+		if conflict {
+			sortByLayer(l.draws[start:idx])
+			start = idx
+
+			clear(written)
+			clear(read)
+		}
+
+		written[target] = true
+
+		for _, source := range sources {
+			read[source] = true
+		}
+	}
+
+	sortByLayer(l.draws[start:])
+
+	sprites := spriteCommand.Get()
+	mesh := mesh2dCommand.Get()
+
+	// the command that has unflushed draws
+	var pending Command
+
+	for idx := range l.draws {
+		draw := &l.draws[idx]
+
+		if draw.mesh != nil {
+			if pending == sprites {
+				sprites.Flush()
+			}
+
+			switch {
+			case draw.staticMesh != nil:
+				mesh.DrawMesh(draw.target, draw.staticMesh, *draw.mesh)
+			case draw.indices != nil:
+				mesh.DrawIndexedTriangles(draw.target, draw.indices, *draw.mesh)
+			default:
+				mesh.DrawTriangles(draw.target, *draw.mesh)
+			}
+
+			pending = mesh
+		} else {
+			if pending == mesh {
+				mesh.Flush()
+			}
+
+			sprites.Draw(draw.target, draw.source, draw.sprite)
+			pending = sprites
+		}
+	}
+
+	if pending != nil {
+		pending.Flush()
+	}
+}
+
+// sources returns the root textures the draw samples from.
+func (d *layeredDraw) sources() []*pulse.Texture {
+	var sources []*pulse.Texture
+	var material *commands.Material
+
+	if d.mesh != nil {
+		if d.mesh.Texture != nil {
+			sources = append(sources, d.mesh.Texture.Root())
+		}
+
+		material = d.mesh.Material
+	} else {
+		sources = append(sources, d.source.Root())
+		material = d.sprite.Material
+	}
+
+	if material != nil {
+		for _, texture := range material.Textures {
+			sources = append(sources, texture.Root())
+		}
+	}
+
+	return sources
+}
+
+// sortByLayer sorts the draws by their layer, draws with the same layer keep their order.
+func sortByLayer(draws []layeredDraw) {
+	slices.SortStableFunc(draws, func(a, b layeredDraw) int {
+		switch {
+		case a.layer < b.layer:
+			return -1
+		case a.layer > b.layer:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+func (l *layeredCommand) reset() {
+	// drop references to the textures
+	clear(l.draws)
+	l.draws = l.draws[:0]
+}
@@ -21,6 +21,12 @@ type LoopState struct {
 }
 
 func loopOnce(viewState *pulse.View, loopState *LoopState, inputState glimpse.UpdateInputState) error {
+	if viewState.DeviceLost() {
+		if err := recreateDevice(viewState, loopState); err != nil {
+			return fmt.Errorf("recreate device: %w", err)
+		}
+	}
+
 	DebugOverlay.StartFrame()
 
 	// get surface size for next frame
@@ -45,27 +51,18 @@ func loopOnce(viewState *pulse.View, loopState *LoopState, inputState glimpse.Up
 		withDefaults(surfaceWidth, surfaceHeight)
 
 	// request a new surface if needed
-	if !layoutIsCompatible(viewState.Context, layout, loopState.Canvas) {
-		if loopState.Canvas != nil {
-			loopState.Canvas.Texture().Release()
-			loopState.Canvas = nil
-		}
-
-		slog.Info("Allocate new offscreen render target",
-			slog.Int("width", int(layout.Width)),
-			slog.Int("height", int(layout.Height)))
-
-		loopState.Canvas = NewImage(layout.Width, layout.Height, &NewImageOptions{
-			Label:  "OffscreenTarget",
-			Format: layout.Format,
-			MSAA:   layout.MSAA,
-		})
-	}
+	allocateCanvas(viewState.Context, loopState, layout)
 
 	DebugOverlay.StartGetCurrentTexture()
 
 	// get the surface texture (the actual screen)
-	surface := CurrentContext().Surface.GetCurrentTexture()
+	surface, err := viewState.CurrentTexture()
+	if err != nil {
+		// the surface was reconfigured, try again in the next frame
+		slog.Debug("Skip frame", slog.Any("err", err))
+		DebugOverlay.EndFrame()
+		return nil
+	}
 
 	defer func() {
 		if surface != nil {
@@ -82,17 +79,23 @@ func loopOnce(viewState *pulse.View, loopState *LoopState, inputState glimpse.Up
 	updateScreenTransform(surfaceSize, loopState.Canvas.Sizef())
 
 	// run game.Initialize and game.Update
-	err := performGameUpdate(loopState)
+	err = performGameUpdate(loopState)
 	if err != nil {
 		return fmt.Errorf("update game: %w", err)
 	}
 
 	// draw to canvas first
 	DebugOverlay.StartGameDraw()
-	loopState.Game.Draw(loopState.Canvas)
+	performGameDraw(loopState, layout)
+
+	// apply post processing effects to the canvas
+	offscreen := loopState.Canvas
+	if layout.PostProcess != nil {
+		offscreen = layout.PostProcess.Apply(offscreen)
+	}
 
 	// finalize drawing
-	err = drawToSurface(viewState, loopState.Game, surface, loopState.Canvas)
+	err = drawToSurface(viewState, loopState.Game, surface, offscreen)
 	if err != nil {
 		return fmt.Errorf("drawToSurface: %w", err)
 	}
@@ -103,11 +106,71 @@ func loopOnce(viewState *pulse.View, loopState *LoopState, inputState glimpse.Up
 	// we do not need to release the surface texture if present was successful
 	surface = nil
 
+	// recycle temporary textures for the next frame
+	texturePool.Get().EndFrame()
+
 	DebugOverlay.EndFrame()
 
 	return nil
 }
 
+// recreateDevice requests a new device after the previous one was lost. All
+// state bound to the previous device is dropped and created again.
+func recreateDevice(viewState *pulse.View, loopState *LoopState) error {
+	slog.Warn("Recreate lost device")
+
+	ctx := viewState.Context
+
+	// the commands and the canvas belong to the previous device
+	resetCommands()
+
+	loopState.Canvas = nil
+	DebugOverlay.white = nil
+
+	if err := ctx.RecreateDevice(); err != nil {
+		return err
+	}
+
+	initializeCommands(ctx)
+
+	// force the surface to be configured again with the new device
+	loopState.SurfaceWidth = 0
+	loopState.SurfaceHeight = 0
+
+	ClearRenderError()
+
+	if handler, ok := loopState.Game.(DeviceRecreatedHandler); ok && loopState.Initialized {
+		if err := handler.DeviceRecreated(); err != nil {
+			return fmt.Errorf("restore game resources: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// allocateCanvas allocates a new offscreen render target, if the current
+// canvas is not compatible with the requested layout
+func allocateCanvas(ctx *pulse.Context, loopState *LoopState, layout LayoutOptions) {
+	if layoutIsCompatible(ctx, layout, loopState.Canvas) {
+		return
+	}
+
+	if loopState.Canvas != nil {
+		loopState.Canvas.Texture().Release()
+		loopState.Canvas = nil
+	}
+
+	slog.Info("Allocate new offscreen render target",
+		slog.Int("width", int(layout.Width)),
+		slog.Int("height", int(layout.Height)))
+
+	loopState.Canvas = NewImage(layout.Width, layout.Height, &NewImageOptions{
+		Label:  "OffscreenTarget",
+		Format: layout.Format,
+		MSAA:   layout.MSAA,
+	})
+}
+
 func performGameUpdate(loopState *LoopState) error {
 	DebugOverlay.StartGameUpdate()
 
@@ -125,6 +188,17 @@ func performGameUpdate(loopState *LoopState) error {
 	return nil
 }
 
+// performGameDraw calls Game.Draw, sorting the draw calls
+// by their layer if requested by the layout.
+func performGameDraw(loopState *LoopState, layout LayoutOptions) {
+	if layout.SortByLayer {
+		BeginLayered()
+		defer EndLayered()
+	}
+
+	loopState.Game.Draw(loopState.Canvas)
+}
+
 func updateScreenTransform(surfaceSize glm.Vec2f, offscreenSize glm.Vec2f) {
 	screenTransform := DefaultScreenTransform(surfaceSize, offscreenSize)
 	screenTransformInv := DefaultScreenTransformInv(surfaceSize, offscreenSize)
@@ -156,6 +230,9 @@ func drawToSurface(ctx *pulse.View, game Game, surface *wgpu.Texture, screen *Im
 	// then paint canvas to the surface
 	game.DrawToSurface(surfaceImage, screen)
 
+	// show rendering errors on top of everything else
+	drawRenderError(surfaceImage)
+
 	// flushes any outstanding pipelines
 	SwitchToCommand(nil)
 
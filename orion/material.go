@@ -0,0 +1,193 @@
+package orion
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/oliverbestmann/pulse/pulse/commands"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// Material pairs a custom shader with its own uniforms and images. The shader
+// receives the resources of the material in bind group 1:
+//
+//   - binding 0: the uniforms, if SetUniforms was called
+//   - binding 1: a sampler, if the material has images
+//   - binding 2 and following: the images
+//
+// Bind group 0 is the same as for the default shader of the draw call.
+// Draw calls with the same shader share a pipeline, but draw calls
+// with different materials are not batched.
+//
+// The uniforms survive a recreation of the device, the images
+// must be replaced using SetImage.
+type Material struct {
+	shader   string
+	material commands.Material
+
+	// size of the uniform buffer in bytes
+	uniformSize uint64
+
+	// the uniform data, to upload it again after the device was recreated
+	uniforms   []byte
+	generation uint32
+}
+
+type NewMaterialOptions struct {
+	// Images to bind, at most commands.MaxMaterialTextures. The shader samples
+	// the full texture of an image, sub images and atlas images are not supported.
+	Images []*Image
+
+	// FilterMode to sample the images with, defaults to linear
+	FilterMode wgpu.FilterMode
+
+	// Address modes to sample the images with, defaults to clamp to edge
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+}
+
+func NewMaterial(shader string, opts *NewMaterialOptions) *Material {
+	if opts == nil {
+		opts = &NewMaterialOptions{}
+	}
+
+	if len(opts.Images) > commands.MaxMaterialTextures {
+		panic(fmt.Sprintf("material supports at most %d images", commands.MaxMaterialTextures))
+	}
+
+	filterMode := opts.FilterMode
+	if filterMode == wgpu.FilterModeUndefined {
+		filterMode = wgpu.FilterModeLinear
+	}
+
+	m := &Material{shader: shader}
+
+	m.material.Sampler = wgpu.SamplerDescriptor{
+		Label:         "Material-Sampler",
+		AddressModeU:  addressModeOrDefault(opts.AddressModeU),
+		AddressModeV:  addressModeOrDefault(opts.AddressModeV),
+		AddressModeW:  wgpu.AddressModeUndefined,
+		MagFilter:     filterMode,
+		MinFilter:     filterMode,
+		MipmapFilter:  wgpu.MipmapFilterModeLinear,
+		LodMinClamp:   0,
+		LodMaxClamp:   32,
+		MaxAnisotropy: 1,
+	}
+
+	for _, image := range opts.Images {
+		if err := checkMaterialImage(image); err != nil {
+			panic(err)
+		}
+
+		m.material.Textures = append(m.material.Textures, image.texture)
+	}
+
+	return m
+}
+
+// Shader returns the shader source of the material.
+func (m *Material) Shader() string {
+	return m.shader
+}
+
+// SetUniforms uploads the raw uniform data. The data must match the memory layout of
+// the uniform struct declared in the shader. Draw calls recorded before are flushed,
+// so they still see the previous values. Passing empty data removes the uniforms.
+func (m *Material) SetUniforms(data []byte) {
+	SwitchToCommand(nil)
+
+	if len(data) == 0 {
+		m.Release()
+		return
+	}
+
+	ctx := CurrentContext()
+
+	// uniform buffers are sized in multiples of 16 bytes
+	size := (uint64(len(data)) + 15) &^ 15
+
+	stale := m.generation != ctx.Generation()
+
+	if m.material.Uniforms == nil || m.uniformSize != size || stale {
+		if m.material.Uniforms != nil {
+			m.material.Uniforms.Release()
+		}
+
+		m.material.Uniforms = ctx.CreateBuffer(&wgpu.BufferDescriptor{
+			Label: "Material.Uniforms",
+			Usage: wgpu.BufferUsageUniform | wgpu.BufferUsageCopyDst,
+			Size:  size,
+		})
+
+		m.uniformSize = size
+		m.generation = ctx.Generation()
+	}
+
+	// pad the data to the size of the buffer
+	m.uniforms = make([]byte, size)
+	copy(m.uniforms, data)
+
+	ctx.WriteBuffer(m.material.Uniforms, 0, m.uniforms)
+}
+
+// resolve returns the material to pass to the draw commands. If the device was
+// recreated since the uniforms were uploaded, the uniforms are uploaded again.
+func (m *Material) resolve() *commands.Material {
+	if m.material.Uniforms != nil && m.generation != CurrentContext().Generation() {
+		m.SetUniforms(m.uniforms)
+	}
+
+	return &m.material
+}
+
+// SetMaterialUniforms uploads the value as uniforms of the material, see Material.SetUniforms.
+// The value should be a struct of fixed size types, e.g. float32 and glm.Vec4f, laid out
+// as required by WGSL.
+func SetMaterialUniforms[T any](m *Material, value T) {
+	m.SetUniforms(wgpu.ToBytes([]T{value}))
+}
+
+// SetImage replaces the image at the given index. Setting the image at the index
+// following the last image adds a new image. Draw calls recorded before are flushed.
+// Returns an error if the index is out of range or the image is a sub image.
+func (m *Material) SetImage(idx int, image *Image) error {
+	if idx < 0 || idx > len(m.material.Textures) || idx >= commands.MaxMaterialTextures {
+		return fmt.Errorf("material image index %d out of range", idx)
+	}
+
+	if err := checkMaterialImage(image); err != nil {
+		return err
+	}
+
+	SwitchToCommand(nil)
+
+	if idx == len(m.material.Textures) {
+		m.material.Textures = append(m.material.Textures, image.texture)
+		return nil
+	}
+
+	m.material.Textures[idx] = image.texture
+
+	return nil
+}
+
+// checkMaterialImage returns an error if the image can not be bound to a material.
+// The material binds the full texture, the region of a sub image would be lost.
+func checkMaterialImage(image *Image) error {
+	if image.texture.IsSubTexture() {
+		return errors.New("material images must not be sub images")
+	}
+
+	return nil
+}
+
+// Release releases the uniform buffer of the material. Images are not released.
+func (m *Material) Release() {
+	if m.material.Uniforms != nil {
+		m.material.Uniforms.Release()
+		m.material.Uniforms = nil
+	}
+
+	m.uniforms = nil
+}
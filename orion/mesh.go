@@ -0,0 +1,110 @@
+package orion
+
+import (
+	"errors"
+
+	"github.com/oliverbestmann/pulse/pulse/commands"
+)
+
+// Mesh is a triangle mesh stored on the gpu. Use it for large meshes that rarely
+// change, e.g. terrain, as the vertices are not uploaded again each frame.
+// Draw a mesh using Image.DrawMesh. A mesh does not survive a recreation of
+// the device and must be created again, see DeviceRecreatedHandler.
+type Mesh struct {
+	buffer *commands.Mesh2dBuffer
+
+	// generation of the device the buffers were created with
+	generation uint32
+}
+
+// NewMesh uploads the vertices and indices to the gpu, each three indices form a triangle.
+// If indices is nil, each three vertices form a triangle.
+func NewMesh(vertices []Vertex2d, indices []uint32) *Mesh {
+	if indices == nil {
+		indices = make([]uint32, len(vertices)/3*3)
+		for idx := range indices {
+			indices[idx] = uint32(idx)
+		}
+	}
+
+	ctx := CurrentContext()
+
+	buffer := commands.NewMesh2dBuffer(ctx, toMeshVertices(vertices), indices)
+	return &Mesh{buffer: buffer, generation: ctx.Generation()}
+}
+
+// VertexCount returns the number of vertices of the mesh.
+func (m *Mesh) VertexCount() int {
+	return int(m.buffer.VertexCount())
+}
+
+// IndexCount returns the number of indices of the mesh.
+func (m *Mesh) IndexCount() int {
+	return int(m.buffer.IndexCount())
+}
+
+// UpdateVertices replaces the vertices starting at offset. The number
+// of vertices of a mesh can not be changed, vertices exceeding the mesh
+// are reported to the context and nothing is updated.
+func (m *Mesh) UpdateVertices(offset int, vertices []Vertex2d) {
+	// draw calls recorded so far must see the previous vertices
+	SwitchToCommand(nil)
+
+	m.buffer.WriteVertices(CurrentContext(), uint32(offset), toMeshVertices(vertices))
+}
+
+// UpdateIndices replaces the indices starting at offset. The number
+// of indices of a mesh can not be changed. If the indices exceed the mesh
+// or refer to missing vertices, the error is reported to the context and
+// nothing is updated.
+func (m *Mesh) UpdateIndices(offset int, indices []uint32) {
+	// draw calls recorded so far must see the previous indices
+	SwitchToCommand(nil)
+
+	m.buffer.WriteIndices(CurrentContext(), uint32(offset), indices)
+}
+
+// Release releases the gpu buffers of the mesh.
+func (m *Mesh) Release() {
+	m.buffer.Release()
+}
+
+// DrawMesh draws the mesh, only the transform and the color are uploaded. As with
+// DrawTriangles, the uv coordinates of the mesh refer to opts.Texture, even if it
+// is a sub image.
+func (i *Image) DrawMesh(mesh *Mesh, opts *DrawTrianglesOptions) {
+	if opts == nil {
+		opts = &DrawTrianglesOptions{}
+	}
+
+	if mesh.generation != CurrentContext().Generation() {
+		CurrentContext().ReportError(errors.New("mesh was created with a previous device"))
+		return
+	}
+
+	meshOpts := meshOptionsOf(opts)
+
+	if layered.active() {
+		layered.drawStaticMesh(opts.Layer, i.texture, mesh.buffer, meshOpts)
+		return
+	}
+
+	mesh2d := mesh2dCommand.Get()
+	SwitchToCommand(mesh2d)
+
+	mesh2d.DrawMesh(i.texture, mesh.buffer, meshOpts)
+}
+
+func toMeshVertices(vertices []Vertex2d) []commands.MeshVertex {
+	transformed := make([]commands.MeshVertex, len(vertices))
+
+	for idx := range vertices {
+		transformed[idx] = commands.MeshVertex{
+			Position: vertices[idx].Position,
+			Color:    vertices[idx].Color.ToVec(),
+			UV:       vertices[idx].UV,
+		}
+	}
+
+	return transformed
+}
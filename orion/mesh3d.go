@@ -0,0 +1,157 @@
+package orion
+
+import (
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse/commands"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+type Vertex3d struct {
+	Position glm.Vec3f
+	Normal   glm.Vec3f
+	Color    Color
+
+	// UV coordinates within the texture, in range 0 to 1. Only used
+	// if DrawMesh3dOptions.Texture is set.
+	UV glm.Vec2f
+}
+
+// Camera3d is a perspective camera looking from Position at Target.
+type Camera3d struct {
+	Position glm.Vec3f
+	Target   glm.Vec3f
+
+	// Up direction of the camera, defaults to positive y
+	Up glm.Vec3f
+
+	// Vertical field of view, defaults to 60 degrees
+	FovY glm.Rad
+
+	// Near and far clipping planes, default to 0.1 and 1000
+	Near, Far float32
+}
+
+// ViewProjection calculates the view projection matrix of the camera
+// for a target with the given aspect ratio.
+func (c Camera3d) ViewProjection(aspect float32) glm.Mat4f {
+	up := c.Up
+	if up == (glm.Vec3f{}) {
+		up = glm.Vec3f{0, 1, 0}
+	}
+
+	fovY := c.FovY
+	if fovY == 0 {
+		fovY = math.Pi / 3
+	}
+
+	near := c.Near
+	if near == 0 {
+		near = 0.1
+	}
+
+	far := c.Far
+	if far == 0 {
+		far = 1000
+	}
+
+	projection := glm.Perspective(fovY, aspect, near, far)
+	view := glm.LookAt(c.Position, c.Target, up)
+
+	return projection.Mul(view)
+}
+
+type DrawMesh3dOptions struct {
+	// Model transform of the mesh
+	Transform glm.Mat4f
+
+	// Camera to look at the mesh with
+	Camera Camera3d
+
+	// LightDirection is the direction a directional light shines to. If
+	// zero, the mesh is not lit. Ambient is the brightness of faces facing
+	// away from the light, between 0 and 1.
+	LightDirection glm.Vec3f
+	Ambient        float32
+
+	ColorScale ColorScale
+	BlendState wgpu.BlendState
+
+	// CullMode defaults to no culling, front faces are counter-clockwise
+	CullMode wgpu.CullMode
+
+	// Texture to sample from using the uv coordinates of the vertices. The sampled
+	// color is multiplied with the vertex color. A sub image maps uv 0 to 1 to its region.
+	Texture *Image
+
+	// Sampler configuration for the texture, see DrawImageOptions
+	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// Material to draw the mesh with a custom shader, might be nil. See
+	// pulse/commands/mesh3d.wgsl for the bindings in group 0.
+	Material *Material
+}
+
+// DrawMesh3d draws triangles in 3d space, each three indices form a triangle. If indices
+// is nil, each three vertices form a triangle. Triangles are depth tested against a depth
+// texture attached to the image, which is created on first use. Image.Clear also resets
+// the depth, if called on a full image, not a sub image.
+func (i *Image) DrawMesh3d(vertices []Vertex3d, indices []uint32, opts *DrawMesh3dOptions) {
+	if opts == nil {
+		opts = &DrawMesh3dOptions{}
+	}
+
+	blendState := BlendStateDefault
+	if opts.BlendState != (wgpu.BlendState{}) {
+		blendState = opts.BlendState
+	}
+
+	size := i.Sizef()
+
+	meshOpts := commands.DrawMesh3dOptions{
+		Model:          opts.Transform,
+		ViewProjection: opts.Camera.ViewProjection(size[0] / size[1]),
+		LightDirection: opts.LightDirection,
+		Ambient:        opts.Ambient,
+		Color:          opts.ColorScale.ToVec(),
+		BlendState:     blendState,
+		CullMode:       opts.CullMode,
+		FilterMode:     opts.FilterMode,
+		MipmapFilter:   opts.MipmapFilter,
+		AddressModeU:   opts.AddressModeU,
+		AddressModeV:   opts.AddressModeV,
+	}
+
+	if opts.Texture != nil {
+		meshOpts.Texture = opts.Texture.texture
+	}
+
+	if opts.Material != nil {
+		meshOpts.Shader = opts.Material.shader
+		meshOpts.Material = opts.Material.resolve()
+	}
+
+	transformed := make([]commands.Mesh3dVertex, len(vertices))
+
+	for idx := range vertices {
+		transformed[idx] = commands.Mesh3dVertex{
+			Position: vertices[idx].Position,
+			Normal:   vertices[idx].Normal,
+			UV:       vertices[idx].UV,
+			Color:    vertices[idx].Color.ToVec(),
+		}
+	}
+
+	mesh3d := mesh3dCommand.Get()
+	SwitchToCommand(mesh3d)
+
+	if indices != nil {
+		mesh3d.DrawIndexedTriangles(i.texture, transformed, indices, meshOpts)
+	} else {
+		mesh3d.DrawTriangles(i.texture, transformed, meshOpts)
+	}
+}
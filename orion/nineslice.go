@@ -0,0 +1,193 @@
+package orion
+
+import (
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// NineSliceInsets defines the size of the borders of a nine slice image in pixels
+// of the source image. The corners are never stretched, the edges are stretched
+// in one direction and the center is stretched in both directions.
+type NineSliceInsets struct {
+	Left, Top, Right, Bottom uint32
+}
+
+type DrawNineSliceOptions struct {
+	// Color to apply
+	ColorScale ColorScale
+
+	// Transform is applied to the destination rectangle
+	Transform glm.Mat3f
+
+	// See DrawImageOptions
+	BlendState wgpu.BlendState
+	FilterMode wgpu.FilterMode
+	Layer      float32
+
+	// TileEdges repeats the edges instead of stretching them. The last tile
+	// is cut off if the edge length is not a multiple of the tile size.
+	TileEdges bool
+
+	// TileCenter repeats the center instead of stretching it.
+	TileCenter bool
+}
+
+// DrawNineSlice draws the source image to the destination rectangle, keeping
+// the size of the borders defined by insets. If the destination is smaller
+// than the borders, the borders are scaled down and the center is not drawn.
+// Insets larger than the source image are reduced to fit into the source.
+//
+// With linear filtering, pixels next to a border may bleed into the
+// neighbouring piece. Use nearest filtering for pixel art.
+func (i *Image) DrawNineSlice(source *Image, insets NineSliceInsets, dest pulse.Rectangle2f, opts *DrawNineSliceOptions) {
+	if opts == nil {
+		opts = &DrawNineSliceOptions{}
+	}
+
+	srcWidth, srcHeight := source.Width(), source.Height()
+
+	// insets larger than the source meet within the source, similar to Rectangle2.Inset
+	insets.Left, insets.Right = clampInsets(insets.Left, insets.Right, srcWidth)
+	insets.Top, insets.Bottom = clampInsets(insets.Top, insets.Bottom, srcHeight)
+
+	columns := nineSliceAxis(insets.Left, srcWidth-insets.Left-insets.Right, insets.Right, dest.Min[0], dest.Width())
+	rows := nineSliceAxis(insets.Top, srcHeight-insets.Top-insets.Bottom, insets.Bottom, dest.Min[1], dest.Height())
+
+	drawOpts := DrawImageOptions{
+		ColorScale: opts.ColorScale,
+		BlendState: opts.BlendState,
+		FilterMode: opts.FilterMode,
+		Layer:      opts.Layer,
+	}
+
+	for y, row := range rows {
+		for x, column := range columns {
+			isCenterX := x == 1
+			isCenterY := y == 1
+
+			var tileX, tileY bool
+
+			switch {
+			case isCenterX && isCenterY:
+				tileX, tileY = opts.TileCenter, opts.TileCenter
+			case isCenterX:
+				// top or bottom edge
+				tileX = opts.TileEdges
+			case isCenterY:
+				// left or right edge
+				tileY = opts.TileEdges
+			}
+
+			// tiles keep their aspect ratio, scaled like the border they belong to
+			tileScale := float32(1)
+			if tileX && !isCenterY {
+				tileScale = row.scale
+			} else if tileY && !isCenterX {
+				tileScale = column.scale
+			}
+
+			for _, spanX := range column.spans(tileX, tileScale) {
+				for _, spanY := range row.spans(tileY, tileScale) {
+					piece := source.SubImage(spanX.srcPos, spanY.srcPos, spanX.srcLen, spanY.srcLen)
+
+					drawOpts.Transform = opts.Transform.
+						Translate(spanX.dstPos, spanY.dstPos).
+						Scale(spanX.dstLen/float32(spanX.srcLen), spanY.dstLen/float32(spanY.srcLen))
+
+					i.DrawImage(piece, &drawOpts)
+				}
+			}
+		}
+	}
+}
+
+// clampInsets shrinks both insets in proportion, if they do not fit into the given size.
+func clampInsets(start, end, size uint32) (uint32, uint32) {
+	if uint64(start)+uint64(end) <= uint64(size) {
+		return start, end
+	}
+
+	start = uint32(uint64(start) * uint64(size) / (uint64(start) + uint64(end)))
+	return start, size - start
+}
+
+// nineSliceSegment is one of the three segments of a nine slice image along one axis
+type nineSliceSegment struct {
+	srcPos, srcLen uint32
+	dstPos, dstLen float32
+
+	// scale from source to destination
+	scale float32
+}
+
+// nineSliceSpan is a single piece of a segment. A stretched segment has one span,
+// a tiled segment one span for each tile.
+type nineSliceSpan struct {
+	srcPos, srcLen uint32
+	dstPos, dstLen float32
+}
+
+// nineSliceAxis splits one axis into the start border, the center and the end border.
+// The borders are scaled down if they do not fit into the destination.
+func nineSliceAxis(start, center, end uint32, dstPos, dstLen float32) [3]nineSliceSegment {
+	borderScale := float32(1)
+
+	borders := float32(start + end)
+	if borders > dstLen {
+		borderScale = max(0, dstLen) / borders
+	}
+
+	dstStart := float32(start) * borderScale
+	dstEnd := float32(end) * borderScale
+	dstCenter := max(0, dstLen-dstStart-dstEnd)
+
+	var centerScale float32
+	if center > 0 {
+		centerScale = dstCenter / float32(center)
+	}
+
+	return [3]nineSliceSegment{
+		{srcPos: 0, srcLen: start, dstPos: dstPos, dstLen: dstStart, scale: borderScale},
+		{srcPos: start, srcLen: center, dstPos: dstPos + dstStart, dstLen: dstCenter, scale: centerScale},
+		{srcPos: start + center, srcLen: end, dstPos: dstPos + dstStart + dstCenter, dstLen: dstEnd, scale: borderScale},
+	}
+}
+
+// spans returns the pieces to draw for this segment. If tile is set, the segment is filled
+// with copies of the source, each scaled by tileScale. Empty pieces are skipped.
+func (s nineSliceSegment) spans(tile bool, tileScale float32) []nineSliceSpan {
+	if s.srcLen == 0 || s.dstLen <= 0 {
+		return nil
+	}
+
+	if !tile || tileScale <= 0 {
+		return []nineSliceSpan{{srcPos: s.srcPos, srcLen: s.srcLen, dstPos: s.dstPos, dstLen: s.dstLen}}
+	}
+
+	tileLen := float32(s.srcLen) * tileScale
+
+	// ignore rounding errors, they would result in a tiny last tile
+	count := int(math.Ceil(float64(s.dstLen/tileLen) - 1e-4))
+	spans := make([]nineSliceSpan, 0, count)
+
+	for idx := range count {
+		offset := float32(idx) * tileLen
+		dstLen := min(tileLen, s.dstLen-offset)
+
+		// cut off the last tile
+		srcLen := uint32(math.Ceil(float64(dstLen / tileScale)))
+		srcLen = min(max(srcLen, 1), s.srcLen)
+
+		spans = append(spans, nineSliceSpan{
+			srcPos: s.srcPos,
+			srcLen: srcLen,
+			dstPos: s.dstPos + offset,
+			dstLen: dstLen,
+		})
+	}
+
+	return spans
+}
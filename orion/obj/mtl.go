@@ -0,0 +1,140 @@
+package obj
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+// Material is a material defined in an MTL file. Colors are
+// interpreted as linear rgb. Texture paths are relative to the
+// file system passed to Load, empty if a texture is not set.
+type Material struct {
+	Name string
+
+	Ambient  orion.Color
+	Diffuse  orion.Color
+	Specular orion.Color
+	Emissive orion.Color
+
+	// Shininess is the specular exponent
+	Shininess float32
+
+	// Opacity between 0 and 1, also applied as alpha of the diffuse color
+	Opacity float32
+
+	DiffuseTexture  string
+	SpecularTexture string
+	NormalTexture   string
+	OpacityTexture  string
+}
+
+// ParseMaterials parses an MTL file. The options Name and Dir are used,
+// texture paths are resolved relative to Dir.
+func ParseMaterials(r io.Reader, opts *Options) (map[string]*Material, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	materials := map[string]*Material{}
+
+	var current *Material
+
+	parse := func(keyword string, args []string) error {
+		if keyword == "newmtl" {
+			if len(args) == 0 {
+				return errors.New("newmtl: expected material name")
+			}
+
+			current = &Material{
+				Name:     strings.Join(args, " "),
+				Ambient:  pulse.ColorBlack,
+				Diffuse:  pulse.ColorWhite,
+				Specular: pulse.ColorBlack,
+				Emissive: pulse.ColorBlack,
+				Opacity:  1,
+			}
+
+			materials[current.Name] = current
+			return nil
+		}
+
+		if current == nil {
+			return fmt.Errorf("%s: no material defined, expected newmtl", keyword)
+		}
+
+		switch keyword {
+		case "Ka", "Kd", "Ks", "Ke":
+			values, err := parseFloats(args, 3, 3)
+			if err != nil {
+				return fmt.Errorf("%s: %w", keyword, err)
+			}
+
+			color := pulse.ColorLinearRGBA(values[0], values[1], values[2], 1)
+
+			switch keyword {
+			case "Ka":
+				current.Ambient = color
+			case "Kd":
+				current.Diffuse = color.WithAlpha(current.Opacity)
+			case "Ks":
+				current.Specular = color
+			case "Ke":
+				current.Emissive = color
+			}
+
+		case "Ns", "d", "Tr":
+			values, err := parseFloats(args, 1, 1)
+			if err != nil {
+				return fmt.Errorf("%s: %w", keyword, err)
+			}
+
+			switch keyword {
+			case "Ns":
+				current.Shininess = values[0]
+			case "d":
+				current.Opacity = values[0]
+			case "Tr":
+				current.Opacity = 1 - values[0]
+			}
+
+			if keyword != "Ns" {
+				current.Diffuse = current.Diffuse.WithAlpha(current.Opacity)
+			}
+
+		case "map_Kd", "map_Ks", "map_Bump", "map_bump", "bump", "norm", "map_d":
+			if len(args) == 0 {
+				return fmt.Errorf("%s: expected texture path", keyword)
+			}
+
+			// the path follows optional texture options like -s or -bm,
+			// assume it is the last argument
+			texture := path.Join(opts.Dir, strings.ReplaceAll(args[len(args)-1], `\`, "/"))
+
+			switch keyword {
+			case "map_Kd":
+				current.DiffuseTexture = texture
+			case "map_Ks":
+				current.SpecularTexture = texture
+			case "map_d":
+				current.OpacityTexture = texture
+			default:
+				current.NormalTexture = texture
+			}
+		}
+
+		// other statements, like the illumination model, are ignored
+		return nil
+	}
+
+	if err := scanLines(r, opts.Name, parse); err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
@@ -0,0 +1,553 @@
+// Package obj loads Wavefront OBJ models and their MTL material libraries.
+// The meshes of a model can be drawn directly using orion.Image.DrawMesh3d.
+package obj
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/orion"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+type Model struct {
+	Meshes []Mesh
+
+	// Materials of all material libraries referenced by the model, by name
+	Materials map[string]*Material
+}
+
+// Mesh is a part of a model with a single material. A new mesh starts
+// whenever the object, the group or the material changes.
+type Mesh struct {
+	// Object and Group the faces of this mesh belong to, might be empty
+	Object string
+	Group  string
+
+	// Material selected by usemtl, nil if none was selected or
+	// the material is not defined in a material library
+	Material *Material
+
+	// Vertices and indices of the triangles, each three indices form a triangle
+	Vertices []orion.Vertex3d
+	Indices  []uint32
+}
+
+// ParseError describes a syntax error in an OBJ or MTL file.
+type ParseError struct {
+	// File the error occurred in, might be empty
+	File string
+
+	// Line number, starting at 1
+	Line int
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+type Options struct {
+	// Name of the file, used in error messages
+	Name string
+
+	// FS to load material libraries from. If nil, material libraries are ignored.
+	FS fs.FS
+
+	// Dir is the directory of the obj file. Material libraries and
+	// texture paths are resolved relative to this directory.
+	Dir string
+}
+
+// Load parses the OBJ file with the given name and the material libraries it references.
+func Load(fsys fs.FS, name string) (*Model, error) {
+	fp, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fp.Close()
+
+	return Parse(fp, &Options{Name: name, FS: fsys, Dir: path.Dir(name)})
+}
+
+// Parse parses an OBJ file. Faces with more than three vertices are triangulated as a fan.
+// Missing normals are calculated from the faces, smoothed within smoothing groups.
+func Parse(r io.Reader, opts *Options) (*Model, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	p := &parser{
+		opts:      opts,
+		materials: map[string]*Material{},
+		meshes:    []meshState{{}},
+	}
+
+	err := scanLines(r, opts.Name, p.parseLine)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.build(), nil
+}
+
+// scanLines calls parse for each non-empty line without comments,
+// joining lines ending with a backslash.
+func scanLines(r io.Reader, name string, parse func(keyword string, args []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	var lineNo, startLineNo int
+	var pending string
+
+	for scanner.Scan() {
+		lineNo += 1
+
+		line := scanner.Text()
+
+		if pending == "" {
+			startLineNo = lineNo
+		}
+
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+
+		line = strings.TrimSpace(line)
+
+		if continued, ok := strings.CutSuffix(line, `\`); ok {
+			pending += continued + " "
+			continue
+		}
+
+		line = pending + line
+		pending = ""
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := parse(fields[0], fields[1:]); err != nil {
+			return &ParseError{File: name, Line: startLineNo, Err: err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// corner of a face, indices are zero based or -1 if not specified
+type corner struct {
+	position int
+	uv       int
+	normal   int
+}
+
+type triangle struct {
+	corners [3]corner
+
+	// smoothing group, zero if smoothing is off
+	smoothing int
+
+	// index of the mesh this triangle belongs to
+	mesh int
+}
+
+type meshState struct {
+	object   string
+	group    string
+	material string
+}
+
+type parser struct {
+	opts *Options
+
+	positions []glm.Vec3f
+	colors    []orion.Color
+	uvs       []glm.Vec2f
+	normals   []glm.Vec3f
+
+	triangles []triangle
+	materials map[string]*Material
+
+	// the last mesh is the current one
+	meshes []meshState
+
+	// number of triangles in the current mesh
+	meshTriangles int
+
+	smoothing int
+}
+
+func (p *parser) parseLine(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		values, err := parseFloats(args, 3, 6)
+		if err != nil {
+			return fmt.Errorf("vertex: %w", err)
+		}
+
+		p.positions = append(p.positions, glm.Vec3f{values[0], values[1], values[2]})
+
+		// a common extension stores a vertex color after the position
+		color := pulse.ColorWhite
+		if len(values) >= 6 {
+			color = pulse.ColorLinearRGBA(values[3], values[4], values[5], 1)
+		}
+
+		p.colors = append(p.colors, color)
+
+	case "vt":
+		values, err := parseFloats(args, 1, 3)
+		if err != nil {
+			return fmt.Errorf("texture coordinate: %w", err)
+		}
+
+		var v float32
+		if len(values) >= 2 {
+			v = values[1]
+		}
+
+		// obj has its origin in the bottom left corner, textures in the top left
+		p.uvs = append(p.uvs, glm.Vec2f{values[0], 1 - v})
+
+	case "vn":
+		values, err := parseFloats(args, 3, 3)
+		if err != nil {
+			return fmt.Errorf("normal: %w", err)
+		}
+
+		p.normals = append(p.normals, normalize(glm.Vec3f{values[0], values[1], values[2]}))
+
+	case "f":
+		return p.parseFace(args)
+
+	case "o":
+		p.startMesh()
+		current := &p.meshes[len(p.meshes)-1]
+		current.object = strings.Join(args, " ")
+		current.group = ""
+
+	case "g":
+		p.startMesh()
+		p.meshes[len(p.meshes)-1].group = strings.Join(args, " ")
+
+	case "usemtl":
+		if len(args) == 0 {
+			return errors.New("usemtl: expected material name")
+		}
+
+		p.startMesh()
+		p.meshes[len(p.meshes)-1].material = strings.Join(args, " ")
+
+	case "s":
+		if len(args) != 1 {
+			return errors.New("smoothing group: expected a single value")
+		}
+
+		if args[0] == "off" {
+			p.smoothing = 0
+			break
+		}
+
+		group, err := strconv.Atoi(args[0])
+		if err != nil || group < 0 {
+			return fmt.Errorf("smoothing group: invalid value %q", args[0])
+		}
+
+		p.smoothing = group
+
+	case "mtllib":
+		if p.opts.FS == nil {
+			break
+		}
+
+		for _, name := range args {
+			if err := p.loadMaterials(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	// other statements, like lines, points or curves, are not supported and ignored
+	return nil
+}
+
+// startMesh starts a new mesh that inherits the state of the current one,
+// if the current mesh already has triangles.
+func (p *parser) startMesh() {
+	if p.meshTriangles == 0 {
+		return
+	}
+
+	p.meshes = append(p.meshes, p.meshes[len(p.meshes)-1])
+	p.meshTriangles = 0
+}
+
+func (p *parser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face: expected at least three vertices, got %d", len(args))
+	}
+
+	corners := make([]corner, len(args))
+
+	for idx, arg := range args {
+		c, err := p.parseCorner(arg)
+		if err != nil {
+			return fmt.Errorf("face: %w", err)
+		}
+
+		corners[idx] = c
+	}
+
+	// triangulate as a fan
+	for idx := 1; idx+1 < len(corners); idx++ {
+		p.triangles = append(p.triangles, triangle{
+			corners:   [3]corner{corners[0], corners[idx], corners[idx+1]},
+			smoothing: p.smoothing,
+			mesh:      len(p.meshes) - 1,
+		})
+
+		p.meshTriangles += 1
+	}
+
+	return nil
+}
+
+// parseCorner parses a vertex reference in the form v, v/vt, v//vn or v/vt/vn.
+func (p *parser) parseCorner(input string) (corner, error) {
+	parts := strings.Split(input, "/")
+	if len(parts) > 3 {
+		return corner{}, fmt.Errorf("invalid vertex %q", input)
+	}
+
+	c := corner{position: -1, uv: -1, normal: -1}
+
+	counts := []int{len(p.positions), len(p.uvs), len(p.normals)}
+	targets := []*int{&c.position, &c.uv, &c.normal}
+
+	for idx, part := range parts {
+		if part == "" {
+			if idx == 0 {
+				return corner{}, fmt.Errorf("invalid vertex %q: missing position", input)
+			}
+
+			continue
+		}
+
+		value, err := parseIndex(part, counts[idx])
+		if err != nil {
+			return corner{}, fmt.Errorf("invalid vertex %q: %w", input, err)
+		}
+
+		*targets[idx] = value
+	}
+
+	return c, nil
+}
+
+// parseIndex parses a one based index. Negative indices are relative
+// to the end of the list. The resulting index is zero based.
+func parseIndex(input string, count int) (int, error) {
+	value, err := strconv.Atoi(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %q", input)
+	}
+
+	switch {
+	case value > 0 && value <= count:
+		return value - 1, nil
+
+	case value < 0 && -value <= count:
+		return count + value, nil
+
+	default:
+		return 0, fmt.Errorf("index %d out of range, have %d elements", value, count)
+	}
+}
+
+func (p *parser) loadMaterials(name string) error {
+	filename := path.Join(p.opts.Dir, strings.ReplaceAll(name, `\`, "/"))
+
+	fp, err := p.opts.FS.Open(filename)
+	if err != nil {
+		return fmt.Errorf("mtllib: %w", err)
+	}
+
+	defer fp.Close()
+
+	materials, err := ParseMaterials(fp, &Options{Name: filename, Dir: path.Dir(filename)})
+	if err != nil {
+		return err
+	}
+
+	for name, material := range materials {
+		p.materials[name] = material
+	}
+
+	return nil
+}
+
+// smoothingKey identifies the normal of a position within a smoothing group
+type smoothingKey struct {
+	position  int
+	smoothing int
+}
+
+// vertexKey identifies a unique vertex within a mesh
+type vertexKey struct {
+	position int
+	uv       int
+	normal   int
+
+	// set if the normal is calculated
+	smoothing int
+	triangle  int
+}
+
+func (p *parser) build() *Model {
+	faceNormals := make([]glm.Vec3f, len(p.triangles))
+
+	// sum up the face normals of each position within a smoothing group,
+	// larger faces have more influence, as the normals are not normalized
+	smoothNormals := map[smoothingKey]glm.Vec3f{}
+
+	for idx, tri := range p.triangles {
+		a := p.positions[tri.corners[0].position]
+		b := p.positions[tri.corners[1].position]
+		c := p.positions[tri.corners[2].position]
+
+		faceNormals[idx] = b.Sub(a).Cross(c.Sub(a))
+
+		if tri.smoothing == 0 {
+			continue
+		}
+
+		for _, corner := range tri.corners {
+			key := smoothingKey{position: corner.position, smoothing: tri.smoothing}
+			smoothNormals[key] = smoothNormals[key].Add(faceNormals[idx])
+		}
+	}
+
+	meshes := make([]Mesh, len(p.meshes))
+	lookups := make([]map[vertexKey]uint32, len(p.meshes))
+
+	for idx, state := range p.meshes {
+		meshes[idx] = Mesh{
+			Object:   state.object,
+			Group:    state.group,
+			Material: p.materials[state.material],
+		}
+
+		lookups[idx] = map[vertexKey]uint32{}
+	}
+
+	for triIdx, tri := range p.triangles {
+		mesh := &meshes[tri.mesh]
+		lookup := lookups[tri.mesh]
+
+		for _, corner := range tri.corners {
+			key := vertexKey{position: corner.position, uv: corner.uv, normal: corner.normal, triangle: -1}
+
+			var normal glm.Vec3f
+
+			switch {
+			case corner.normal >= 0:
+				normal = p.normals[corner.normal]
+
+			case tri.smoothing != 0:
+				key.smoothing = tri.smoothing
+				normal = smoothNormals[smoothingKey{position: corner.position, smoothing: tri.smoothing}]
+
+			default:
+				key.triangle = triIdx
+				normal = faceNormals[triIdx]
+			}
+
+			vertexIdx, ok := lookup[key]
+			if !ok {
+				vertex := orion.Vertex3d{
+					Position: p.positions[corner.position],
+					Normal:   normalize(normal),
+					Color:    p.colors[corner.position],
+				}
+
+				if corner.uv >= 0 {
+					vertex.UV = p.uvs[corner.uv]
+				}
+
+				vertexIdx = uint32(len(mesh.Vertices))
+				mesh.Vertices = append(mesh.Vertices, vertex)
+				lookup[key] = vertexIdx
+			}
+
+			mesh.Indices = append(mesh.Indices, vertexIdx)
+		}
+	}
+
+	// drop meshes without any triangles
+	var result []Mesh
+	for _, mesh := range meshes {
+		if len(mesh.Indices) > 0 {
+			result = append(result, mesh)
+		}
+	}
+
+	return &Model{
+		Meshes:    result,
+		Materials: p.materials,
+	}
+}
+
+// normalize normalizes the vector, a zero vector stays zero
+func normalize(vec glm.Vec3f) glm.Vec3f {
+	if vec.LengthSqr() == 0 {
+		return vec
+	}
+
+	return vec.Normalize()
+}
+
+func parseFloats(args []string, minCount, maxCount int) ([]float32, error) {
+	if len(args) < minCount || len(args) > maxCount {
+		if minCount == maxCount {
+			return nil, fmt.Errorf("expected %d values, got %d", minCount, len(args))
+		}
+
+		return nil, fmt.Errorf("expected %d to %d values, got %d", minCount, maxCount, len(args))
+	}
+
+	values := make([]float32, len(args))
+
+	for idx, arg := range args {
+		value, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", arg)
+		}
+
+		values[idx] = float32(value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,415 @@
+package obj
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+const square = `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+`
+
+func TestParseMeshes(t *testing.T) {
+	type expectedMesh struct {
+		object   string
+		group    string
+		vertices int
+		indices  []uint32
+	}
+
+	cases := []struct {
+		name   string
+		input  string
+		meshes []expectedMesh
+	}{
+		{
+			name:   "triangle",
+			input:  square + "f 1 2 3",
+			meshes: []expectedMesh{{vertices: 3, indices: []uint32{0, 1, 2}}},
+		},
+		{
+			name:   "fan triangulation",
+			input:  square + "vn 0 0 1\nf 1//1 2//1 3//1 4//1",
+			meshes: []expectedMesh{{vertices: 4, indices: []uint32{0, 1, 2, 0, 2, 3}}},
+		},
+		{
+			name:   "negative indices",
+			input:  square + "f -4 -3 -2",
+			meshes: []expectedMesh{{vertices: 3, indices: []uint32{0, 1, 2}}},
+		},
+		{
+			name:   "comments and continued lines",
+			input:  square + "# a comment\nf 1 2 \\\n 3 # trailing comment",
+			meshes: []expectedMesh{{vertices: 3, indices: []uint32{0, 1, 2}}},
+		},
+		{
+			name:   "shared vertices with explicit normals",
+			input:  square + "vn 0 0 1\nf 1//1 2//1 3//1\nf 1//1 3//1 4//1",
+			meshes: []expectedMesh{{vertices: 4, indices: []uint32{0, 1, 2, 0, 2, 3}}},
+		},
+		{
+			name:   "flat faces do not share vertices",
+			input:  square + "f 1 2 3\nf 1 3 4",
+			meshes: []expectedMesh{{vertices: 6, indices: []uint32{0, 1, 2, 3, 4, 5}}},
+		},
+		{
+			name:   "smoothing group shares vertices",
+			input:  square + "s 1\nf 1 2 3\nf 1 3 4",
+			meshes: []expectedMesh{{vertices: 4, indices: []uint32{0, 1, 2, 0, 2, 3}}},
+		},
+		{
+			name:  "objects, groups and materials split meshes",
+			input: square + "o first\nf 1 2 3\ng group\nf 1 2 3\nusemtl red\nf 1 2 3\no second\nf 1 2 3",
+			meshes: []expectedMesh{
+				{object: "first", vertices: 3, indices: []uint32{0, 1, 2}},
+				{object: "first", group: "group", vertices: 3, indices: []uint32{0, 1, 2}},
+				{object: "first", group: "group", vertices: 3, indices: []uint32{0, 1, 2}},
+				{object: "second", vertices: 3, indices: []uint32{0, 1, 2}},
+			},
+		},
+		{
+			name:   "statements without faces do not split meshes",
+			input:  square + "o first\ng group\nusemtl red\nf 1 2 3",
+			meshes: []expectedMesh{{object: "first", group: "group", vertices: 3, indices: []uint32{0, 1, 2}}},
+		},
+		{
+			name:  "no faces",
+			input: square,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := Parse(strings.NewReader(tc.input), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(model.Meshes) != len(tc.meshes) {
+				t.Fatalf("expected %d meshes, got %d", len(tc.meshes), len(model.Meshes))
+			}
+
+			for idx, expected := range tc.meshes {
+				mesh := model.Meshes[idx]
+
+				if mesh.Object != expected.object || mesh.Group != expected.group {
+					t.Errorf("mesh %d: expected object %q and group %q, got %q and %q",
+						idx, expected.object, expected.group, mesh.Object, mesh.Group)
+				}
+
+				if len(mesh.Vertices) != expected.vertices {
+					t.Errorf("mesh %d: expected %d vertices, got %d", idx, expected.vertices, len(mesh.Vertices))
+				}
+
+				if !slices.Equal(mesh.Indices, expected.indices) {
+					t.Errorf("mesh %d: expected indices %v, got %v", idx, expected.indices, mesh.Indices)
+				}
+			}
+		})
+	}
+}
+
+func TestParseVertexAttributes(t *testing.T) {
+	input := `
+v 0 0 0 1 0 0
+v 2 0 0 0 1 0
+v 0 2 0
+vt 0 0
+vt 1 0.25
+vn 0 0 2
+f 1/1 2/2 3
+f 1//1 2//1 3//1
+`
+
+	model, err := Parse(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(model.Meshes) != 1 {
+		t.Fatalf("expected a single mesh, got %d", len(model.Meshes))
+	}
+
+	vertices := model.Meshes[0].Vertices
+	if len(vertices) != 6 {
+		t.Fatalf("expected 6 vertices, got %d", len(vertices))
+	}
+
+	cases := []struct {
+		name     string
+		vertex   int
+		position glm.Vec3f
+		normal   glm.Vec3f
+		uv       glm.Vec2f
+		color    pulse.Color
+	}{
+		{
+			name:     "vertex color and flipped uv",
+			vertex:   0,
+			position: glm.Vec3f{0, 0, 0},
+			normal:   glm.Vec3f{0, 0, 1},
+			uv:       glm.Vec2f{0, 1},
+			color:    pulse.ColorLinearRGBA(1, 0, 0, 1),
+		},
+		{
+			name:     "second uv",
+			vertex:   1,
+			position: glm.Vec3f{2, 0, 0},
+			normal:   glm.Vec3f{0, 0, 1},
+			uv:       glm.Vec2f{1, 0.75},
+			color:    pulse.ColorLinearRGBA(0, 1, 0, 1),
+		},
+		{
+			name:     "default color without uv",
+			vertex:   2,
+			position: glm.Vec3f{0, 2, 0},
+			normal:   glm.Vec3f{0, 0, 1},
+			color:    pulse.ColorWhite,
+		},
+		{
+			name:     "explicit normal is normalized",
+			vertex:   3,
+			position: glm.Vec3f{0, 0, 0},
+			normal:   glm.Vec3f{0, 0, 1},
+			color:    pulse.ColorLinearRGBA(1, 0, 0, 1),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vertex := vertices[tc.vertex]
+
+			if vertex.Position != tc.position {
+				t.Errorf("expected position %v, got %v", tc.position, vertex.Position)
+			}
+
+			if vertex.Normal.Sub(tc.normal).Length() > 1e-5 {
+				t.Errorf("expected normal %v, got %v", tc.normal, vertex.Normal)
+			}
+
+			if vertex.UV.Sub(tc.uv).Length() > 1e-5 {
+				t.Errorf("expected uv %v, got %v", tc.uv, vertex.UV)
+			}
+
+			if vertex.Color != tc.color {
+				t.Errorf("expected color %v, got %v", tc.color, vertex.Color)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{name: "too few face vertices", input: square + "f 1 2", line: 6},
+		{name: "position out of range", input: square + "f 1 2 5", line: 6},
+		{name: "negative index out of range", input: square + "f -5 1 2", line: 6},
+		{name: "uv out of range", input: square + "f 1/1 2/1 3/1", line: 6},
+		{name: "missing position", input: square + "f /1 2 3", line: 6},
+		{name: "too many index parts", input: square + "f 1/1/1/1 2 3", line: 6},
+		{name: "invalid number", input: "v 1 a 2", line: 1},
+		{name: "too few coordinates", input: "v 1 2", line: 1},
+		{name: "empty texture coordinate", input: "vt", line: 1},
+		{name: "invalid normal", input: "vn 0 1", line: 1},
+		{name: "usemtl without name", input: "\nusemtl", line: 2},
+		{name: "invalid smoothing group", input: "s x", line: 1},
+		{name: "error in continued line", input: square + "f 1 \\\n2 \\\n9", line: 6},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.input), &Options{Name: "model.obj"})
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+
+			if parseErr.File != "model.obj" || parseErr.Line != tc.line {
+				t.Errorf("expected error in model.obj:%d, got %s:%d", tc.line, parseErr.File, parseErr.Line)
+			}
+		})
+	}
+}
+
+func TestParseMaterials(t *testing.T) {
+	input := `
+newmtl plain
+
+newmtl red
+Ka 0.1 0.1 0.1
+Kd 1 0 0
+Ks 0.5 0.5 0.5
+Ke 0 0 1
+Ns 32
+d 0.5
+map_Kd -s 1 1 1 textures\red.png
+map_Ks specular.png
+map_Bump normal.png
+map_d alpha.png
+
+newmtl glass
+Tr 0.75
+Kd 0 1 0
+illum 2
+`
+
+	materials, err := ParseMaterials(strings.NewReader(input), &Options{Dir: "assets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		expected Material
+	}{
+		{
+			name: "plain",
+			expected: Material{
+				Name:     "plain",
+				Ambient:  pulse.ColorBlack,
+				Diffuse:  pulse.ColorWhite,
+				Specular: pulse.ColorBlack,
+				Emissive: pulse.ColorBlack,
+				Opacity:  1,
+			},
+		},
+		{
+			name: "red",
+			expected: Material{
+				Name:            "red",
+				Ambient:         pulse.ColorLinearRGBA(0.1, 0.1, 0.1, 1),
+				Diffuse:         pulse.ColorLinearRGBA(1, 0, 0, 0.5),
+				Specular:        pulse.ColorLinearRGBA(0.5, 0.5, 0.5, 1),
+				Emissive:        pulse.ColorLinearRGBA(0, 0, 1, 1),
+				Shininess:       32,
+				Opacity:         0.5,
+				DiffuseTexture:  "assets/textures/red.png",
+				SpecularTexture: "assets/specular.png",
+				NormalTexture:   "assets/normal.png",
+				OpacityTexture:  "assets/alpha.png",
+			},
+		},
+		{
+			name: "glass",
+			expected: Material{
+				Name:     "glass",
+				Ambient:  pulse.ColorBlack,
+				Diffuse:  pulse.ColorLinearRGBA(0, 1, 0, 0.25),
+				Specular: pulse.ColorBlack,
+				Emissive: pulse.ColorBlack,
+				Opacity:  0.25,
+			},
+		},
+	}
+
+	if len(materials) != len(cases) {
+		t.Fatalf("expected %d materials, got %d", len(cases), len(materials))
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			material, ok := materials[tc.name]
+			if !ok {
+				t.Fatalf("material %q not found", tc.name)
+			}
+
+			if *material != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, *material)
+			}
+		})
+	}
+}
+
+func TestParseMaterialsErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{name: "statement before newmtl", input: "Kd 1 1 1", line: 1},
+		{name: "newmtl without name", input: "newmtl", line: 1},
+		{name: "too few color values", input: "newmtl a\nKd 1 1", line: 2},
+		{name: "invalid opacity", input: "newmtl a\n\nd x", line: 3},
+		{name: "texture without path", input: "newmtl a\nmap_Kd", line: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseMaterials(strings.NewReader(tc.input), &Options{Name: "lib.mtl"})
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+
+			if parseErr.File != "lib.mtl" || parseErr.Line != tc.line {
+				t.Errorf("expected error in lib.mtl:%d, got %s:%d", tc.line, parseErr.File, parseErr.Line)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/cube.obj":           {Data: []byte("mtllib materials\\cube.mtl\n" + square + "usemtl red\nf 1 2 3\n")},
+		"models/materials/cube.mtl": {Data: []byte("newmtl red\nKd 1 0 0\nmap_Kd red.png\n")},
+		"models/broken.obj":         {Data: []byte("mtllib missing.mtl\n")},
+	}
+
+	t.Run("material library", func(t *testing.T) {
+		model, err := Load(fsys, "models/cube.obj")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		red := model.Materials["red"]
+		if red == nil {
+			t.Fatal("material red not loaded")
+		}
+
+		if red.DiffuseTexture != "models/materials/red.png" {
+			t.Errorf("unexpected diffuse texture %q", red.DiffuseTexture)
+		}
+
+		if len(model.Meshes) != 1 || model.Meshes[0].Material != red {
+			t.Errorf("expected a single mesh using material red")
+		}
+	})
+
+	t.Run("missing material library", func(t *testing.T) {
+		_, err := Load(fsys, "models/broken.obj")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("expected fs.ErrNotExist, got %v", err)
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.File != "models/broken.obj" || parseErr.Line != 1 {
+			t.Errorf("expected error in models/broken.obj:1, got %v", err)
+		}
+	})
+
+	t.Run("material library without file system", func(t *testing.T) {
+		model, err := Parse(strings.NewReader("mtllib missing.mtl\n"+square+"usemtl red\nf 1 2 3"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(model.Meshes) != 1 || model.Meshes[0].Material != nil {
+			t.Errorf("expected a single mesh without material")
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package oriontest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// updateGolden is set if golden files should be (re-)written
+// instead of being compared to.
+var updateGolden = os.Getenv("ORION_UPDATE_GOLDEN") == "1"
+
+type CompareOptions struct {
+	// Tolerance is the maximum difference allowed per color channel.
+	Tolerance uint8
+
+	// MaxMismatchedPixels is the number of pixels that may exceed the tolerance
+	// before the comparison fails.
+	MaxMismatchedPixels int
+}
+
+// Result of comparing two images
+type Result struct {
+	// Number of pixels that differ by more than the tolerance
+	MismatchedPixels int
+
+	// Largest difference of any channel
+	MaxDifference uint8
+
+	// An image highlighting the mismatched pixels in red. Matching pixels
+	// are drawn as a faded gray scale version of the expected image.
+	Diff *image.NRGBA
+}
+
+// Compare compares the pixels of both images. Both images must have the same size.
+func Compare(expected, actual image.Image, tolerance uint8) (Result, error) {
+	if expected.Bounds().Size() != actual.Bounds().Size() {
+		return Result{}, fmt.Errorf(
+			"size mismatch: expected %s, got %s",
+			expected.Bounds().Size(), actual.Bounds().Size(),
+		)
+	}
+
+	exp := toNRGBA(expected)
+	act := toNRGBA(actual)
+
+	var result Result
+	result.Diff = image.NewNRGBA(exp.Bounds())
+
+	for idx := 0; idx < len(exp.Pix); idx += 4 {
+		var diff uint8
+		for ch := range 4 {
+			diff = max(diff, absDiff(exp.Pix[idx+ch], act.Pix[idx+ch]))
+		}
+
+		result.MaxDifference = max(result.MaxDifference, diff)
+
+		if diff > tolerance {
+			result.MismatchedPixels += 1
+
+			copy(result.Diff.Pix[idx:idx+4], []byte{255, 0, 0, 255})
+		} else {
+			r, g, b := exp.Pix[idx], exp.Pix[idx+1], exp.Pix[idx+2]
+			gray := uint8((uint32(r)*299 + uint32(g)*587 + uint32(b)*114) / 1000 / 4)
+
+			copy(result.Diff.Pix[idx:idx+4], []byte{gray, gray, gray, 255})
+		}
+	}
+
+	return result, nil
+}
+
+// AssertGolden compares the image to the golden png file at the given path.
+//
+// If the images do not match, the actual image and a diff image are written next to the
+// golden file, using the suffixes ".actual.png" and ".diff.png".
+//
+// Set the environment variable ORION_UPDATE_GOLDEN=1 to write the actual image
+// as the new golden file. A missing golden file fails the test.
+func AssertGolden(t testing.TB, actual image.Image, goldenPath string, opts *CompareOptions) {
+	t.Helper()
+
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+
+	if updateGolden {
+		if err := writePNG(goldenPath, actual); err != nil {
+			t.Fatalf("update golden file: %s", err)
+		}
+
+		return
+	}
+
+	expected, err := readPNG(goldenPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %q does not exist, run with ORION_UPDATE_GOLDEN=1 to create it", goldenPath)
+	}
+
+	if err != nil {
+		t.Fatalf("read golden file: %s", err)
+	}
+
+	result, err := Compare(expected, actual, opts.Tolerance)
+	if err != nil {
+		writeFailure(t, goldenPath, actual, nil)
+		t.Fatalf("compare to golden file %q: %s", goldenPath, err)
+	}
+
+	if result.MismatchedPixels > opts.MaxMismatchedPixels {
+		writeFailure(t, goldenPath, actual, result.Diff)
+
+		t.Fatalf(
+			"image does not match golden file %q: %d pixels differ, max difference %d, tolerance %d",
+			goldenPath, result.MismatchedPixels, result.MaxDifference, opts.Tolerance,
+		)
+	}
+}
+
+func writeFailure(t testing.TB, goldenPath string, actual image.Image, diff image.Image) {
+	t.Helper()
+
+	base := strings.TrimSuffix(goldenPath, filepath.Ext(goldenPath))
+
+	if err := writePNG(base+".actual.png", actual); err != nil {
+		t.Logf("write actual image: %s", err)
+	}
+
+	if diff != nil {
+		if err := writePNG(base+".diff.png", diff); err != nil {
+			t.Logf("write diff image: %s", err)
+		}
+	}
+}
+
+func readPNG(path string) (image.Image, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fp.Close()
+
+	img, err := png.Decode(fp)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q: %w", path, err)
+	}
+
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(fp, img); err != nil {
+		_ = fp.Close()
+		return fmt.Errorf("encode %q: %w", path, err)
+	}
+
+	return fp.Close()
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return nrgba
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
@@ -0,0 +1,249 @@
+package oriontest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name       string
+		expected   image.Image
+		actual     image.Image
+		tolerance  uint8
+		mismatched int
+		maxDiff    uint8
+	}{
+		{
+			name:     "equal",
+			expected: filledImage(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255}),
+			actual:   filledImage(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255}),
+		},
+		{
+			name:       "all different",
+			expected:   filledImage(2, 2, color.NRGBA{R: 10, A: 255}),
+			actual:     filledImage(2, 2, color.NRGBA{R: 20, A: 255}),
+			mismatched: 4,
+			maxDiff:    10,
+		},
+		{
+			name:      "within tolerance",
+			expected:  filledImage(2, 2, color.NRGBA{G: 100, A: 255}),
+			actual:    filledImage(2, 2, color.NRGBA{G: 95, A: 255}),
+			tolerance: 5,
+			maxDiff:   5,
+		},
+		{
+			name:       "single pixel",
+			expected:   filledImage(3, 3, color.NRGBA{A: 255}),
+			actual:     withPixel(filledImage(3, 3, color.NRGBA{A: 255}), 1, 2, color.NRGBA{B: 200, A: 255}),
+			tolerance:  10,
+			mismatched: 1,
+			maxDiff:    200,
+		},
+		{
+			name:       "alpha",
+			expected:   filledImage(1, 1, color.NRGBA{A: 255}),
+			actual:     filledImage(1, 1, color.NRGBA{A: 0}),
+			mismatched: 1,
+			maxDiff:    255,
+		},
+		{
+			name:     "offset bounds",
+			expected: filledImage(2, 2, color.NRGBA{R: 50, A: 255}),
+			actual:   filledImage(3, 3, color.NRGBA{R: 50, A: 255}).SubImage(image.Rect(1, 1, 3, 3)),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Compare(tc.expected, tc.actual, tc.tolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.MismatchedPixels != tc.mismatched {
+				t.Errorf("expected %d mismatched pixels, got %d", tc.mismatched, result.MismatchedPixels)
+			}
+
+			if result.MaxDifference != tc.maxDiff {
+				t.Errorf("expected max difference %d, got %d", tc.maxDiff, result.MaxDifference)
+			}
+
+			if result.Diff.Bounds().Size() != tc.expected.Bounds().Size() {
+				t.Errorf("diff has size %s", result.Diff.Bounds().Size())
+			}
+		})
+	}
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	_, err := Compare(filledImage(2, 2, color.NRGBA{}), filledImage(2, 3, color.NRGBA{}), 0)
+	if err == nil {
+		t.Fatal("expected an error for images of different size")
+	}
+}
+
+func TestCompareDiffHighlightsMismatches(t *testing.T) {
+	expected := filledImage(2, 1, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	actual := withPixel(filledImage(2, 1, color.NRGBA{R: 255, G: 255, B: 255, A: 255}), 1, 0, color.NRGBA{A: 255})
+
+	result, err := Compare(expected, actual, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.Diff.NRGBAAt(1, 0); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("expected mismatched pixel to be red, got %v", got)
+	}
+
+	// matching pixels are a faded gray scale version of the expected image
+	if got := result.Diff.NRGBAAt(0, 0); got != (color.NRGBA{R: 63, G: 63, B: 63, A: 255}) {
+		t.Errorf("expected matching pixel to be faded gray, got %v", got)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	dir := t.TempDir()
+	goldenPath := filepath.Join(dir, "golden.png")
+
+	golden := filledImage(4, 4, color.NRGBA{R: 100, G: 150, B: 200, A: 255})
+	if err := writePNG(goldenPath, golden); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("match", func(t *testing.T) {
+		tb := runWithFakeTB(func(tb testing.TB) {
+			AssertGolden(tb, golden, goldenPath, nil)
+		})
+
+		if tb.failed {
+			t.Fatalf("expected golden image to match: %s", tb.message)
+		}
+	})
+
+	t.Run("tolerated", func(t *testing.T) {
+		actual := withPixel(filledImage(4, 4, color.NRGBA{R: 100, G: 150, B: 200, A: 255}), 0, 0, color.NRGBA{A: 255})
+
+		tb := runWithFakeTB(func(tb testing.TB) {
+			AssertGolden(tb, actual, goldenPath, &CompareOptions{MaxMismatchedPixels: 1})
+		})
+
+		if tb.failed {
+			t.Fatalf("expected a single mismatched pixel to be tolerated: %s", tb.message)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		actual := filledImage(4, 4, color.NRGBA{A: 255})
+
+		tb := runWithFakeTB(func(tb testing.TB) {
+			AssertGolden(tb, actual, goldenPath, nil)
+		})
+
+		if !tb.failed {
+			t.Fatal("expected mismatching image to fail")
+		}
+
+		for _, suffix := range []string{".actual.png", ".diff.png"} {
+			if _, err := os.Stat(filepath.Join(dir, "golden"+suffix)); err != nil {
+				t.Errorf("expected %s to be written: %s", suffix, err)
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		tb := runWithFakeTB(func(tb testing.TB) {
+			AssertGolden(tb, golden, filepath.Join(dir, "missing.png"), nil)
+		})
+
+		if !tb.failed {
+			t.Fatal("expected missing golden file to fail")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		updateGolden = true
+		defer func() { updateGolden = false }()
+
+		path := filepath.Join(dir, "updated", "golden.png")
+
+		tb := runWithFakeTB(func(tb testing.TB) {
+			AssertGolden(tb, golden, path, nil)
+		})
+
+		if tb.failed {
+			t.Fatalf("expected update to succeed: %s", tb.message)
+		}
+
+		written, err := readPNG(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Compare(golden, written, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.MismatchedPixels != 0 {
+			t.Errorf("written golden file differs in %d pixels", result.MismatchedPixels)
+		}
+	})
+}
+
+// fakeTB records a failure instead of failing the test
+type fakeTB struct {
+	testing.TB
+
+	failed  bool
+	message string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Logf(format string, args ...any) {}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failed = true
+	f.message = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runWithFakeTB runs fn in its own goroutine, so Fatalf can stop it.
+func runWithFakeTB(fn func(tb testing.TB)) *fakeTB {
+	tb := &fakeTB{}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(tb)
+	}()
+
+	<-done
+
+	return tb
+}
+
+func filledImage(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		for x := range width {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func withPixel(img *image.NRGBA, x, y int, c color.NRGBA) *image.NRGBA {
+	img.SetNRGBA(x, y, c)
+	return img
+}
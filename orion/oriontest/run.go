@@ -0,0 +1,80 @@
+// Package oriontest runs orion games without a window and compares
+// the rendered frames against golden images.
+package oriontest
+
+import (
+	"image"
+	"testing"
+
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+type Options struct {
+	// Size of the virtual surface passed to orion.Game.Layout.
+	// Defaults to 1000x600.
+	SurfaceWidth  uint32
+	SurfaceHeight uint32
+
+	// Number of frames to run. Defaults to 1.
+	Frames int
+
+	// Input to replay. Frames without any recorded input
+	// run with no key or mouse button pressed.
+	Input *Script
+
+	// OnFrame is called after each frame with the canvas the game has drawn to.
+	OnFrame func(frame int, canvas *orion.Image)
+}
+
+// Run runs the game for the configured number of frames and returns the canvas
+// of the last frame. The test fails if the game returns an error.
+func Run(t testing.TB, game orion.Game, opts Options) image.Image {
+	t.Helper()
+
+	if opts.Frames <= 0 {
+		opts.Frames = 1
+	}
+
+	input := opts.Input
+	if input == nil {
+		input = &Script{}
+	}
+
+	headless, err := orion.NewHeadless(orion.HeadlessOptions{
+		Game:          game,
+		SurfaceWidth:  opts.SurfaceWidth,
+		SurfaceHeight: opts.SurfaceHeight,
+	})
+
+	if err != nil {
+		t.Fatalf("create headless game: %s", err)
+	}
+
+	defer headless.Release()
+
+	for frame := range opts.Frames {
+		if err := headless.Frame(input.stateAt(frame)); err != nil {
+			t.Fatalf("run frame %d: %s", frame, err)
+		}
+
+		if opts.OnFrame != nil {
+			opts.OnFrame(frame, headless.Canvas())
+		}
+	}
+
+	img, err := headless.Canvas().ToImage()
+	if err != nil {
+		t.Fatalf("capture canvas: %s", err)
+	}
+
+	return img
+}
+
+// RunGolden runs the game and compares the canvas of the last frame
+// against the golden image at the given path, see AssertGolden.
+func RunGolden(t testing.TB, game orion.Game, goldenPath string, opts Options, compare *CompareOptions) {
+	t.Helper()
+
+	img := Run(t, game, opts)
+	AssertGolden(t, img, goldenPath, compare)
+}
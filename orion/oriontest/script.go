@@ -0,0 +1,101 @@
+package oriontest
+
+import (
+	"github.com/oliverbestmann/pulse/glimpse"
+	"github.com/oliverbestmann/pulse/orion"
+)
+
+type inputEvent struct {
+	frame int
+	apply func(state *glimpse.InputState)
+}
+
+// Script records input events for specific frames. The input state of a frame
+// is derived from all events recorded for the frame and the frames before.
+type Script struct {
+	events []inputEvent
+}
+
+// PressKey presses the key in the given frame. The key stays pressed until released.
+func (s *Script) PressKey(frame int, key orion.KeyCode) *Script {
+	return s.record(frame, func(state *glimpse.InputState) {
+		setKey(&state.Keys.Pressed, key, true)
+		setKey(&state.Keys.JustPressed, key, true)
+	})
+}
+
+// ReleaseKey releases the key in the given frame.
+func (s *Script) ReleaseKey(frame int, key orion.KeyCode) *Script {
+	return s.record(frame, func(state *glimpse.InputState) {
+		setKey(&state.Keys.Pressed, key, false)
+		setKey(&state.Keys.JustReleased, key, true)
+	})
+}
+
+// TapKey presses the key in the given frame and releases it in the next one.
+func (s *Script) TapKey(frame int, key orion.KeyCode) *Script {
+	return s.PressKey(frame, key).ReleaseKey(frame+1, key)
+}
+
+// PressMouseButton presses the mouse button in the given frame.
+func (s *Script) PressMouseButton(frame int, button orion.MouseButton) *Script {
+	return s.record(frame, func(state *glimpse.InputState) {
+		setKey(&state.Mouse.Pressed, button, true)
+		setKey(&state.Mouse.JustPressed, button, true)
+	})
+}
+
+// ReleaseMouseButton releases the mouse button in the given frame.
+func (s *Script) ReleaseMouseButton(frame int, button orion.MouseButton) *Script {
+	return s.record(frame, func(state *glimpse.InputState) {
+		setKey(&state.Mouse.Pressed, button, false)
+		setKey(&state.Mouse.JustReleased, button, true)
+	})
+}
+
+// MoveMouse moves the cursor to the given position in surface coordinates.
+func (s *Script) MoveMouse(frame int, x, y float32) *Script {
+	return s.record(frame, func(state *glimpse.InputState) {
+		state.Mouse.DeltaX += x - state.Mouse.CursorX
+		state.Mouse.DeltaY += y - state.Mouse.CursorY
+		state.Mouse.CursorX = x
+		state.Mouse.CursorY = y
+	})
+}
+
+func (s *Script) record(frame int, apply func(state *glimpse.InputState)) *Script {
+	s.events = append(s.events, inputEvent{frame: frame, apply: apply})
+	return s
+}
+
+// stateAt replays all events up to the given frame.
+func (s *Script) stateAt(frame int) glimpse.InputState {
+	var state glimpse.InputState
+
+	for current := 0; current <= frame; current++ {
+		// just pressed and just released only last for a single frame
+		clear(state.Keys.JustPressed)
+		clear(state.Keys.JustReleased)
+		clear(state.Mouse.JustPressed)
+		clear(state.Mouse.JustReleased)
+
+		state.Mouse.DeltaX = 0
+		state.Mouse.DeltaY = 0
+
+		for _, event := range s.events {
+			if event.frame == current {
+				event.apply(&state)
+			}
+		}
+	}
+
+	return state
+}
+
+func setKey[K comparable](m *map[K]bool, key K, value bool) {
+	if *m == nil {
+		*m = map[K]bool{}
+	}
+
+	(*m)[key] = value
+}
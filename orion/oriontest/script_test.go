@@ -0,0 +1,111 @@
+package oriontest
+
+import (
+	"testing"
+
+	"github.com/oliverbestmann/pulse/glimpse"
+)
+
+func TestScriptKeys(t *testing.T) {
+	script := (&Script{}).
+		PressKey(1, glimpse.KeyA).
+		ReleaseKey(3, glimpse.KeyA).
+		TapKey(2, glimpse.KeySpace)
+
+	cases := []struct {
+		frame        int
+		key          glimpse.Key
+		pressed      bool
+		justPressed  bool
+		justReleased bool
+	}{
+		{frame: 0, key: glimpse.KeyA},
+		{frame: 1, key: glimpse.KeyA, pressed: true, justPressed: true},
+		{frame: 2, key: glimpse.KeyA, pressed: true},
+		{frame: 3, key: glimpse.KeyA, justReleased: true},
+		{frame: 4, key: glimpse.KeyA},
+
+		{frame: 1, key: glimpse.KeySpace},
+		{frame: 2, key: glimpse.KeySpace, pressed: true, justPressed: true},
+		{frame: 3, key: glimpse.KeySpace, justReleased: true},
+		{frame: 10, key: glimpse.KeySpace},
+	}
+
+	for _, tc := range cases {
+		state := script.stateAt(tc.frame)
+
+		if got := state.Keys.Pressed[tc.key]; got != tc.pressed {
+			t.Errorf("frame %d, %s: expected pressed=%t, got %t", tc.frame, tc.key, tc.pressed, got)
+		}
+
+		if got := state.Keys.JustPressed[tc.key]; got != tc.justPressed {
+			t.Errorf("frame %d, %s: expected justPressed=%t, got %t", tc.frame, tc.key, tc.justPressed, got)
+		}
+
+		if got := state.Keys.JustReleased[tc.key]; got != tc.justReleased {
+			t.Errorf("frame %d, %s: expected justReleased=%t, got %t", tc.frame, tc.key, tc.justReleased, got)
+		}
+	}
+}
+
+func TestScriptMouse(t *testing.T) {
+	const button glimpse.MouseButton = 0
+
+	script := (&Script{}).
+		MoveMouse(0, 10, 20).
+		MoveMouse(2, 15, 10).
+		PressMouseButton(2, button).
+		ReleaseMouseButton(4, button)
+
+	cases := []struct {
+		frame            int
+		cursorX, cursorY float32
+		deltaX, deltaY   float32
+		pressed          bool
+		justPressed      bool
+		justReleased     bool
+	}{
+		{frame: 0, cursorX: 10, cursorY: 20, deltaX: 10, deltaY: 20},
+		{frame: 1, cursorX: 10, cursorY: 20},
+		{frame: 2, cursorX: 15, cursorY: 10, deltaX: 5, deltaY: -10, pressed: true, justPressed: true},
+		{frame: 3, cursorX: 15, cursorY: 10, pressed: true},
+		{frame: 4, cursorX: 15, cursorY: 10, justReleased: true},
+	}
+
+	for _, tc := range cases {
+		state := script.stateAt(tc.frame)
+		mouse := state.Mouse
+
+		if mouse.CursorX != tc.cursorX || mouse.CursorY != tc.cursorY {
+			t.Errorf("frame %d: expected cursor at %v,%v, got %v,%v", tc.frame, tc.cursorX, tc.cursorY, mouse.CursorX, mouse.CursorY)
+		}
+
+		if mouse.DeltaX != tc.deltaX || mouse.DeltaY != tc.deltaY {
+			t.Errorf("frame %d: expected delta %v,%v, got %v,%v", tc.frame, tc.deltaX, tc.deltaY, mouse.DeltaX, mouse.DeltaY)
+		}
+
+		if got := mouse.Pressed[button]; got != tc.pressed {
+			t.Errorf("frame %d: expected pressed=%t, got %t", tc.frame, tc.pressed, got)
+		}
+
+		if got := mouse.JustPressed[button]; got != tc.justPressed {
+			t.Errorf("frame %d: expected justPressed=%t, got %t", tc.frame, tc.justPressed, got)
+		}
+
+		if got := mouse.JustReleased[button]; got != tc.justReleased {
+			t.Errorf("frame %d: expected justReleased=%t, got %t", tc.frame, tc.justReleased, got)
+		}
+	}
+}
+
+func TestScriptMultipleMovesInOneFrame(t *testing.T) {
+	script := (&Script{}).
+		MoveMouse(1, 5, 5).
+		MoveMouse(1, 8, 2)
+
+	state := script.stateAt(1)
+
+	if state.Mouse.DeltaX != 8 || state.Mouse.DeltaY != 2 {
+		t.Errorf("expected the deltas of one frame to add up to 8,2, got %v,%v", state.Mouse.DeltaX, state.Mouse.DeltaY)
+	}
+}
@@ -0,0 +1,57 @@
+// Prelude of every ShaderEffect. The effect source is appended to this file
+// and must define the function `fn effect(uv: vec2f) -> vec4f` that calculates
+// the color of the output pixel at the given uv coordinate.
+
+struct VertexOutput {
+    @location(0) uv: vec2f,
+    @builtin(position) position: vec4f,
+};
+
+struct VertexUniforms {
+    target_texture_size: vec2f,
+    source_texture_size: vec2f,
+}
+
+@group(0) @binding(0) var source: texture_2d<f32>;
+@group(0) @binding(1) var source_sampler: sampler;
+@group(0) @binding(2) var<uniform> vertex_uniforms: VertexUniforms;
+
+@vertex
+fn vs_main(
+    @builtin(vertex_index) index: u32,
+    @location(1) tr0: vec3f,
+    @location(2) tr1: vec3f,
+    @location(3) source_region: vec4f,
+    @location(4) target_region_in: vec4<u32>,
+) -> VertexOutput {
+    let target_region = vec4f(target_region_in);
+
+    let model_transform = transpose(mat3x3(tr0, tr1, vec3f(0, 0, 1)));
+
+    // index vertices as p00, p01, p10, p11
+    let vertex = vec2f(f32((index >> 1) & 1), f32(index & 1));
+
+    // position in pixels, then in ndc space
+    let pos_px = (model_transform * vec3f(vertex * abs(source_region.zw), 1)).xy;
+    let pos = (target_region.xy + pos_px) / vertex_uniforms.target_texture_size * 2.0 - 1.0;
+
+    var result: VertexOutput;
+    result.uv = (source_region.xy + source_region.zw * vertex) / vertex_uniforms.source_texture_size;
+    result.position = vec4(pos.x, -pos.y, 0.0, 1.0);
+    return result;
+}
+
+// size of a single pixel of the source in uv coordinates
+fn source_texel() -> vec2f {
+    return 1.0 / vec2f(textureDimensions(source));
+}
+
+// samples the source image at the given uv coordinate
+fn sample_source(uv: vec2f) -> vec4f {
+    return textureSampleLevel(source, source_sampler, uv, 0.0);
+}
+
+@fragment
+fn fs_main(vertex: VertexOutput) -> @location(0) vec4f {
+    return effect(vertex.uv);
+}
@@ -0,0 +1,99 @@
+package orion
+
+import (
+	_ "embed"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+//go:embed post-effect.wgsl
+var postEffectPrelude string
+
+// PostEffect is a full screen effect applied to the canvas before it is drawn to the surface.
+type PostEffect interface {
+	// Apply draws the source image with the effect applied to the target. The target
+	// has the same size and format as the source, its previous content is undefined.
+	Apply(target, source *Image)
+}
+
+// ShaderEffect is a PostEffect running a single full screen fragment shader. The shader
+// source must define a function `fn effect(uv: vec2f) -> vec4f`. The source image can
+// be sampled using `sample_source(uv)`, `source_texel()` returns the size of a pixel
+// in uv coordinates. Parameters and additional images are provided by the material
+// of the effect in bind group 1, see Material.
+type ShaderEffect struct {
+	material *Material
+}
+
+// NewShaderEffect creates a new ShaderEffect. The options configure the material,
+// they might be nil.
+func NewShaderEffect(source string, opts *NewMaterialOptions) *ShaderEffect {
+	return &ShaderEffect{
+		material: NewMaterial(postEffectPrelude+"\n"+source, opts),
+	}
+}
+
+// Material returns the material of the effect, use it to set the parameters
+// of the effect, e.g. with SetMaterialUniforms.
+func (e *ShaderEffect) Material() *Material {
+	return e.material
+}
+
+// Apply draws the source to the target using the shader of the effect. The source
+// is stretched to cover the target, so the target might have a different size.
+func (e *ShaderEffect) Apply(target, source *Image) {
+	scale := target.Sizef().Div(source.Sizef())
+
+	target.DrawImage(source, &DrawImageOptions{
+		Transform:  glm.ScaleMat3(scale[0], scale[1]),
+		BlendState: wgpu.BlendStateReplace,
+		FilterMode: wgpu.FilterModeLinear,
+		Material:   e.material,
+	})
+}
+
+// PostProcessChain applies a list of effects one after another. Intermediate results
+// are rendered to temporary images, see AcquireTempImage. Set it as
+// LayoutOptions.PostProcess to apply it to the canvas of a game.
+type PostProcessChain struct {
+	Effects []PostEffect
+}
+
+func NewPostProcessChain(effects ...PostEffect) *PostProcessChain {
+	return &PostProcessChain{Effects: effects}
+}
+
+// Add appends the effect to the end of the chain.
+func (c *PostProcessChain) Add(effect PostEffect) {
+	c.Effects = append(c.Effects, effect)
+}
+
+// Apply runs all effects of the chain on the source and returns the result. The result is
+// a temporary image that is only valid during the current frame. If the chain has no
+// effects, the source is returned as is.
+func (c *PostProcessChain) Apply(source *Image) *Image {
+	if len(c.Effects) == 0 {
+		return source
+	}
+
+	opts := &NewImageOptions{Format: source.Format()}
+
+	// the two images we ping-pong between
+	var images [2]*Image
+
+	input := source
+
+	for idx, effect := range c.Effects {
+		output := images[idx%2]
+		if output == nil {
+			output = AcquireTempImage(source.Width(), source.Height(), opts)
+			images[idx%2] = output
+		}
+
+		effect.Apply(output, input)
+		input = output
+	}
+
+	return input
+}
@@ -77,6 +77,9 @@ func RunGame(opts RunGameOptions) error {
 	currentContext.set(ctx)
 	currentView.set(view)
 
+	// show errors that happen during rendering on screen
+	ctx.OnError = recordRenderError
+
 	initializeCommands(ctx)
 
 	loopState := &LoopState{
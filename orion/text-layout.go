@@ -0,0 +1,543 @@
+package orion
+
+import (
+	"math"
+	"slices"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// FontFace is a font at a fixed size. It provides the metrics used by LayoutText
+// and draws single glyphs. See DebugFontFace and Font.Face. Faces are compared
+// to group glyphs into runs, an implementation must be a comparable type.
+type FontFace interface {
+	Metrics() FontMetrics
+
+	// Advance returns the horizontal distance from the origin of the glyph
+	// for the given rune to the origin of the next glyph.
+	Advance(ch rune) float32
+
+	// Kern returns the additional horizontal adjustment between two runes.
+	Kern(prev, ch rune) float32
+
+	// DrawGlyph draws the glyph with its origin on the baseline at the given position.
+	// The position is in text space, opts.Transform maps text space to the target.
+	DrawGlyph(target *Image, ch rune, origin glm.Vec2f, opts *DrawImageOptions)
+}
+
+type TextWrap uint32
+
+const (
+	// TextWrapWord breaks lines between words. Words longer than
+	// the maximum width are broken between characters.
+	TextWrapWord TextWrap = iota
+
+	// TextWrapCharacter breaks lines between any two characters.
+	TextWrapCharacter
+)
+
+type TextAlign uint32
+
+const (
+	TextAlignLeft TextAlign = iota
+	TextAlignCenter
+	TextAlignRight
+
+	// TextAlignJustify stretches the spaces of wrapped lines to fill the maximum width.
+	// The last line of a paragraph is aligned left.
+	TextAlignJustify
+)
+
+type LayoutTextOptions struct {
+	// Face to lay out the text with. Defaults to DebugFontFace.
+	Face FontFace
+
+	// BoldFace is used for bold markup spans. If not set, bold
+	// text is simulated by drawing the regular glyphs twice.
+	BoldFace FontFace
+
+	// MaxWidth is the width to wrap lines at. Lines are not wrapped if zero.
+	MaxWidth float32
+
+	// Wrap selects where lines can be broken.
+	Wrap TextWrap
+
+	// Align defines the horizontal alignment of lines. Lines are aligned within MaxWidth,
+	// or within the width of the longest line, if MaxWidth is zero.
+	Align TextAlign
+
+	// LineSpacing scales the line height of the face. Defaults to 1.
+	LineSpacing float32
+
+	// TabWidth is the width of a tab in multiples of the width of a space.
+	// Defaults to 4.
+	TabWidth uint
+
+	// Markup enables inline markup: [color=#f00]...[/color] changes the color and
+	// [b]...[/b] makes text bold. Tags can be nested. A literal [ is written as [[.
+	Markup bool
+}
+
+// TextLayout is the result of LayoutText. It contains the position
+// of each glyph, grouped into lines and runs of the same style.
+type TextLayout struct {
+	Lines []TextLine
+
+	// Size of the text. The width is the width of the widest line, or MaxWidth
+	// if set. The height is the sum of the line heights.
+	Size glm.Vec2f
+}
+
+type TextLine struct {
+	Runs []GlyphRun
+
+	// Top is the y coordinate of the top of the line, Baseline the y coordinate
+	// of its baseline. Height is the distance to the top of the next line.
+	Top      float32
+	Baseline float32
+	Height   float32
+
+	// Left is the x coordinate where the line starts after alignment. Width is the
+	// width of the line, not including trailing whitespace.
+	Left  float32
+	Width float32
+
+	// Start and End are the byte offsets of the line in the source text.
+	// End points after the last rune of the line, excluding a line break.
+	Start, End int
+}
+
+// GlyphRun is a sequence of glyphs on a line that share the same face and style.
+type GlyphRun struct {
+	Face  FontFace
+	Color Color
+
+	// FauxBold is set for bold spans without a LayoutTextOptions.BoldFace.
+	FauxBold bool
+
+	Glyphs []PositionedGlyph
+}
+
+type PositionedGlyph struct {
+	Rune rune
+
+	// Index is the byte offset of the rune in the source text.
+	Index int
+
+	// Position of the glyph origin on the baseline.
+	Position glm.Vec2f
+
+	Advance float32
+}
+
+// MeasureText returns the size of the text after layout, see TextLayout.Size.
+func MeasureText(text string, opts *LayoutTextOptions) glm.Vec2f {
+	return LayoutText(text, opts).Size
+}
+
+// LayoutText breaks the text into lines and positions each glyph. Lines are
+// separated by '\n', other control characters except tabs are skipped.
+func LayoutText(text string, opts *LayoutTextOptions) *TextLayout {
+	if opts == nil {
+		opts = &LayoutTextOptions{}
+	}
+
+	l := textLayouter{opts: *opts}
+
+	if l.opts.Face == nil {
+		l.opts.Face = DebugFontFace()
+	}
+
+	if l.opts.LineSpacing <= 0 {
+		l.opts.LineSpacing = 1
+	}
+
+	if l.opts.TabWidth == 0 {
+		l.opts.TabWidth = 4
+	}
+
+	runes := parseMarkup(text, opts.Markup)
+
+	// split into paragraphs and wrap each of them
+	start := 0
+	for idx, ch := range runes {
+		if ch.ch == '\n' {
+			l.wrapParagraph(runes[start:idx], ch.index)
+			start = idx + 1
+		}
+	}
+
+	l.wrapParagraph(runes[start:], len(text))
+
+	return l.finish()
+}
+
+type layoutGlyph struct {
+	styledRune
+
+	face     FontFace
+	fauxBold bool
+
+	x, advance float32
+}
+
+type layoutLine struct {
+	glyphs     []layoutGlyph
+	start, end int
+
+	// set for lines that were wrapped, in contrast to the last line of a paragraph
+	wrapped bool
+}
+
+type textLayouter struct {
+	opts  LayoutTextOptions
+	lines []layoutLine
+}
+
+// wrapParagraph breaks a paragraph into lines. end is the byte offset
+// of the end of the paragraph in the source text.
+func (l *textLayouter) wrapParagraph(runes []styledRune, end int) {
+	var line []layoutGlyph
+
+	start := end
+	if len(runes) > 0 {
+		start = runes[0].index
+	}
+
+	for _, ch := range runes {
+		if ch.ch < 32 && ch.ch != '\t' || ch.ch == 0x7f {
+			continue
+		}
+
+		glyph := layoutGlyph{styledRune: ch, face: l.opts.Face}
+
+		if ch.style.bold {
+			if l.opts.BoldFace != nil {
+				glyph.face = l.opts.BoldFace
+			} else {
+				glyph.fauxBold = true
+			}
+		}
+
+		line = append(line, glyph)
+
+		for l.opts.MaxWidth > 0 && len(line) > 1 && !isTextSpace(line[len(line)-1].ch) {
+			if l.place(line) <= l.opts.MaxWidth {
+				break
+			}
+
+			// the last glyph does not fit onto the line anymore
+			breakAt := len(line) - 1
+
+			if l.opts.Wrap == TextWrapWord {
+				// break after the last space before the current word
+				if idx := lastWordBreak(line); idx > 0 {
+					breakAt = idx
+				}
+			}
+
+			l.lines = append(l.lines, layoutLine{
+				glyphs:  line[:breakAt:breakAt],
+				start:   start,
+				end:     line[breakAt].index,
+				wrapped: true,
+			})
+
+			start = line[breakAt].index
+			line = append([]layoutGlyph(nil), line[breakAt:]...)
+		}
+	}
+
+	l.lines = append(l.lines, layoutLine{glyphs: line, start: start, end: end})
+}
+
+// place updates the horizontal position of each glyph on the line and
+// returns the width of the line, excluding trailing whitespace.
+func (l *textLayouter) place(line []layoutGlyph) float32 {
+	var pen, width float32
+
+	for idx := range line {
+		glyph := &line[idx]
+
+		if idx > 0 && line[idx-1].face == glyph.face {
+			pen += glyph.face.Kern(line[idx-1].ch, glyph.ch)
+		}
+
+		glyph.x = pen
+
+		if glyph.ch == '\t' {
+			tab := glyph.face.Advance(' ') * float32(l.opts.TabWidth)
+			if tab > 0 {
+				glyph.advance = float32(math.Floor(float64(pen/tab))+1)*tab - pen
+			}
+		} else {
+			glyph.advance = glyph.face.Advance(glyph.ch)
+		}
+
+		pen += glyph.advance
+
+		if !isTextSpace(glyph.ch) {
+			width = pen
+		}
+	}
+
+	return width
+}
+
+// lastWordBreak returns the index of the first glyph after the last space
+// that is followed by a non space, or zero if there is no such glyph.
+func lastWordBreak(line []layoutGlyph) int {
+	for idx := len(line) - 1; idx > 0; idx-- {
+		if isTextSpace(line[idx-1].ch) && !isTextSpace(line[idx].ch) {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+func isTextSpace(ch rune) bool {
+	return ch == ' ' || ch == '\t'
+}
+
+func (l *textLayouter) finish() *TextLayout {
+	layout := &TextLayout{}
+
+	widths := make([]float32, len(l.lines))
+
+	for idx, line := range l.lines {
+		widths[idx] = l.place(line.glyphs)
+		layout.Size[0] = max(layout.Size[0], widths[idx])
+	}
+
+	alignWidth := layout.Size[0]
+	if l.opts.MaxWidth > 0 {
+		alignWidth = l.opts.MaxWidth
+		layout.Size[0] = l.opts.MaxWidth
+	}
+
+	var top float32
+
+	for idx, line := range l.lines {
+		width := widths[idx]
+
+		// vertical metrics of the line are the maximum of all faces on the line
+		metrics := l.opts.Face.Metrics()
+		for _, glyph := range line.glyphs {
+			faceMetrics := glyph.face.Metrics()
+			metrics.Ascent = max(metrics.Ascent, faceMetrics.Ascent)
+			metrics.LineHeight = max(metrics.LineHeight, faceMetrics.LineHeight)
+		}
+
+		var left, spaceExtra float32
+
+		switch l.opts.Align {
+		case TextAlignCenter:
+			left = (alignWidth - width) / 2
+
+		case TextAlignRight:
+			left = alignWidth - width
+
+		case TextAlignJustify:
+			if spaces := countInnerSpaces(line.glyphs); line.wrapped && spaces > 0 {
+				spaceExtra = (alignWidth - width) / float32(spaces)
+				width = alignWidth
+			}
+		}
+
+		textLine := TextLine{
+			Top:      top,
+			Baseline: top + metrics.Ascent,
+			Height:   metrics.LineHeight * l.opts.LineSpacing,
+			Left:     left,
+			Width:    width,
+			Start:    line.start,
+			End:      line.end,
+		}
+
+		var shift float32
+
+		first, last := wordBounds(line.glyphs)
+
+		for glyphIdx, glyph := range line.glyphs {
+			positioned := PositionedGlyph{
+				Rune:     glyph.ch,
+				Index:    glyph.index,
+				Position: glm.Vec2f{left + glyph.x + shift, textLine.Baseline},
+				Advance:  glyph.advance,
+			}
+
+			if glyph.ch == ' ' && glyphIdx > first && glyphIdx < last {
+				positioned.Advance += spaceExtra
+				shift += spaceExtra
+			}
+
+			textLine.appendGlyph(glyph, positioned)
+		}
+
+		layout.Lines = append(layout.Lines, textLine)
+
+		top += textLine.Height
+	}
+
+	layout.Size[1] = top
+
+	return layout
+}
+
+func (line *TextLine) appendGlyph(glyph layoutGlyph, positioned PositionedGlyph) {
+	if len(line.Runs) > 0 {
+		run := &line.Runs[len(line.Runs)-1]
+
+		if run.Face == glyph.face && run.Color == glyph.style.color && run.FauxBold == glyph.fauxBold {
+			run.Glyphs = append(run.Glyphs, positioned)
+			return
+		}
+	}
+
+	line.Runs = append(line.Runs, GlyphRun{
+		Face:     glyph.face,
+		Color:    glyph.style.color,
+		FauxBold: glyph.fauxBold,
+		Glyphs:   []PositionedGlyph{positioned},
+	})
+}
+
+// wordBounds returns the index of the first and of the last glyph that is not a space.
+func wordBounds(line []layoutGlyph) (first, last int) {
+	first = slices.IndexFunc(line, func(glyph layoutGlyph) bool { return !isTextSpace(glyph.ch) })
+
+	last = len(line) - 1
+	for last >= 0 && isTextSpace(line[last].ch) {
+		last--
+	}
+
+	return first, last
+}
+
+// countInnerSpaces counts the spaces between the first and the last word of the line.
+func countInnerSpaces(line []layoutGlyph) int {
+	first, last := wordBounds(line)
+
+	var count int
+	for idx := first + 1; idx < last; idx++ {
+		if line[idx].ch == ' ' {
+			count++
+		}
+	}
+
+	return count
+}
+
+// CaretPosition returns the position of a caret placed before the rune at the given
+// byte offset in the source text, together with the index of its line. The position
+// is at the top of the line. An offset at the end of a line is placed after its last glyph.
+func (l *TextLayout) CaretPosition(index int) (glm.Vec2f, int) {
+	if len(l.Lines) == 0 {
+		return glm.Vec2f{}, 0
+	}
+
+	for lineIdx, line := range l.Lines {
+		if index > line.End {
+			continue
+		}
+
+		// an offset at the end of a wrapped line belongs to the next line
+		if index == line.End && lineIdx+1 < len(l.Lines) && l.Lines[lineIdx+1].Start == index {
+			continue
+		}
+
+		x := line.Left
+		for _, run := range line.Runs {
+			for _, glyph := range run.Glyphs {
+				if glyph.Index >= index {
+					return glm.Vec2f{glyph.Position[0], line.Top}, lineIdx
+				}
+
+				x = glyph.Position[0] + glyph.Advance
+			}
+		}
+
+		return glm.Vec2f{x, line.Top}, lineIdx
+	}
+
+	// behind the end of the text
+	lineIdx := len(l.Lines) - 1
+	pos, _ := l.CaretPosition(l.Lines[lineIdx].End)
+	return pos, lineIdx
+}
+
+// IndexAt returns the byte offset in the source text of the caret position
+// closest to the given point in text space.
+func (l *TextLayout) IndexAt(pos glm.Vec2f) int {
+	if len(l.Lines) == 0 {
+		return 0
+	}
+
+	line := l.Lines[len(l.Lines)-1]
+	for _, candidate := range l.Lines {
+		if pos[1] < candidate.Top+candidate.Height {
+			line = candidate
+			break
+		}
+	}
+
+	for _, run := range line.Runs {
+		for _, glyph := range run.Glyphs {
+			if pos[0] < glyph.Position[0]+glyph.Advance/2 {
+				return glyph.Index
+			}
+		}
+	}
+
+	return line.End
+}
+
+type DrawTextLayoutOptions struct {
+	// Transform to apply to the text. The origin of the text
+	// is the top left corner of the first line.
+	Transform glm.Mat3f
+
+	// Color of the text. Colors of markup spans are multiplied with this color.
+	ColorScale ColorScale
+
+	// BlendState defines how to blend the text with the
+	// existing framebuffer. The default is BlendStateDefault.
+	BlendState wgpu.BlendState
+
+	// Layer to draw the text on, see DrawImageOptions.
+	Layer float32
+}
+
+// DrawTextLayout draws all glyphs of the layout, see LayoutText.
+func (i *Image) DrawTextLayout(layout *TextLayout, opts *DrawTextLayoutOptions) {
+	if opts == nil {
+		opts = &DrawTextLayoutOptions{}
+	}
+
+	imageOpts := DrawImageOptions{
+		Transform:  opts.Transform,
+		BlendState: opts.BlendState,
+		Layer:      opts.Layer,
+	}
+
+	for _, line := range layout.Lines {
+		for _, run := range line.Runs {
+			imageOpts.ColorScale = opts.ColorScale.Scaled(run.Color.ToVec())
+
+			for _, glyph := range run.Glyphs {
+				if isTextSpace(glyph.Rune) {
+					continue
+				}
+
+				run.Face.DrawGlyph(i, glyph.Rune, glyph.Position, &imageOpts)
+
+				if run.FauxBold {
+					// draw a second time with a small offset to thicken the strokes
+					run.Face.DrawGlyph(i, glyph.Rune, glyph.Position.Add(glm.Vec2f{1, 0}), &imageOpts)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,516 @@
+package orion
+
+import (
+	"testing"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+// monospaceFace is a font face where every glyph is 10 pixels wide.
+// The pair "AV" is kerned by -2 pixels.
+type monospaceFace struct{}
+
+func (monospaceFace) Metrics() FontMetrics {
+	return FontMetrics{Ascent: 8, Descent: 4, LineHeight: 12}
+}
+
+func (monospaceFace) Advance(ch rune) float32 {
+	return 10
+}
+
+func (monospaceFace) Kern(prev, ch rune) float32 {
+	if prev == 'A' && ch == 'V' {
+		return -2
+	}
+
+	return 0
+}
+
+func (monospaceFace) DrawGlyph(target *Image, ch rune, origin glm.Vec2f, opts *DrawImageOptions) {
+}
+
+// tallFace is like monospaceFace, but with a larger line height.
+type tallFace struct {
+	monospaceFace
+}
+
+func (tallFace) Metrics() FontMetrics {
+	return FontMetrics{Ascent: 16, Descent: 4, LineHeight: 20}
+}
+
+// lineText returns the runes of all glyphs on the line
+func lineText(line TextLine) string {
+	var runes []rune
+	for _, run := range line.Runs {
+		for _, glyph := range run.Glyphs {
+			runes = append(runes, glyph.Rune)
+		}
+	}
+
+	return string(runes)
+}
+
+func TestLayoutTextLines(t *testing.T) {
+	type expectedLine struct {
+		text       string
+		start, end int
+	}
+
+	cases := []struct {
+		name     string
+		text     string
+		maxWidth float32
+		wrap     TextWrap
+		lines    []expectedLine
+	}{
+		{
+			name:  "single line",
+			text:  "hello world",
+			lines: []expectedLine{{"hello world", 0, 11}},
+		},
+		{
+			name:  "empty text",
+			text:  "",
+			lines: []expectedLine{{"", 0, 0}},
+		},
+		{
+			name:  "line breaks",
+			text:  "ab\n\ncd",
+			lines: []expectedLine{{"ab", 0, 2}, {"", 3, 3}, {"cd", 4, 6}},
+		},
+		{
+			name:  "control characters are skipped",
+			text:  "a\x01b\x7f",
+			lines: []expectedLine{{"ab", 0, 4}},
+		},
+		{
+			name:     "word wrap",
+			text:     "hello world",
+			maxWidth: 60,
+			lines:    []expectedLine{{"hello ", 0, 6}, {"world", 6, 11}},
+		},
+		{
+			name:     "trailing spaces do not wrap",
+			text:     "ab    cd",
+			maxWidth: 30,
+			lines:    []expectedLine{{"ab    ", 0, 6}, {"cd", 6, 8}},
+		},
+		{
+			name:     "long words are broken between characters",
+			text:     "a verylongword",
+			maxWidth: 50,
+			lines:    []expectedLine{{"a ", 0, 2}, {"veryl", 2, 7}, {"ongwo", 7, 12}, {"rd", 12, 14}},
+		},
+		{
+			name:     "character wrap",
+			text:     "ab cdefg",
+			maxWidth: 35,
+			wrap:     TextWrapCharacter,
+			lines:    []expectedLine{{"ab ", 0, 3}, {"cde", 3, 6}, {"fg", 6, 8}},
+		},
+		{
+			name:     "multi byte runes",
+			text:     "äöü ßé",
+			maxWidth: 40,
+			lines:    []expectedLine{{"äöü ", 0, 7}, {"ßé", 7, 11}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			layout := LayoutText(tc.text, &LayoutTextOptions{
+				Face:     monospaceFace{},
+				MaxWidth: tc.maxWidth,
+				Wrap:     tc.wrap,
+			})
+
+			if len(layout.Lines) != len(tc.lines) {
+				t.Fatalf("expected %d lines, got %d", len(tc.lines), len(layout.Lines))
+			}
+
+			for idx, expected := range tc.lines {
+				line := layout.Lines[idx]
+
+				if text := lineText(line); text != expected.text {
+					t.Errorf("line %d: expected %q, got %q", idx, expected.text, text)
+				}
+
+				if line.Start != expected.start || line.End != expected.end {
+					t.Errorf("line %d: expected range %d-%d, got %d-%d",
+						idx, expected.start, expected.end, line.Start, line.End)
+				}
+
+				if line.Top != float32(idx)*12 || line.Baseline != line.Top+8 || line.Height != 12 {
+					t.Errorf("line %d: unexpected vertical metrics %v %v %v", idx, line.Top, line.Baseline, line.Height)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutTextAlign(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		align    TextAlign
+		maxWidth float32
+
+		// left and width of each line, and the x position of each glyph of the first line
+		left      []float32
+		width     []float32
+		positions []float32
+	}{
+		{
+			name:      "left",
+			text:      "ab",
+			maxWidth:  100,
+			left:      []float32{0},
+			width:     []float32{20},
+			positions: []float32{0, 10},
+		},
+		{
+			name:      "center",
+			text:      "ab",
+			align:     TextAlignCenter,
+			maxWidth:  100,
+			left:      []float32{40},
+			width:     []float32{20},
+			positions: []float32{40, 50},
+		},
+		{
+			name:      "right",
+			text:      "ab",
+			align:     TextAlignRight,
+			maxWidth:  100,
+			left:      []float32{80},
+			width:     []float32{20},
+			positions: []float32{80, 90},
+		},
+		{
+			name:      "right without max width",
+			text:      "a\nbcd",
+			align:     TextAlignRight,
+			left:      []float32{20, 0},
+			width:     []float32{10, 30},
+			positions: []float32{20},
+		},
+		{
+			name:      "justify stretches inner spaces of wrapped lines",
+			text:      "aa bb cc dd",
+			align:     TextAlignJustify,
+			maxWidth:  85,
+			left:      []float32{0, 0},
+			width:     []float32{85, 20},
+			positions: []float32{0, 10, 20, 32.5, 42.5, 52.5, 65, 75, 85},
+		},
+		{
+			name:      "justify keeps the last line",
+			text:      "aa bb",
+			align:     TextAlignJustify,
+			maxWidth:  85,
+			left:      []float32{0},
+			width:     []float32{50},
+			positions: []float32{0, 10, 20, 30, 40},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			layout := LayoutText(tc.text, &LayoutTextOptions{
+				Face:     monospaceFace{},
+				MaxWidth: tc.maxWidth,
+				Align:    tc.align,
+			})
+
+			if len(layout.Lines) != len(tc.left) {
+				t.Fatalf("expected %d lines, got %d", len(tc.left), len(layout.Lines))
+			}
+
+			for idx, line := range layout.Lines {
+				if line.Left != tc.left[idx] || line.Width != tc.width[idx] {
+					t.Errorf("line %d: expected left %v and width %v, got %v and %v",
+						idx, tc.left[idx], tc.width[idx], line.Left, line.Width)
+				}
+			}
+
+			var positions []float32
+			for _, glyph := range layout.Lines[0].Runs[0].Glyphs {
+				positions = append(positions, glyph.Position[0])
+			}
+
+			if len(positions) != len(tc.positions) {
+				t.Fatalf("expected %d glyphs, got %d", len(tc.positions), len(positions))
+			}
+
+			for idx := range positions {
+				if positions[idx] != tc.positions[idx] {
+					t.Errorf("expected glyph positions %v, got %v", tc.positions, positions)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutTextSpacing(t *testing.T) {
+	cases := []struct {
+		name      string
+		text      string
+		opts      LayoutTextOptions
+		positions []float32
+		size      glm.Vec2f
+	}{
+		{
+			name:      "kerning",
+			text:      "AVA",
+			positions: []float32{0, 8, 18},
+			size:      glm.Vec2f{28, 12},
+		},
+		{
+			name:      "tab stops",
+			text:      "a\tb\t\tc",
+			positions: []float32{0, 10, 40, 50, 80, 120},
+			size:      glm.Vec2f{130, 12},
+		},
+		{
+			name:      "tab width",
+			text:      "\tb",
+			opts:      LayoutTextOptions{TabWidth: 2},
+			positions: []float32{0, 20},
+			size:      glm.Vec2f{30, 12},
+		},
+		{
+			name:      "trailing whitespace is not part of the size",
+			text:      "ab  ",
+			positions: []float32{0, 10, 20, 30},
+			size:      glm.Vec2f{20, 12},
+		},
+		{
+			name:      "line spacing",
+			text:      "a",
+			opts:      LayoutTextOptions{LineSpacing: 1.5},
+			positions: []float32{0},
+			size:      glm.Vec2f{10, 18},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.Face = monospaceFace{}
+
+			layout := LayoutText(tc.text, &opts)
+
+			var positions []float32
+			for _, glyph := range layout.Lines[0].Runs[0].Glyphs {
+				positions = append(positions, glyph.Position[0])
+			}
+
+			if len(positions) != len(tc.positions) {
+				t.Fatalf("expected glyph positions %v, got %v", tc.positions, positions)
+			}
+
+			for idx := range positions {
+				if positions[idx] != tc.positions[idx] {
+					t.Fatalf("expected glyph positions %v, got %v", tc.positions, positions)
+				}
+			}
+
+			if layout.Size != tc.size {
+				t.Errorf("expected size %v, got %v", tc.size, layout.Size)
+			}
+
+			if size := MeasureText(tc.text, &opts); size != layout.Size {
+				t.Errorf("expected MeasureText to return %v, got %v", layout.Size, size)
+			}
+		})
+	}
+}
+
+func TestLayoutTextMarkup(t *testing.T) {
+	red := pulse.ColorHex("#f00")
+	blue := pulse.ColorHex("#00f")
+
+	type expectedRun struct {
+		text     string
+		face     FontFace
+		color    Color
+		fauxBold bool
+	}
+
+	cases := []struct {
+		name     string
+		text     string
+		markup   bool
+		boldFace FontFace
+		runs     []expectedRun
+	}{
+		{
+			name: "markup disabled",
+			text: "[b]a[/b]",
+			runs: []expectedRun{{text: "[b]a[/b]", face: monospaceFace{}}},
+		},
+		{
+			name:   "faux bold",
+			text:   "a[b]b[/b]c",
+			markup: true,
+			runs: []expectedRun{
+				{text: "a", face: monospaceFace{}},
+				{text: "b", face: monospaceFace{}, fauxBold: true},
+				{text: "c", face: monospaceFace{}},
+			},
+		},
+		{
+			name:     "bold face",
+			text:     "[b]ab[/b]",
+			markup:   true,
+			boldFace: tallFace{},
+			runs:     []expectedRun{{text: "ab", face: tallFace{}}},
+		},
+		{
+			name:   "nested colors",
+			text:   "[color=#f00]a[color=#00f]b[/color]c[/color]d",
+			markup: true,
+			runs: []expectedRun{
+				{text: "a", face: monospaceFace{}, color: red},
+				{text: "b", face: monospaceFace{}, color: blue},
+				{text: "c", face: monospaceFace{}, color: red},
+				{text: "d", face: monospaceFace{}},
+			},
+		},
+		{
+			name:   "escaped bracket",
+			text:   "[[b]",
+			markup: true,
+			runs:   []expectedRun{{text: "[b]", face: monospaceFace{}}},
+		},
+		{
+			name:   "unknown and malformed tags are kept",
+			text:   "[i]a[color=red]b[b",
+			markup: true,
+			runs:   []expectedRun{{text: "[i]a[color=red]b[b", face: monospaceFace{}}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			layout := LayoutText(tc.text, &LayoutTextOptions{
+				Face:     monospaceFace{},
+				BoldFace: tc.boldFace,
+				Markup:   tc.markup,
+			})
+
+			runs := layout.Lines[0].Runs
+			if len(runs) != len(tc.runs) {
+				t.Fatalf("expected %d runs, got %d", len(tc.runs), len(runs))
+			}
+
+			for idx, expected := range tc.runs {
+				run := runs[idx]
+
+				text := lineText(TextLine{Runs: []GlyphRun{run}})
+
+				if text != expected.text || run.Face != expected.face || run.Color != expected.color || run.FauxBold != expected.fauxBold {
+					t.Errorf("run %d: expected %q %T %v %v, got %q %T %v %v", idx,
+						expected.text, expected.face, expected.color.ToVec(), expected.fauxBold,
+						text, run.Face, run.Color.ToVec(), run.FauxBold)
+				}
+			}
+		})
+	}
+
+	t.Run("glyph indices point into the source", func(t *testing.T) {
+		layout := LayoutText("[b]a[/b][[b", &LayoutTextOptions{Face: monospaceFace{}, Markup: true})
+
+		var indices []int
+		for _, run := range layout.Lines[0].Runs {
+			for _, glyph := range run.Glyphs {
+				indices = append(indices, glyph.Index)
+			}
+		}
+
+		if len(indices) != 3 || indices[0] != 3 || indices[1] != 8 || indices[2] != 10 {
+			t.Errorf("unexpected glyph indices %v", indices)
+		}
+	})
+
+	t.Run("line height of the tallest face", func(t *testing.T) {
+		layout := LayoutText("a[b]b[/b]\nc", &LayoutTextOptions{
+			Face:     monospaceFace{},
+			BoldFace: tallFace{},
+			Markup:   true,
+		})
+
+		first, second := layout.Lines[0], layout.Lines[1]
+
+		if first.Baseline != 16 || first.Height != 20 || second.Top != 20 || second.Height != 12 {
+			t.Errorf("unexpected vertical metrics %+v %+v", first, second)
+		}
+	})
+}
+
+func TestTextLayoutCaret(t *testing.T) {
+	// wraps into "hello " and "world"
+	layout := LayoutText("hello world", &LayoutTextOptions{
+		Face:     monospaceFace{},
+		MaxWidth: 60,
+	})
+
+	caretCases := []struct {
+		name  string
+		index int
+		pos   glm.Vec2f
+		line  int
+	}{
+		{name: "start", index: 0, pos: glm.Vec2f{0, 0}},
+		{name: "within the first line", index: 3, pos: glm.Vec2f{30, 0}},
+		{name: "before the trailing space", index: 5, pos: glm.Vec2f{50, 0}},
+		{name: "at a wrap belongs to the next line", index: 6, pos: glm.Vec2f{0, 12}, line: 1},
+		{name: "within the second line", index: 8, pos: glm.Vec2f{20, 12}, line: 1},
+		{name: "end", index: 11, pos: glm.Vec2f{50, 12}, line: 1},
+		{name: "behind the end", index: 100, pos: glm.Vec2f{50, 12}, line: 1},
+	}
+
+	for _, tc := range caretCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, line := layout.CaretPosition(tc.index)
+			if pos != tc.pos || line != tc.line {
+				t.Errorf("expected %v on line %d, got %v on line %d", tc.pos, tc.line, pos, line)
+			}
+		})
+	}
+
+	indexCases := []struct {
+		name  string
+		pos   glm.Vec2f
+		index int
+	}{
+		{name: "left half of a glyph", pos: glm.Vec2f{24, 5}, index: 2},
+		{name: "right half of a glyph", pos: glm.Vec2f{26, 5}, index: 3},
+		{name: "behind the first line", pos: glm.Vec2f{100, 5}, index: 6},
+		{name: "second line", pos: glm.Vec2f{0, 13}, index: 6},
+		{name: "below the text", pos: glm.Vec2f{100, 100}, index: 11},
+		{name: "above the text", pos: glm.Vec2f{-10, -10}, index: 0},
+	}
+
+	for _, tc := range indexCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if index := layout.IndexAt(tc.pos); index != tc.index {
+				t.Errorf("expected index %d, got %d", tc.index, index)
+			}
+		})
+	}
+
+	t.Run("empty text", func(t *testing.T) {
+		empty := LayoutText("", &LayoutTextOptions{Face: monospaceFace{}})
+
+		if pos, line := empty.CaretPosition(0); pos != (glm.Vec2f{}) || line != 0 {
+			t.Errorf("expected the caret at the origin, got %v on line %d", pos, line)
+		}
+
+		if index := empty.IndexAt(glm.Vec2f{10, 10}); index != 0 {
+			t.Errorf("expected index 0, got %d", index)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package orion
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/oliverbestmann/pulse/pulse"
+)
+
+type textStyle struct {
+	color Color
+	bold  bool
+}
+
+type styledRune struct {
+	ch rune
+
+	// byte offset of the rune in the source text
+	index int
+
+	style textStyle
+}
+
+// parseMarkup splits the text into runes and applies the style of the markup
+// tags around them, see LayoutTextOptions.Markup. Tags that are unknown or
+// malformed are kept as text.
+func parseMarkup(text string, markup bool) []styledRune {
+	runes := make([]styledRune, 0, len(text))
+
+	var colors []Color
+	var bold int
+
+	currentStyle := func() textStyle {
+		var style textStyle
+
+		if len(colors) > 0 {
+			style.color = colors[len(colors)-1]
+		}
+
+		style.bold = bold > 0
+
+		return style
+	}
+
+	applyTag := func(tag string) bool {
+		switch tag {
+		case "b":
+			bold += 1
+
+		case "/b":
+			bold = max(0, bold-1)
+
+		case "/color":
+			if len(colors) > 0 {
+				colors = colors[:len(colors)-1]
+			}
+
+		default:
+			value, ok := strings.CutPrefix(tag, "color=")
+			if !ok {
+				return false
+			}
+
+			color, err := pulse.ParseColorHex(value)
+			if err != nil {
+				return false
+			}
+
+			colors = append(colors, color)
+		}
+
+		return true
+	}
+
+	for idx := 0; idx < len(text); {
+		ch, size := utf8.DecodeRuneInString(text[idx:])
+
+		if markup && ch == '[' {
+			if strings.HasPrefix(text[idx:], "[[") {
+				// escaped bracket
+				runes = append(runes, styledRune{ch: '[', index: idx, style: currentStyle()})
+				idx += 2
+				continue
+			}
+
+			if end := strings.IndexByte(text[idx:], ']'); end > 0 && applyTag(text[idx+1:idx+end]) {
+				idx += end + 1
+				continue
+			}
+		}
+
+		runes = append(runes, styledRune{ch: ch, index: idx, style: currentStyle()})
+		idx += size
+	}
+
+	return runes
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/oliverbestmann/pulse/glm"
 	"github.com/oliverbestmann/pulse/pulse"
 	"github.com/oliverbestmann/pulse/pulse/commands"
+	"github.com/oliverbestmann/webgpu/wgpu"
 )
 
 type DebugTextOptions struct {
@@ -39,3 +40,43 @@ func DebugText(dest *Image, text string, opts *DebugTextOptions) {
 		TabWidth:    tabWidth,
 	})
 }
+
+// DebugFontFace returns the built-in 6x10 pixel font used by DebugText as a FontFace.
+// Characters not contained in the font are drawn as a question mark.
+func DebugFontFace() FontFace {
+	return debugFontFace{}
+}
+
+type debugFontFace struct{}
+
+func (debugFontFace) Metrics() FontMetrics {
+	return FontMetrics{
+		Ascent:     8,
+		Descent:    2,
+		LineHeight: 10,
+		CapHeight:  7,
+		XHeight:    5,
+	}
+}
+
+func (debugFontFace) Advance(rune) float32 {
+	return 6
+}
+
+func (debugFontFace) Kern(rune, rune) float32 {
+	return 0
+}
+
+func (debugFontFace) DrawGlyph(target *Image, ch rune, origin glm.Vec2f, opts *DrawImageOptions) {
+	if ch == ' ' {
+		return
+	}
+
+	glyph := asImage(textCommand.Get().Glyph(ch))
+
+	glyphOpts := *opts
+	glyphOpts.Transform = opts.Transform.Translate(origin[0], origin[1]-8)
+	glyphOpts.FilterMode = wgpu.FilterModeNearest
+
+	target.DrawImage(glyph, &glyphOpts)
+}
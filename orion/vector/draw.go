@@ -14,6 +14,9 @@ type FillPathOptions struct {
 	ColorScale orion.ColorScale
 	BlendState wgpu.BlendState
 	Shader     string
+
+	// Material to fill the path with, replaces Shader if set
+	Material *orion.Material
 }
 
 func FillPath(target *orion.Image, path Path, opts *FillPathOptions) {
@@ -47,6 +50,7 @@ func FillPath(target *orion.Image, path Path, opts *FillPathOptions) {
 		ColorScale: opts.ColorScale,
 		BlendState: opts.BlendState,
 		Shader:     opts.Shader,
+		Material:   opts.Material,
 	})
 }
 
@@ -73,7 +77,8 @@ func StrokePath(target *orion.Image, path Path, opts *StrokePathOptions) {
 	unitScale := calculateUnitScale(opts.Transform)
 	points := path.Contour(unitScale)
 
-	if drawLines == nil {
+	// create the command again if the device was recreated
+	if drawLines == nil || drawLines.generation != orion.CurrentContext().Generation() {
 		drawLines = &drawLinesCommand{}
 		drawLines.Init()
 	}
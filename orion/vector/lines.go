@@ -4,7 +4,6 @@ import (
 	_ "embed"
 	"unsafe"
 
-	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/oliverbestmann/pulse/glm"
 	"github.com/oliverbestmann/pulse/orion"
 	"github.com/oliverbestmann/pulse/pulse"
@@ -21,6 +20,9 @@ type drawLinesCommand struct {
 
 	pointsBuf  *wgpu.Buffer
 	configsBuf *wgpu.Buffer
+
+	// generation of the device this command was created with
+	generation uint32
 }
 
 func (d *drawLinesCommand) Flush() {
@@ -30,6 +32,7 @@ func (d *drawLinesCommand) Flush() {
 func (d *drawLinesCommand) Init() {
 	ctx := orion.CurrentContext()
 
+	d.generation = ctx.Generation()
 	d.cache = pulse.NewPipelineCache[pipelineStub](ctx)
 
 	d.configsBuf = ctx.CreateBuffer(&wgpu.BufferDescriptor{
@@ -61,6 +64,8 @@ func (d *drawLinesCommand) Draw(target *pulse.Texture, points []glm.Vec2f, opts
 		blendState = opts.BlendState
 	}
 
+	clip := target.Clip()
+
 	pipelineConf := pipelineStub{
 		Target:      target.Root(),
 		Blend:       blendState,
@@ -68,6 +73,16 @@ func (d *drawLinesCommand) Draw(target *pulse.Texture, points []glm.Vec2f, opts
 		SampleCount: target.SampleCount(),
 	}
 
+	// without a clip each pixel is marked in a temporary stencil texture, so
+	// that overlapping segments do not blend twice
+	stencilReference := uint32(1)
+
+	if clip.Stencil() {
+		// pixels within the clip are marked in the stencil of the depth texture instead
+		pipelineConf.Stencil = lineStencilClip
+		stencilReference = clip.StencilReference
+	}
+
 	toClipSpace := glm.Mat3f{}.
 		Translate(-1.0, 1.0).
 		Scale(2.0/float32(target.Root().Width()), -2.0/float32(target.Root().Height())).
@@ -83,11 +98,41 @@ func (d *drawLinesCommand) Draw(target *pulse.Texture, points []glm.Vec2f, opts
 		PointsCount: uint32(len(points)),
 	}
 
-	pipeline := d.cache.Get(pipelineConf)
-
-	stencilView := d.getStencilTex(target.Root())
+	pipeline, err := d.cache.TryGet(pipelineConf)
+	if err != nil {
+		// skip this batch and let the context handle the error
+		orion.CurrentContext().ReportError(err)
+		return
+	}
 
 	dev := orion.CurrentContext()
+
+	var depthStencil *wgpu.RenderPassDepthStencilAttachment
+	var restorePipeline pulse.CachedPipeline
+
+	if clip.Stencil() {
+		restoreConf := pipelineConf
+		restoreConf.Stencil = lineStencilRestore
+
+		restorePipeline, err = d.cache.TryGet(restoreConf)
+		if err != nil {
+			dev.ReportError(err)
+			return
+		}
+
+		depthStencil = target.DepthTexture(dev).DepthAttachment(wgpu.LoadOpLoad)
+	} else {
+		// the stencil texture is only needed during this draw call
+		stencilTex := d.acquireStencilTex(target.Root())
+		defer orion.TexturePool().Recycle(stencilTex)
+
+		depthStencil = &wgpu.RenderPassDepthStencilAttachment{
+			View:           stencilTex.ToWGPUTextureView(),
+			StencilLoadOp:  wgpu.LoadOpClear,
+			StencilStoreOp: wgpu.StoreOpStore,
+		}
+	}
+
 	enc := dev.CreateCommandEncoder(nil)
 	defer enc.Release()
 
@@ -102,39 +147,29 @@ func (d *drawLinesCommand) Draw(target *pulse.Texture, points []glm.Vec2f, opts
 				StoreOp:       wgpu.StoreOpStore,
 			},
 		},
-		DepthStencilAttachment: &wgpu.RenderPassDepthStencilAttachment{
-			View:           stencilView,
-			StencilLoadOp:  wgpu.LoadOpClear,
-			StencilStoreOp: wgpu.StoreOpStore,
-		},
-	})
-
-	bindGroup := dev.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: pipeline.GetBindGroupLayout(0),
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding: 0,
-				Buffer:  d.configsBuf,
-				Size:    wgpu.WholeSize,
-			},
-			{
-				Binding: 1,
-				Buffer:  d.pointsBuf,
-				Size:    wgpu.WholeSize,
-			},
-		},
+		DepthStencilAttachment: depthStencil,
 	})
 
+	bindGroup := d.createBindGroup(pipeline)
 	defer bindGroup.Release()
 
-	x, y := target.Offset().XY()
-	w, h := target.Size().XY()
-
 	pass.SetPipeline(pipeline.Pipeline)
 	pass.SetBindGroup(0, bindGroup, nil)
-	pass.SetStencilReference(1)
-	pass.SetScissorRect(x, y, w, h)
+	pass.SetStencilReference(stencilReference)
+	pass.SetScissorRect(target.ScissorRect().XYWH())
 	pass.Draw(6+circleTriangleCount*3, uint32(len(points)), 0, 0)
+
+	if clip.Stencil() {
+		// reset the marked pixels to the stencil value of the clip
+		restoreBindGroup := d.createBindGroup(restorePipeline)
+		defer restoreBindGroup.Release()
+
+		pass.SetPipeline(restorePipeline.Pipeline)
+		pass.SetBindGroup(0, restoreBindGroup, nil)
+		pass.SetStencilReference(stencilReference + 1)
+		pass.Draw(6+circleTriangleCount*3, uint32(len(points)), 0, 0)
+	}
+
 	pass.End()
 
 	buf := enc.Finish(nil)
@@ -147,9 +182,26 @@ func (d *drawLinesCommand) Draw(target *pulse.Texture, points []glm.Vec2f, opts
 	dev.Queue.Submit(buf)
 }
 
-func (d *drawLinesCommand) getStencilTex(target *pulse.Texture) *wgpu.TextureView {
-	desc := wgpu.TextureDescriptor{
-		Label:     "LinesStencil",
+func (d *drawLinesCommand) createBindGroup(pipeline pulse.CachedPipeline) *wgpu.BindGroup {
+	return orion.CurrentContext().CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Layout: pipeline.GetBindGroupLayout(0),
+		Entries: []wgpu.BindGroupEntry{
+			{
+				Binding: 0,
+				Buffer:  d.configsBuf,
+				Size:    wgpu.WholeSize,
+			},
+			{
+				Binding: 1,
+				Buffer:  d.pointsBuf,
+				Size:    wgpu.WholeSize,
+			},
+		},
+	})
+}
+
+func (d *drawLinesCommand) acquireStencilTex(target *pulse.Texture) *pulse.Texture {
+	return orion.TexturePool().AcquireFromDesc(wgpu.TextureDescriptor{
 		Usage:     wgpu.TextureUsageRenderAttachment,
 		Dimension: wgpu.TextureDimension2D,
 		Size: wgpu.Extent3D{
@@ -160,19 +212,7 @@ func (d *drawLinesCommand) getStencilTex(target *pulse.Texture) *wgpu.TextureVie
 		Format:        wgpu.TextureFormatStencil8,
 		SampleCount:   target.SampleCount(),
 		MipLevelCount: 1,
-	}
-
-	if cached, ok := stencilTexCache.Get(desc); ok {
-		return cached
-	}
-
-	dev := orion.CurrentContext()
-
-	texture := dev.CreateTexture(&desc)
-	view := texture.CreateView(nil)
-	stencilTexCache.Add(desc, view)
-
-	return view
+	})
 }
 
 type lineConfig struct {
@@ -182,21 +222,44 @@ type lineConfig struct {
 	PointsCount uint32
 }
 
+// lineStencil selects how the stencil test prevents drawing a pixel twice
+type lineStencil uint8
+
+const (
+	// mark drawn pixels in a temporary stencil texture
+	lineStencilOverlap lineStencil = iota
+
+	// mark drawn pixels within the clip by incrementing their value
+	// in the stencil of the depth texture
+	lineStencilClip
+
+	// restore pixels marked by lineStencilClip without drawing
+	lineStencilRestore
+)
+
 type pipelineStub struct {
 	Target *pulse.Texture
 
 	Blend       wgpu.BlendState
 	Format      wgpu.TextureFormat
 	SampleCount uint32
+	Stencil     lineStencil
 }
 
-func (d pipelineStub) Specialize(dev *wgpu.Device) *wgpu.RenderPipeline {
-	shader := dev.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label:      "LinesShader",
-		WGSLSource: &wgpu.ShaderSourceWGSL{Code: lineShader},
-	})
+func (d pipelineStub) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
+	shader, err := pulse.CreateShaderModule(dev, "LinesShader", lineShader)
+	if err != nil {
+		return nil, err
+	}
+
+	defer shader.Release()
 
-	return dev.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+	writeMask := wgpu.ColorWriteMaskAll
+	if d.Stencil == lineStencilRestore {
+		writeMask = wgpu.ColorWriteMaskNone
+	}
+
+	return pulse.CreateRenderPipeline(dev, &wgpu.RenderPipelineDescriptor{
 		Label: "LinesPipeline",
 		Vertex: wgpu.VertexState{
 			Module:     shader,
@@ -209,29 +272,11 @@ func (d pipelineStub) Specialize(dev *wgpu.Device) *wgpu.RenderPipeline {
 				{
 					Format:    d.Format,
 					Blend:     &d.Blend,
-					WriteMask: wgpu.ColorWriteMaskAll,
+					WriteMask: writeMask,
 				},
 			},
 		},
-		DepthStencil: &wgpu.DepthStencilState{
-			Format: wgpu.TextureFormatStencil8,
-			StencilFront: wgpu.StencilFaceState{
-				// draw only if reference is greater than stencil value.
-				//  set stencil value to reference if drawn
-				Compare: wgpu.CompareFunctionGreater,
-				PassOp:  wgpu.StencilOperationReplace,
-				FailOp:  wgpu.StencilOperationKeep,
-			},
-
-			StencilBack: wgpu.StencilFaceState{
-				Compare: wgpu.CompareFunctionGreater,
-				PassOp:  wgpu.StencilOperationReplace,
-				FailOp:  wgpu.StencilOperationKeep,
-			},
-
-			StencilWriteMask: 0xff,
-			StencilReadMask:  0xff,
-		},
+		DepthStencil: d.depthStencilState(),
 		Primitive: wgpu.PrimitiveState{
 			Topology: wgpu.PrimitiveTopologyTriangleList,
 			CullMode: wgpu.CullModeNone,
@@ -244,8 +289,43 @@ func (d pipelineStub) Specialize(dev *wgpu.Device) *wgpu.RenderPipeline {
 	})
 }
 
-var stencilTexCache, _ = lru.NewWithEvict[wgpu.TextureDescriptor, *wgpu.TextureView](4, evictStencilTex)
+func (d pipelineStub) depthStencilState() *wgpu.DepthStencilState {
+	if d.Stencil == lineStencilOverlap {
+		face := wgpu.StencilFaceState{
+			// draw only if reference is greater than stencil value.
+			//  set stencil value to reference if drawn
+			Compare: wgpu.CompareFunctionGreater,
+			PassOp:  wgpu.StencilOperationReplace,
+			FailOp:  wgpu.StencilOperationKeep,
+		}
+
+		return &wgpu.DepthStencilState{
+			Format:           wgpu.TextureFormatStencil8,
+			StencilFront:     face,
+			StencilBack:      face,
+			StencilWriteMask: 0xff,
+			StencilReadMask:  0xff,
+		}
+	}
 
-func evictStencilTex(_ wgpu.TextureDescriptor, view *wgpu.TextureView) {
-	view.Release()
+	// draw only if the stencil value equals the reference, then
+	// increment it. The restore pass decrements the value again.
+	face := wgpu.StencilFaceState{
+		Compare: wgpu.CompareFunctionEqual,
+		PassOp:  wgpu.StencilOperationIncrementClamp,
+		FailOp:  wgpu.StencilOperationKeep,
+	}
+
+	if d.Stencil == lineStencilRestore {
+		face.PassOp = wgpu.StencilOperationDecrementClamp
+	}
+
+	return &wgpu.DepthStencilState{
+		Format:           pulse.DepthTextureFormat,
+		DepthCompare:     wgpu.CompareFunctionAlways,
+		StencilFront:     face,
+		StencilBack:      face,
+		StencilWriteMask: 0xff,
+		StencilReadMask:  0xff,
+	}
 }
@@ -0,0 +1,197 @@
+package pulse
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+type AtlasOptions struct {
+	// Size of each page. Defaults to 2048x2048.
+	Width  uint32
+	Height uint32
+
+	// Format of the pages. Defaults to rgba8unorm-srgb.
+	Format wgpu.TextureFormat
+
+	// Number of transparent pixels between two images in the atlas.
+	Padding uint32
+
+	// Number of times the border pixels of an image are repeated around the image.
+	// This prevents colors of neighbouring images from bleeding in when sampling
+	// with a linear filter. The extruded pixels are not part of the returned SubTexture.
+	Extrude uint32
+
+	// Label of the page textures
+	Label string
+}
+
+// Atlas packs many small images into a few large textures (pages). Images
+// on the same page share a texture, so they can be drawn in a single batch.
+// A new page is created once an image does not fit into the existing pages.
+type Atlas struct {
+	ctx   *Context
+	opts  AtlasOptions
+	pages []atlasPage
+}
+
+type atlasPage struct {
+	texture *Texture
+	packer  *skylinePacker
+}
+
+func NewAtlas(ctx *Context, opts AtlasOptions) *Atlas {
+	if opts.Width == 0 {
+		opts.Width = 2048
+	}
+
+	if opts.Height == 0 {
+		opts.Height = 2048
+	}
+
+	if opts.Format == wgpu.TextureFormatUndefined {
+		opts.Format = wgpu.TextureFormatRGBA8UnormSrgb
+	}
+
+	if opts.Label == "" {
+		opts.Label = "Atlas"
+	}
+
+	return &Atlas{ctx: ctx, opts: opts}
+}
+
+// Add copies the image into the atlas and returns a SubTexture
+// of the page the image was placed on.
+func (a *Atlas) Add(src image.Image) (*Texture, error) {
+	width := uint32(src.Bounds().Dx())
+	height := uint32(src.Bounds().Dy())
+
+	border := a.opts.Extrude + a.opts.Padding
+
+	// space required in the atlas. we add the padding on both sides
+	// so images are also kept away from the border of the page.
+	size := glm.Vec2u{width + 2*border, height + 2*border}
+	if size[0] > a.opts.Width || size[1] > a.opts.Height {
+		return nil, fmt.Errorf(
+			"image of size %dx%d does not fit into atlas page of size %dx%d",
+			width, height, a.opts.Width, a.opts.Height,
+		)
+	}
+
+	page, pos := a.allocate(size)
+
+	// the region we write, including the extruded border
+	extruded := extrude(src, a.opts.Extrude)
+	region := RectangleFromXYWH(
+		pos[0]+a.opts.Padding,
+		pos[1]+a.opts.Padding,
+		uint32(extruded.Rect.Dx()),
+		uint32(extruded.Rect.Dy()),
+	)
+
+	page.texture.WritePixelsToRect(a.ctx, WritePixelsOptions{
+		Pixels: extruded.Pix,
+		Region: region,
+		Stride: uint32(extruded.Stride),
+	})
+
+	imagePos := glm.Vec2u{pos[0] + border, pos[1] + border}
+	return page.texture.SubTexture(imagePos, glm.Vec2u{width, height}), nil
+}
+
+func (a *Atlas) allocate(size glm.Vec2u) (*atlasPage, glm.Vec2u) {
+	for idx := range a.pages {
+		page := &a.pages[idx]
+
+		if pos, ok := page.packer.Allocate(size); ok {
+			return page, pos
+		}
+	}
+
+	// no space left on any page, create a new one
+	texture := NewTexture(a.ctx, NewTextureOptions{
+		Format: a.opts.Format,
+		Width:  a.opts.Width,
+		Height: a.opts.Height,
+		Label:  fmt.Sprintf("%s.Page%d", a.opts.Label, len(a.pages)),
+	})
+
+	a.pages = append(a.pages, atlasPage{
+		texture: texture,
+		packer:  newSkylinePacker(a.opts.Width, a.opts.Height),
+	})
+
+	page := &a.pages[len(a.pages)-1]
+
+	pos, ok := page.packer.Allocate(size)
+	if !ok {
+		// we've checked the size before
+		panic("image does not fit into empty atlas page")
+	}
+
+	return page, pos
+}
+
+// Pages returns the textures of all pages allocated so far.
+func (a *Atlas) Pages() []*Texture {
+	var textures []*Texture
+	for _, page := range a.pages {
+		textures = append(textures, page.texture)
+	}
+
+	return textures
+}
+
+// Release releases all pages. Textures returned by Add
+// must not be used afterward.
+func (a *Atlas) Release() {
+	for _, page := range a.pages {
+		page.texture.Release()
+	}
+
+	a.pages = nil
+}
+
+// extrude copies the image into a new NRGBA image, repeating
+// the border pixels the given number of times.
+func extrude(src image.Image, amount uint32) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	border := int(amount)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width+2*border, height+2*border))
+
+	inner := image.Rect(border, border, border+width, border+height)
+	draw.Draw(dst, inner, src, bounds.Min, draw.Src)
+
+	if border == 0 || width == 0 || height == 0 {
+		return dst
+	}
+
+	// repeat the first and last column of each row
+	for y := border; y < border+height; y++ {
+		row := dst.Pix[y*dst.Stride:]
+		left := row[border*4 : border*4+4]
+		right := row[(border+width-1)*4 : (border+width)*4]
+
+		for x := range border {
+			copy(row[x*4:], left)
+			copy(row[(border+width+x)*4:], right)
+		}
+	}
+
+	// repeat the first and last row, including the extruded corners
+	rowLen := dst.Stride
+	top := dst.Pix[border*rowLen : (border+1)*rowLen]
+	bottom := dst.Pix[(border+height-1)*rowLen : (border+height)*rowLen]
+
+	for y := range border {
+		copy(dst.Pix[y*rowLen:], top)
+		copy(dst.Pix[(border+height+y)*rowLen:], bottom)
+	}
+
+	return dst
+}
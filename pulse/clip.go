@@ -0,0 +1,50 @@
+package pulse
+
+import "github.com/oliverbestmann/webgpu/wgpu"
+
+// DepthTextureFormat is the format of the depth texture attached to a
+// root texture, see Texture.DepthTexture. The stencil aspect holds the
+// stencil values used for clipping.
+const DepthTextureFormat = wgpu.TextureFormatDepth24PlusStencil8
+
+// Clip restricts drawing into a root texture and all of its sub textures.
+// Commands read the clip of their target when they flush, pending draws
+// must be flushed before the clip is changed.
+type Clip struct {
+	// Scissor limits drawing to a rectangle in pixels of the root texture.
+	// Drawing is not limited if Scissor is nil.
+	Scissor *Rectangle2u
+
+	// StencilReference enables the stencil test if it is not zero. Only pixels
+	// with a value equal to the reference in the stencil aspect of the depth
+	// texture are drawn.
+	StencilReference uint32
+}
+
+// Stencil returns true, if the clip uses the stencil test.
+func (c Clip) Stencil() bool {
+	return c.StencilReference != 0
+}
+
+// Clip returns the clip of the root of this texture.
+func (t *Texture) Clip() Clip {
+	return t.root.clip
+}
+
+// SetClip sets the clip of the root of this texture.
+func (t *Texture) SetClip(clip Clip) {
+	t.root.clip = clip
+}
+
+// ScissorRect returns the region of this texture within its root,
+// limited to the scissor of the current clip. The result is empty if
+// the clip does not overlap the region.
+func (t *Texture) ScissorRect() Rectangle2u {
+	region := RectangleFromSize(t.Offset(), t.Size())
+
+	if scissor := t.root.clip.Scissor; scissor != nil {
+		region = region.Intersection(*scissor)
+	}
+
+	return region
+}
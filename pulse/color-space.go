@@ -0,0 +1,263 @@
+package pulse
+
+import (
+	"math"
+	"slices"
+)
+
+// ColorHSVA creates a color from hue, saturation and value. The hue is given in degrees,
+// saturation and value are in range 0 to 1. As usual, HSV is defined on top of srgb.
+func ColorHSVA(h, s, v, a float32) Color {
+	h = wrapHue(h) / 60
+
+	chroma := v * s
+	r, g, b := hueToRGB(h, chroma)
+
+	m := v - chroma
+	return ColorSRGBA(r+m, g+m, b+m, a)
+}
+
+// ColorHSLA creates a color from hue, saturation and lightness. The hue is given in degrees,
+// saturation and lightness are in range 0 to 1. As usual, HSL is defined on top of srgb.
+func ColorHSLA(h, s, l, a float32) Color {
+	h = wrapHue(h) / 60
+
+	chroma := (1 - abs(2*l-1)) * s
+	r, g, b := hueToRGB(h, chroma)
+
+	m := l - chroma/2
+	return ColorSRGBA(r+m, g+m, b+m, a)
+}
+
+// ColorOKLab creates a color from the perceptual OKLab color space.
+// See https://bottosson.github.io/posts/oklab/
+func ColorOKLab(l, a, b, alpha float32) Color {
+	l_ := l + 0.3963377774*a + 0.2158037573*b
+	m_ := l - 0.1055613458*a - 0.0638541728*b
+	s_ := l - 0.0894841775*a - 1.2914855480*b
+
+	lc := l_ * l_ * l_
+	mc := m_ * m_ * m_
+	sc := s_ * s_ * s_
+
+	return ColorLinearRGBA(
+		+4.0767416621*lc-3.3077115913*mc+0.2309699292*sc,
+		-1.2684380046*lc+2.6097574011*mc-0.3413193965*sc,
+		-0.0041960863*lc-0.7034186147*mc+1.7076147010*sc,
+		alpha,
+	)
+}
+
+// ToHSVA converts the color into hue (in degrees), saturation and value.
+func (c Color) ToHSVA() (h, s, v, a float32) {
+	r, g, b, a := c.ToSRGBA()
+
+	maxC := max(r, g, b)
+	chroma := maxC - min(r, g, b)
+
+	h = rgbToHue(r, g, b, maxC, chroma)
+
+	if maxC > 0 {
+		s = chroma / maxC
+	}
+
+	return h, s, maxC, a
+}
+
+// ToHSLA converts the color into hue (in degrees), saturation and lightness.
+func (c Color) ToHSLA() (h, s, l, a float32) {
+	r, g, b, a := c.ToSRGBA()
+
+	maxC := max(r, g, b)
+	minC := min(r, g, b)
+	chroma := maxC - minC
+
+	h = rgbToHue(r, g, b, maxC, chroma)
+	l = (maxC + minC) / 2
+
+	if l > 0 && l < 1 {
+		s = chroma / (1 - abs(2*l-1))
+	}
+
+	return h, s, l, a
+}
+
+// ToOKLab converts the color into the perceptual OKLab color space.
+func (c Color) ToOKLab() (l, a, b, alpha float32) {
+	r, g, bl, alpha := c.Components()
+
+	lc := cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	mc := cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	sc := cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+
+	l = 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc
+	a = 1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc
+	b = 0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+
+	return l, a, b, alpha
+}
+
+// LerpOKLab interpolates between c and other in the OKLab color space. Steps
+// of the same size result in a perceptually similar change of the color.
+func (c Color) LerpOKLab(other Color, t float32) Color {
+	l0, a0, b0, alpha0 := c.ToOKLab()
+	l1, a1, b1, alpha1 := other.ToOKLab()
+
+	return ColorOKLab(
+		l0+(l1-l0)*t,
+		a0+(a1-a0)*t,
+		b0+(b1-b0)*t,
+		alpha0+(alpha1-alpha0)*t,
+	)
+}
+
+type GradientStop struct {
+	// Offset of the stop, usually in range 0 to 1
+	Offset float32
+	Color  Color
+}
+
+// Gradient is a sequence of color stops. Colors between two
+// stops are interpolated in OKLab color space.
+type Gradient struct {
+	stops []GradientStop
+}
+
+// NewGradient creates a gradient from the given stops. The stops
+// do not need to be sorted.
+func NewGradient(stops ...GradientStop) Gradient {
+	stops = slices.Clone(stops)
+
+	slices.SortStableFunc(stops, func(a, b GradientStop) int {
+		switch {
+		case a.Offset < b.Offset:
+			return -1
+		case a.Offset > b.Offset:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return Gradient{stops: stops}
+}
+
+// NewGradientOf creates a gradient with the colors evenly distributed between 0 and 1.
+func NewGradientOf(colors ...Color) Gradient {
+	stops := make([]GradientStop, len(colors))
+
+	for idx, color := range colors {
+		var offset float32
+		if len(colors) > 1 {
+			offset = float32(idx) / float32(len(colors)-1)
+		}
+
+		stops[idx] = GradientStop{Offset: offset, Color: color}
+	}
+
+	return Gradient{stops: stops}
+}
+
+// At samples the gradient at the given offset. Offsets before the first or after the
+// last stop return the color of the first or last stop. An empty gradient returns ColorTransparent.
+func (g Gradient) At(offset float32) Color {
+	if len(g.stops) == 0 {
+		return ColorTransparent
+	}
+
+	// find the first stop after the offset
+	idx, _ := slices.BinarySearchFunc(g.stops, offset, func(stop GradientStop, offset float32) int {
+		if stop.Offset <= offset {
+			return -1
+		}
+
+		return 1
+	})
+
+	if idx == 0 {
+		return g.stops[0].Color
+	}
+
+	if idx == len(g.stops) {
+		return g.stops[len(g.stops)-1].Color
+	}
+
+	prev := g.stops[idx-1]
+	next := g.stops[idx]
+
+	t := (offset - prev.Offset) / (next.Offset - prev.Offset)
+	return prev.Color.LerpOKLab(next.Color, t)
+}
+
+// Sample returns count colors evenly spaced between offset 0 and 1.
+func (g Gradient) Sample(count int) []Color {
+	colors := make([]Color, count)
+
+	for idx := range colors {
+		var offset float32
+		if count > 1 {
+			offset = float32(idx) / float32(count-1)
+		}
+
+		colors[idx] = g.At(offset)
+	}
+
+	return colors
+}
+
+// hueToRGB calculates the rgb components for a hue in range 0 to 6 and the given chroma.
+func hueToRGB(h, chroma float32) (r, g, b float32) {
+	x := chroma * (1 - abs(float32(math.Mod(float64(h), 2))-1))
+
+	switch {
+	case h < 1:
+		return chroma, x, 0
+	case h < 2:
+		return x, chroma, 0
+	case h < 3:
+		return 0, chroma, x
+	case h < 4:
+		return 0, x, chroma
+	case h < 5:
+		return x, 0, chroma
+	default:
+		return chroma, 0, x
+	}
+}
+
+// rgbToHue calculates the hue in degrees
+func rgbToHue(r, g, b, maxC, chroma float32) float32 {
+	if chroma == 0 {
+		return 0
+	}
+
+	var h float32
+
+	switch maxC {
+	case r:
+		h = (g - b) / chroma
+	case g:
+		h = (b-r)/chroma + 2
+	default:
+		h = (r-g)/chroma + 4
+	}
+
+	return wrapHue(h * 60)
+}
+
+func wrapHue(h float32) float32 {
+	h = float32(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+
+	return h
+}
+
+func abs(value float32) float32 {
+	return float32(math.Abs(float64(value)))
+}
+
+func cbrt(value float32) float32 {
+	return float32(math.Cbrt(float64(value)))
+}
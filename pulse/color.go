@@ -1,7 +1,10 @@
 package pulse
 
 import (
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/oliverbestmann/pulse/glm"
 )
@@ -43,6 +46,55 @@ func ColorSRGBA(r, g, b, a float32) Color {
 	return ColorLinearRGBA(r, g, b, a)
 }
 
+// ParseColorHex parses a css like hex color in the form #rgb, #rgba, #rrggbb or #rrggbbaa.
+// The leading # is optional. The color values are interpreted as srgb.
+func ParseColorHex(hex string) (Color, error) {
+	digits := strings.TrimPrefix(hex, "#")
+
+	// expand short form to the long form
+	if len(digits) == 3 || len(digits) == 4 {
+		var expanded strings.Builder
+		for _, ch := range digits {
+			expanded.WriteRune(ch)
+			expanded.WriteRune(ch)
+		}
+
+		digits = expanded.String()
+	}
+
+	// default to opaque
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+
+	if len(digits) != 8 {
+		return Color{}, fmt.Errorf("invalid hex color %q", hex)
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid hex color %q: %w", hex, err)
+	}
+
+	r := float32((value>>24)&0xff) / 255
+	g := float32((value>>16)&0xff) / 255
+	b := float32((value>>8)&0xff) / 255
+	a := float32(value&0xff) / 255
+
+	return ColorSRGBA(r, g, b, a), nil
+}
+
+// ColorHex is like ParseColorHex, but panics if the value can not be parsed.
+// This is useful to define a palette of constant colors.
+func ColorHex(hex string) Color {
+	color, err := ParseColorHex(hex)
+	if err != nil {
+		panic(err)
+	}
+
+	return color
+}
+
 // ToVec returns a glm.Vec4f containing the components of this Color instance in
 // linear rgb space.
 func (c Color) ToVec() glm.Vec4f {
@@ -86,6 +138,46 @@ func (c Color) Blue() float32 {
 	return c.b1 + 1
 }
 
+// ToSRGBA returns the color components encoded in non linear srgb.
+// Alpha is returned as is.
+func (c Color) ToSRGBA() (r, g, b, a float32) {
+	r, g, b, a = c.Components()
+	return gamma(r), gamma(g), gamma(b), a
+}
+
+// Hex formats the color as #rrggbbaa in srgb, see ParseColorHex.
+func (c Color) Hex() string {
+	r, g, b, a := c.ToSRGBA()
+	return fmt.Sprintf("#%02x%02x%02x%02x", toUnorm8(r), toUnorm8(g), toUnorm8(b), toUnorm8(a))
+}
+
+// Premultiplied returns the color with its rgb components multiplied by alpha.
+func (c Color) Premultiplied() Color {
+	r, g, b, a := c.Components()
+	return ColorLinearRGBA(r*a, g*a, b*a, a)
+}
+
+// Unpremultiplied reverts Premultiplied. A fully transparent color
+// results in ColorTransparent.
+func (c Color) Unpremultiplied() Color {
+	r, g, b, a := c.Components()
+	if a == 0 {
+		return ColorTransparent
+	}
+
+	return ColorLinearRGBA(r/a, g/a, b/a, a)
+}
+
+// Lerp interpolates between c and other in linear rgb. This matches
+// the result of blending both colors on the gpu.
+// Use LerpOKLab for a perceptually even transition.
+func (c Color) Lerp(other Color, t float32) Color {
+	a := c.ToVec()
+	b := other.ToVec()
+
+	return ColorOf(a.Add(b.Sub(a).Scale(t)))
+}
+
 // WithAlpha returns a new color with the alpha component set to the given value.
 func (c Color) WithAlpha(alpha float32) Color {
 	c.a1 = alpha - 1
@@ -104,3 +196,16 @@ func degamma(value float32) float32 {
 
 	return float32(sign * math.Pow((abs+0.055)/1.055, 2.4))
 }
+
+func gamma(value float32) float32 {
+	x := float64(value)
+
+	// https://www.w3.org/TR/css-color-4/#color-conversion-code
+	sign := math.Copysign(1, x)
+	abs := math.Abs(x)
+	if abs <= 0.0031308 {
+		return float32(x * 12.92)
+	}
+
+	return float32(sign * (1.055*math.Pow(abs, 1/2.4) - 0.055))
+}
@@ -0,0 +1,227 @@
+package pulse
+
+import (
+	"testing"
+)
+
+func approxEqual(a, b float32) bool {
+	return abs(a-b) < 1e-3
+}
+
+func approxEqualColor(a, b Color) bool {
+	ar, ag, ab, aa := a.Components()
+	br, bg, bb, ba := b.Components()
+
+	return approxEqual(ar, br) && approxEqual(ag, bg) && approxEqual(ab, bb) && approxEqual(aa, ba)
+}
+
+func TestParseColorHex(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: "#ffffff", expected: "#ffffffff"},
+		{input: "#12345678", expected: "#12345678"},
+		{input: "12345678", expected: "#12345678"},
+		{input: "#abc", expected: "#aabbccff"},
+		{input: "#abcd", expected: "#aabbccdd"},
+		{input: "#000", expected: "#000000ff"},
+		{input: "#0000", expected: "#00000000"},
+		{input: "#FF8000", expected: "#ff8000ff"},
+		{input: "", err: true},
+		{input: "#", err: true},
+		{input: "#12", err: true},
+		{input: "#12345", err: true},
+		{input: "#1234567", err: true},
+		{input: "#123456789", err: true},
+		{input: "#ggg", err: true},
+		{input: "#-12345", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			color, err := ParseColorHex(tc.input)
+
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", color.Hex())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hex := color.Hex(); hex != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, hex)
+			}
+		})
+	}
+}
+
+func TestColorHSVAndHSL(t *testing.T) {
+	cases := []struct {
+		hex     string
+		h, s, v float32
+		hslS    float32
+		l       float32
+	}{
+		{hex: "#ff0000ff", h: 0, s: 1, v: 1, hslS: 1, l: 0.5},
+		{hex: "#ffff00ff", h: 60, s: 1, v: 1, hslS: 1, l: 0.5},
+		{hex: "#00ff00ff", h: 120, s: 1, v: 1, hslS: 1, l: 0.5},
+		{hex: "#0000ffff", h: 240, s: 1, v: 1, hslS: 1, l: 0.5},
+		{hex: "#ff00ffff", h: 300, s: 1, v: 1, hslS: 1, l: 0.5},
+		{hex: "#800000ff", h: 0, s: 1, v: 128.0 / 255, hslS: 1, l: 64.0 / 255},
+		{hex: "#ff8080ff", h: 0, s: 127.0 / 255, v: 1, hslS: 1, l: 191.5 / 255},
+		{hex: "#ffffffff", h: 0, s: 0, v: 1, hslS: 0, l: 1},
+		{hex: "#000000ff", h: 0, s: 0, v: 0, hslS: 0, l: 0},
+		{hex: "#80808080", h: 0, s: 0, v: 128.0 / 255, hslS: 0, l: 128.0 / 255},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.hex, func(t *testing.T) {
+			color := ColorHex(tc.hex)
+
+			h, s, v, a := color.ToHSVA()
+			if !approxEqual(h, tc.h) || !approxEqual(s, tc.s) || !approxEqual(v, tc.v) || a != color.Alpha() {
+				t.Errorf("expected hsv %v %v %v, got %v %v %v", tc.h, tc.s, tc.v, h, s, v)
+			}
+
+			h, s, l, a := color.ToHSLA()
+			if !approxEqual(h, tc.h) || !approxEqual(s, tc.hslS) || !approxEqual(l, tc.l) || a != color.Alpha() {
+				t.Errorf("expected hsl %v %v %v, got %v %v %v", tc.h, tc.hslS, tc.l, h, s, l)
+			}
+
+			if hex := ColorHSVA(tc.h, tc.s, tc.v, color.Alpha()).Hex(); hex != tc.hex {
+				t.Errorf("expected ColorHSVA to return %s, got %s", tc.hex, hex)
+			}
+
+			if hex := ColorHSLA(tc.h, tc.hslS, tc.l, color.Alpha()).Hex(); hex != tc.hex {
+				t.Errorf("expected ColorHSLA to return %s, got %s", tc.hex, hex)
+			}
+		})
+	}
+}
+
+func TestColorHueWraps(t *testing.T) {
+	cases := []struct {
+		name string
+		hue  float32
+	}{
+		{name: "full turn", hue: 360},
+		{name: "negative", hue: -360},
+		{name: "multiple turns", hue: 720},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if hex := ColorHSVA(tc.hue, 1, 1, 1).Hex(); hex != "#ff0000ff" {
+				t.Errorf("expected red, got %s", hex)
+			}
+		})
+	}
+}
+
+func TestColorOKLab(t *testing.T) {
+	cases := []struct {
+		name    string
+		color   Color
+		l, a, b float32
+	}{
+		{name: "white", color: ColorWhite, l: 1},
+		{name: "black", color: ColorBlack},
+		{name: "red", color: ColorLinearRGBA(1, 0, 0, 1), l: 0.628, a: 0.225, b: 0.126},
+		{name: "blue", color: ColorLinearRGBA(0, 0, 1, 0.5), l: 0.452, a: -0.032, b: -0.312},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, a, b, alpha := tc.color.ToOKLab()
+
+			if !approxEqual(l, tc.l) || !approxEqual(a, tc.a) || !approxEqual(b, tc.b) || alpha != tc.color.Alpha() {
+				t.Errorf("expected oklab %v %v %v, got %v %v %v", tc.l, tc.a, tc.b, l, a, b)
+			}
+
+			if roundTrip := ColorOKLab(l, a, b, alpha); !approxEqualColor(roundTrip, tc.color) {
+				t.Errorf("expected %v after round trip, got %v", tc.color.ToVec(), roundTrip.ToVec())
+			}
+		})
+	}
+}
+
+func TestColorPremultiplied(t *testing.T) {
+	cases := []struct {
+		name          string
+		color         Color
+		premultiplied Color
+	}{
+		{name: "opaque", color: ColorLinearRGBA(0.2, 0.4, 0.6, 1), premultiplied: ColorLinearRGBA(0.2, 0.4, 0.6, 1)},
+		{name: "half transparent", color: ColorLinearRGBA(0.2, 0.4, 0.6, 0.5), premultiplied: ColorLinearRGBA(0.1, 0.2, 0.3, 0.5)},
+		{name: "transparent", color: ColorTransparent, premultiplied: ColorTransparent},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			premultiplied := tc.color.Premultiplied()
+			if !approxEqualColor(premultiplied, tc.premultiplied) {
+				t.Errorf("expected %v, got %v", tc.premultiplied.ToVec(), premultiplied.ToVec())
+			}
+
+			if restored := premultiplied.Unpremultiplied(); !approxEqualColor(restored, tc.color) {
+				t.Errorf("expected %v after unpremultiplying, got %v", tc.color.ToVec(), restored.ToVec())
+			}
+		})
+	}
+}
+
+func TestGradient(t *testing.T) {
+	red := ColorLinearRGBA(1, 0, 0, 1)
+	blue := ColorLinearRGBA(0, 0, 1, 1)
+	white := ColorWhite
+
+	unsorted := NewGradient(
+		GradientStop{Offset: 1, Color: blue},
+		GradientStop{Offset: 0, Color: red},
+		GradientStop{Offset: 0.5, Color: white},
+	)
+
+	cases := []struct {
+		name     string
+		gradient Gradient
+		offset   float32
+		expected Color
+	}{
+		{name: "empty", gradient: NewGradient(), offset: 0.5, expected: ColorTransparent},
+		{name: "single color", gradient: NewGradientOf(red), offset: 0.5, expected: red},
+		{name: "before the first stop", gradient: NewGradientOf(red, blue), offset: -1, expected: red},
+		{name: "after the last stop", gradient: NewGradientOf(red, blue), offset: 2, expected: blue},
+		{name: "at the first stop", gradient: NewGradientOf(red, blue), offset: 0, expected: red},
+		{name: "at the last stop", gradient: NewGradientOf(red, blue), offset: 1, expected: blue},
+		{name: "between two stops", gradient: NewGradientOf(red, blue), offset: 0.25, expected: red.LerpOKLab(blue, 0.25)},
+		{name: "unsorted stops", gradient: unsorted, offset: 0.5, expected: white},
+		{name: "between unsorted stops", gradient: unsorted, offset: 0.75, expected: white.LerpOKLab(blue, 0.5)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if color := tc.gradient.At(tc.offset); !approxEqualColor(color, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected.ToVec(), color.ToVec())
+			}
+		})
+	}
+
+	t.Run("sample", func(t *testing.T) {
+		colors := NewGradientOf(red, white, blue).Sample(5)
+
+		expected := []Color{red, red.LerpOKLab(white, 0.5), white, white.LerpOKLab(blue, 0.5), blue}
+
+		for idx := range expected {
+			if !approxEqualColor(colors[idx], expected[idx]) {
+				t.Errorf("sample %d: expected %v, got %v", idx, expected[idx].ToVec(), colors[idx].ToVec())
+			}
+		}
+	})
+}
@@ -32,13 +32,17 @@ func NewClear(ctx *pulse.Context, spriteCommand *SpriteCommand) *ClearCommand {
 	}
 }
 
+// Clear fills the target with the given color. If the target is a root texture
+// with a depth texture attached, the depth texture is cleared too. If the target
+// is clipped, only the pixels within the clip are filled and the depth texture
+// is kept, see pulse.Clip.
 func (c *ClearCommand) Clear(target *pulse.Texture, color pulse.Color) {
 	enc := c.context.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{Label: "ClearTexture"})
 	defer enc.Release()
 
 	view, resolveView := target.RenderViews()
 
-	if target == target.Root() {
+	if target == target.Root() && target.Clip() == (pulse.Clip{}) {
 		carr := color.ToWGPU()
 
 		desc := &wgpu.RenderPassDescriptor{
@@ -59,6 +63,11 @@ func (c *ClearCommand) Clear(target *pulse.Texture, color pulse.Color) {
 			},
 		}
 
+		if target.HasDepthTexture() {
+			// also reset the depth buffer used for 3d rendering
+			desc.DepthStencilAttachment = target.DepthTexture(c.context).DepthAttachment(wgpu.LoadOpClear)
+		}
+
 		enc.BeginRenderPass(desc).End()
 
 		// encode into a command buffer
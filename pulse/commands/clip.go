@@ -0,0 +1,218 @@
+package commands
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"unsafe"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+//go:embed clip.wgsl
+var clipShaderCode string
+
+// ClipCommand writes clip shapes into the stencil aspect of the depth texture
+// of a root texture. Together with pulse.Clip this restricts drawing of the other
+// commands to the shapes. Nested shapes are written by incrementing the stencil
+// value inside of the current clip, a shape is removed again by decrementing it.
+type ClipCommand struct {
+	ctx           *pulse.Context
+	pipelineCache *pulse.PipelineCache[clipPipelineConfig]
+}
+
+func NewClipCommand(ctx *pulse.Context) *ClipCommand {
+	return &ClipCommand{
+		ctx:           ctx,
+		pipelineCache: pulse.NewPipelineCache[clipPipelineConfig](ctx),
+	}
+}
+
+// Increment increments the stencil value of all pixels covered by the triangles,
+// where the stencil value is equal to the reference. The triangles are given in
+// pixels of the root of the target.
+func (c *ClipCommand) Increment(target *pulse.Texture, triangles []glm.Vec2f, reference uint32) {
+	c.update(target, triangles, reference, wgpu.StencilOperationIncrementClamp)
+}
+
+// Decrement reverts a previous call to Increment with the same triangles,
+// the reference must be the incremented value.
+func (c *ClipCommand) Decrement(target *pulse.Texture, triangles []glm.Vec2f, reference uint32) {
+	c.update(target, triangles, reference, wgpu.StencilOperationDecrementClamp)
+}
+
+func (c *ClipCommand) update(target *pulse.Texture, triangles []glm.Vec2f, reference uint32, op wgpu.StencilOperation) {
+	if len(triangles) < 3 {
+		return
+	}
+
+	root := target.Root()
+
+	pc, err := c.pipelineCache.TryGet(clipPipelineConfig{
+		SampleCount: root.SampleCount(),
+		Operation:   op,
+	})
+
+	if err != nil {
+		c.ctx.ReportError(err)
+		return
+	}
+
+	// transform the triangles into clip space
+	toClipSpace := glm.Mat3f{}.
+		Translate(-1, 1).
+		Scale(2.0/float32(root.Width()), -2.0/float32(root.Height()))
+
+	vertices := make([]glm.Vec2f, len(triangles))
+	for idx, point := range triangles {
+		vertices[idx] = toClipSpace.Transform2(point)
+	}
+
+	bufVertices := c.ctx.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    "Clip.Vertices",
+		Usage:    wgpu.BufferUsageVertex,
+		Contents: wgpu.ToBytes(vertices),
+	})
+
+	defer bufVertices.Release()
+
+	encoder := c.ctx.CreateCommandEncoder(nil)
+	defer encoder.Release()
+
+	pass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+		Label:                  "RenderPassClip",
+		DepthStencilAttachment: root.DepthTexture(c.ctx).DepthAttachment(wgpu.LoadOpLoad),
+	})
+
+	applyClip(pass, target, nil)
+
+	pass.SetPipeline(pc.Pipeline)
+	pass.SetStencilReference(reference)
+	pass.SetVertexBuffer(0, bufVertices, 0, wgpu.WholeSize)
+	pass.Draw(uint32(len(vertices)/3*3), 1, 0, 0)
+	pass.End()
+
+	cmdBuffer := encoder.Finish(nil)
+	defer cmdBuffer.Release()
+
+	c.ctx.Submit(cmdBuffer)
+}
+
+func (c *ClipCommand) Flush() {
+	// stencil updates are not batched
+}
+
+// clipDepthStencilState returns the depth stencil state for pipelines that draw
+// into a target with a stencil clip. Pixels are drawn if their stencil value is
+// equal to the stencil reference, the depth texture is not modified.
+func clipDepthStencilState() *wgpu.DepthStencilState {
+	face := wgpu.StencilFaceState{
+		Compare:     wgpu.CompareFunctionEqual,
+		FailOp:      wgpu.StencilOperationKeep,
+		DepthFailOp: wgpu.StencilOperationKeep,
+		PassOp:      wgpu.StencilOperationKeep,
+	}
+
+	return &wgpu.DepthStencilState{
+		Format:           pulse.DepthTextureFormat,
+		DepthCompare:     wgpu.CompareFunctionAlways,
+		StencilFront:     face,
+		StencilBack:      face,
+		StencilReadMask:  0xff,
+		StencilWriteMask: 0,
+	}
+}
+
+// clipAttachment returns the depth stencil attachment required by the clip
+// of the target, or nil if the clip does not use the stencil test.
+func clipAttachment(ctx *pulse.Context, target *pulse.Texture) *wgpu.RenderPassDepthStencilAttachment {
+	if !target.Clip().Stencil() {
+		return nil
+	}
+
+	return target.DepthTexture(ctx).DepthAttachment(wgpu.LoadOpLoad)
+}
+
+// applyClip limits drawing of the pass to the target and the clip of its root.
+// If region is not nil, drawing is further limited to this region of the root texture.
+func applyClip(pass *wgpu.RenderPassEncoder, target *pulse.Texture, region *pulse.Rectangle2u) {
+	rect := target.ScissorRect()
+	if region != nil {
+		rect = rect.Intersection(*region)
+	}
+
+	pass.SetScissorRect(rect.XYWH())
+
+	if clip := target.Clip(); clip.Stencil() {
+		pass.SetStencilReference(clip.StencilReference)
+	}
+}
+
+type clipPipelineConfig struct {
+	SampleCount uint32
+	Operation   wgpu.StencilOperation
+}
+
+func (conf clipPipelineConfig) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
+	slog.Info(
+		"Create RenderPipeline for clip",
+		slog.Any("operation", conf.Operation),
+		slog.Any("sampleCount", conf.SampleCount),
+	)
+
+	shader, err := pulse.CreateShaderModule(dev, "Clip.ShaderSource", clipShaderCode)
+	if err != nil {
+		return nil, err
+	}
+
+	defer shader.Release()
+
+	face := wgpu.StencilFaceState{
+		Compare:     wgpu.CompareFunctionEqual,
+		FailOp:      wgpu.StencilOperationKeep,
+		DepthFailOp: wgpu.StencilOperationKeep,
+		PassOp:      conf.Operation,
+	}
+
+	desc := &wgpu.RenderPipelineDescriptor{
+		Label: fmt.Sprintf("Clip.%s", conf.Operation),
+		Vertex: wgpu.VertexState{
+			Module:     shader,
+			EntryPoint: "vs_main",
+			Buffers: []wgpu.VertexBufferLayout{
+				{
+					StepMode:    wgpu.VertexStepModeVertex,
+					ArrayStride: uint64(unsafe.Sizeof(glm.Vec2f{})),
+					Attributes: []wgpu.VertexAttribute{
+						{
+							Format:         wgpu.VertexFormatFloat32x2,
+							Offset:         0,
+							ShaderLocation: 0,
+						},
+					},
+				},
+			},
+		},
+		Primitive: wgpu.PrimitiveState{
+			Topology: wgpu.PrimitiveTopologyTriangleList,
+			CullMode: wgpu.CullModeNone,
+		},
+		DepthStencil: &wgpu.DepthStencilState{
+			Format:           pulse.DepthTextureFormat,
+			DepthCompare:     wgpu.CompareFunctionAlways,
+			StencilFront:     face,
+			StencilBack:      face,
+			StencilReadMask:  0xff,
+			StencilWriteMask: 0xff,
+		},
+		Multisample: wgpu.MultisampleState{
+			Count:                  conf.SampleCount,
+			Mask:                   0xFFFFFFFF,
+			AlphaToCoverageEnabled: false,
+		},
+	}
+
+	return pulse.CreateRenderPipeline(dev, desc)
+}
@@ -0,0 +1,6 @@
+//go:build js
+
+package commands
+
+// value of wgpu.DepthStencilState.DepthWriteEnabled to enable depth writes
+const depthWriteEnabled = true
@@ -0,0 +1,8 @@
+//go:build !js
+
+package commands
+
+import "github.com/oliverbestmann/webgpu/wgpu"
+
+// value of wgpu.DepthStencilState.DepthWriteEnabled to enable depth writes
+const depthWriteEnabled = wgpu.OptionalBoolTrue
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// MaxMaterialTextures is the maximum number of textures a material can bind.
+const MaxMaterialTextures = 8
+
+// Material provides additional resources in bind group 1 to a custom shader.
+// The shader must declare all bindings the material provides:
+//
+//   - binding 0: the uniform buffer, if Uniforms is set
+//   - binding 1: a sampler, if Textures is not empty
+//   - binding 2 and following: the textures
+//
+// Draw calls using different materials are not batched.
+type Material struct {
+	Uniforms *wgpu.Buffer
+
+	// Sampler configuration to sample the textures with
+	Sampler wgpu.SamplerDescriptor
+
+	Textures []*pulse.Texture
+}
+
+// createBindGroup creates bind group 1 for the given pipeline.
+// Returns nil, if the material does not bind any resources.
+func (m *Material) createBindGroup(ctx *pulse.Context, pipeline pulse.CachedPipeline) *wgpu.BindGroup {
+	if len(m.Textures) > MaxMaterialTextures {
+		panic(fmt.Sprintf("material has %d textures, at most %d are supported", len(m.Textures), MaxMaterialTextures))
+	}
+
+	var entries []wgpu.BindGroupEntry
+
+	if m.Uniforms != nil {
+		entries = append(entries, wgpu.BindGroupEntry{
+			Binding: 0,
+			Buffer:  m.Uniforms,
+			Size:    wgpu.WholeSize,
+		})
+	}
+
+	if len(m.Textures) > 0 {
+		entries = append(entries, wgpu.BindGroupEntry{
+			Binding: 1,
+			Sampler: pulse.CachedSampler(ctx.Device, m.Sampler),
+		})
+
+		for idx, texture := range m.Textures {
+			entries = append(entries, wgpu.BindGroupEntry{
+				Binding:     uint32(2 + idx),
+				TextureView: texture.SourceView(),
+			})
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Label:   "MaterialBindGroup",
+		Layout:  pipeline.GetBindGroupLayout(1),
+		Entries: entries,
+	})
+}
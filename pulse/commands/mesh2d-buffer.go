@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// Mesh2dBuffer holds the vertices and indices of a mesh on the gpu, so they do
+// not need to be uploaded every frame. Draw it using Mesh2dCommand.DrawMesh.
+type Mesh2dBuffer struct {
+	vertices *wgpu.Buffer
+	indices  *wgpu.Buffer
+
+	vertexCount uint32
+	indexCount  uint32
+}
+
+// NewMesh2dBuffer uploads the vertices and indices to the gpu. The number of vertices
+// and indices is fixed, use WriteVertices and WriteIndices to update parts of the mesh.
+// The TransformIndex of the vertices is ignored. Invalid indices are reported to the
+// context as in WriteIndices.
+func NewMesh2dBuffer(ctx *pulse.Context, vertices []MeshVertex, indices []uint32) *Mesh2dBuffer {
+	// webgpu does not like empty buffers
+	vertexBufferSize := max(1, len(vertices)) * int(unsafe.Sizeof(MeshVertex{}))
+	indexBufferSize := max(1, len(indices)) * int(unsafe.Sizeof(uint32(0)))
+
+	m := &Mesh2dBuffer{
+		vertices: ctx.CreateBuffer(&wgpu.BufferDescriptor{
+			Label: "Mesh2dBuffer.Vertices",
+			Usage: wgpu.BufferUsageVertex | wgpu.BufferUsageCopyDst,
+			Size:  uint64(vertexBufferSize),
+		}),
+
+		indices: ctx.CreateBuffer(&wgpu.BufferDescriptor{
+			Label: "Mesh2dBuffer.Indices",
+			Usage: wgpu.BufferUsageIndex | wgpu.BufferUsageCopyDst,
+			Size:  uint64(indexBufferSize),
+		}),
+
+		vertexCount: uint32(len(vertices)),
+		indexCount:  uint32(len(indices)),
+	}
+
+	m.WriteVertices(ctx, 0, vertices)
+	m.WriteIndices(ctx, 0, indices)
+
+	return m
+}
+
+// VertexCount returns the number of vertices of the mesh
+func (m *Mesh2dBuffer) VertexCount() uint32 {
+	return m.vertexCount
+}
+
+// IndexCount returns the number of indices of the mesh
+func (m *Mesh2dBuffer) IndexCount() uint32 {
+	return m.indexCount
+}
+
+// WriteVertices replaces the vertices starting at the given offset. If the vertices
+// exceed the mesh, nothing is written and the error is reported to the context.
+func (m *Mesh2dBuffer) WriteVertices(ctx *pulse.Context, offset uint32, vertices []MeshVertex) {
+	if len(vertices) == 0 {
+		return
+	}
+
+	if uint64(offset)+uint64(len(vertices)) > uint64(m.vertexCount) {
+		ctx.ReportError(fmt.Errorf("vertices out of range: offset %d, count %d, mesh has %d vertices", offset, len(vertices), m.vertexCount))
+		return
+	}
+
+	// all vertices refer to the only transform of the draw call
+	local := make([]MeshVertex, len(vertices))
+	for idx, vertex := range vertices {
+		vertex.TransformIndex = 0
+		local[idx] = vertex
+	}
+
+	byteOffset := uint64(offset) * uint64(unsafe.Sizeof(MeshVertex{}))
+	ctx.WriteBuffer(m.vertices, byteOffset, wgpu.ToBytes(local))
+}
+
+// WriteIndices replaces the indices starting at the given offset. Indices must
+// be smaller than the number of vertices. If the indices exceed the mesh or an index
+// is out of range, nothing is written and the error is reported to the context.
+func (m *Mesh2dBuffer) WriteIndices(ctx *pulse.Context, offset uint32, indices []uint32) {
+	if len(indices) == 0 {
+		return
+	}
+
+	if uint64(offset)+uint64(len(indices)) > uint64(m.indexCount) {
+		ctx.ReportError(fmt.Errorf("indices out of range: offset %d, count %d, mesh has %d indices", offset, len(indices), m.indexCount))
+		return
+	}
+
+	for _, vertexIdx := range indices {
+		if vertexIdx >= m.vertexCount {
+			ctx.ReportError(fmt.Errorf("vertex index %d out of range, have %d vertices", vertexIdx, m.vertexCount))
+			return
+		}
+	}
+
+	byteOffset := uint64(offset) * uint64(unsafe.Sizeof(uint32(0)))
+	ctx.WriteBuffer(m.indices, byteOffset, wgpu.ToBytes(indices))
+}
+
+// Release releases the gpu buffers. The mesh must not be used afterward.
+func (m *Mesh2dBuffer) Release() {
+	m.vertices.Release()
+	m.indices.Release()
+}
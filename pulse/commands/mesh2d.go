@@ -18,17 +18,35 @@ var mesh2dShaderCode string
 // maximum number of vertices to render in one batch
 const maxMeshVertices = 128 * 1024 * 3
 
+// maximum number of indices to render in one batch
+const maxMeshIndices = 128 * 1024 * 3
+
+// maximum number of model transforms in one batch
+const maxMeshTransforms = 1024 * 16
+
 type mesh2dBatchConfig struct {
 	target     *pulse.Texture
 	blendState wgpu.BlendState
 	shader     string
+	material   *Material
+
+	// root of the texture to sample from, might be nil
+	texture      *pulse.Texture
+	filterMode   wgpu.FilterMode
+	mipmapFilter wgpu.MipmapFilterMode
+	addressModeU wgpu.AddressMode
+	addressModeV wgpu.AddressMode
 }
 
 type MeshVertex struct {
 	_ structs.HostLayout
 
-	Position       glm.Vec2f
-	Color          glm.Vec4f
+	Position glm.Vec2f
+	Color    glm.Vec4f
+
+	// UV coordinates to sample the texture at, in range 0 to 1
+	UV glm.Vec2f
+
 	TransformIndex uint32
 }
 
@@ -40,9 +58,16 @@ type Mesh2dCommand struct {
 	vertices        []MeshVertex
 	modelTransforms [][12]float32
 
+	indices []uint32
+
 	bufVertices        *wgpu.Buffer
+	bufIndices         *wgpu.Buffer
 	bufModelTransforms *wgpu.Buffer
 	bufViewTransform   *wgpu.Buffer
+	bufDrawCall        *wgpu.Buffer
+
+	// bound if a batch without texture is drawn with the default shader
+	whiteTexture *pulse.Texture
 
 	batchConfig mesh2dBatchConfig
 }
@@ -55,11 +80,18 @@ func NewMesh2dCommand(ctx *pulse.Context) *Mesh2dCommand {
 		Size:  uint64(unsafe.Sizeof(MeshVertex{})) * maxMeshVertices,
 	})
 
+	// create an index buffer
+	bufIndices := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh2d.Indices",
+		Usage: wgpu.BufferUsageIndex | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof(uint32(0))) * maxMeshIndices,
+	})
+
 	// create a transform buffer
 	bufModelTransforms := ctx.CreateBuffer(&wgpu.BufferDescriptor{
 		Label: "Mesh2d.ModelTransformations",
 		Usage: wgpu.BufferUsageStorage | wgpu.BufferUsageCopyDst,
-		Size:  uint64(unsafe.Sizeof([12]float32{})) * 1024 * 16,
+		Size:  uint64(unsafe.Sizeof([12]float32{})) * maxMeshTransforms,
 	})
 
 	// buffer to hold view transform
@@ -69,11 +101,30 @@ func NewMesh2dCommand(ctx *pulse.Context) *Mesh2dCommand {
 		Size:  uint64(unsafe.Sizeof([12]float32{})),
 	})
 
+	// color and uv region applied to all vertices of a draw call
+	bufDrawCall := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh2d.DrawCall",
+		Usage: wgpu.BufferUsageVertex | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof(mesh2dDrawCall{})),
+	})
+
+	whiteTexture := pulse.NewTexture(ctx, pulse.NewTextureOptions{
+		Label:  "Mesh2d.White",
+		Width:  1,
+		Height: 1,
+		Format: wgpu.TextureFormatRGBA8Unorm,
+	})
+
+	whiteTexture.WritePixels(ctx, []byte{0xff, 0xff, 0xff, 0xff})
+
 	p := &Mesh2dCommand{
 		ctx:                ctx,
 		bufVertices:        bufVertices,
+		bufIndices:         bufIndices,
 		bufModelTransforms: bufModelTransforms,
 		bufViewTransform:   bufViewTransform,
+		bufDrawCall:        bufDrawCall,
+		whiteTexture:       whiteTexture,
 	}
 
 	p.pipelineCache = pulse.NewPipelineCache[mesh2dRenderPipeline](ctx)
@@ -86,11 +137,134 @@ type DrawMesh2dOptions struct {
 	BlendState wgpu.BlendState
 	Color      glm.Vec4f
 	Vertices   []MeshVertex
-	// shader code, use default if empty
+
+	// Texture to sample from using the uv coordinates of the vertices. The
+	// sampled color is multiplied with the vertex color. Might be nil.
+	Texture *pulse.Texture
+
+	// Sampler configuration, defaults to linear filtering and clamp to edge
+	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// shader code, use default if empty. A custom shader needs to declare
+	// the texture at binding 2 and the sampler at binding 3 only if a Texture is set.
 	Shader string
+
+	// Material provides additional bindings to a custom shader, might be nil
+	Material *Material
 }
 
+// DrawTriangles draws a list of triangles, each three vertices form a triangle.
 func (p *Mesh2dCommand) DrawTriangles(target *pulse.Texture, opts DrawMesh2dOptions) {
+	batchConfig := p.batchConfigOf(target, &opts)
+
+	modelTransformIndex := p.pushTransform(opts.Transform)
+
+	for idx := 0; idx+3 <= len(opts.Vertices); idx += 3 {
+		requireFlush := p.batchConfig != batchConfig ||
+			len(p.vertices)+3 > maxMeshVertices ||
+			len(p.indices)+3 > maxMeshIndices
+
+		if requireFlush {
+			p.Flush()
+			p.batchConfig = batchConfig
+
+			// new batch, need to push our transform again
+			modelTransformIndex = p.pushTransform(opts.Transform)
+		}
+
+		base := uint32(len(p.vertices))
+		p.indices = append(p.indices, base, base+1, base+2)
+
+		p.appendVertices(opts.Vertices[idx:idx+3], &opts, modelTransformIndex)
+	}
+}
+
+// DrawIndexedTriangles draws triangles made of the vertices referenced by indices,
+// each three indices form a triangle. If an index is out of range, nothing is drawn
+// and the error is reported to the context.
+func (p *Mesh2dCommand) DrawIndexedTriangles(target *pulse.Texture, indices []uint32, opts DrawMesh2dOptions) {
+	// only draw complete triangles
+	indices = indices[:len(indices)/3*3]
+
+	// validate before touching the current batch
+	for _, vertexIdx := range indices {
+		if int(vertexIdx) >= len(opts.Vertices) {
+			p.ctx.ReportError(fmt.Errorf("vertex index %d out of range, have %d vertices", vertexIdx, len(opts.Vertices)))
+			return
+		}
+	}
+
+	if len(opts.Vertices) > maxMeshVertices || len(indices) > maxMeshIndices {
+		// too large for a single batch, split into individual triangles
+		vertices := make([]MeshVertex, len(indices))
+		for idx, vertexIdx := range indices {
+			vertices[idx] = opts.Vertices[vertexIdx]
+		}
+
+		opts.Vertices = vertices
+		p.DrawTriangles(target, opts)
+
+		return
+	}
+
+	batchConfig := p.batchConfigOf(target, &opts)
+
+	requireFlush := p.batchConfig != batchConfig ||
+		len(p.vertices)+len(opts.Vertices) > maxMeshVertices ||
+		len(p.indices)+len(indices) > maxMeshIndices ||
+		len(p.modelTransforms) >= maxMeshTransforms
+
+	if requireFlush {
+		p.Flush()
+		p.batchConfig = batchConfig
+	}
+
+	modelTransformIndex := p.pushTransform(opts.Transform)
+
+	base := uint32(len(p.vertices))
+
+	for _, vertexIdx := range indices {
+		p.indices = append(p.indices, base+vertexIdx)
+	}
+
+	p.appendVertices(opts.Vertices, &opts, modelTransformIndex)
+}
+
+// DrawMesh draws a mesh that was uploaded to the gpu before. The vertices of the options
+// are ignored, the color of the options and the region of a sub texture are applied on
+// the gpu. A custom shader receives them at vertex locations 4 and 5.
+func (p *Mesh2dCommand) DrawMesh(target *pulse.Texture, mesh *Mesh2dBuffer, opts DrawMesh2dOptions) {
+	// draw everything recorded before the mesh
+	p.Flush()
+
+	if mesh.indexCount == 0 {
+		return
+	}
+
+	batchConfig := p.batchConfigOf(target, &opts)
+
+	// all vertices of the mesh use the first transform
+	p.modelTransforms = append(p.modelTransforms, opts.Transform.ToWGPU())
+	defer p.reset()
+
+	call := mesh2dDrawCall{
+		Color:    opts.Color,
+		UVRegion: glm.Vec4f{0, 0, 1, 1},
+	}
+
+	if opts.Texture != nil {
+		// map the uv coordinates into the region of a sub texture
+		uv := opts.Texture.UV()
+		call.UVRegion = glm.Vec4f{uv.Min[0], uv.Min[1], uv.Width(), uv.Height()}
+	}
+
+	p.render(batchConfig, mesh.vertices, mesh.indices, mesh.indexCount, call)
+}
+
+func (p *Mesh2dCommand) batchConfigOf(target *pulse.Texture, opts *DrawMesh2dOptions) mesh2dBatchConfig {
 	if opts.Shader == "" {
 		opts.Shader = mesh2dShaderCode
 	}
@@ -99,37 +273,73 @@ func (p *Mesh2dCommand) DrawTriangles(target *pulse.Texture, opts DrawMesh2dOpti
 		target:     target,
 		blendState: opts.BlendState,
 		shader:     opts.Shader,
+		material:   opts.Material,
+	}
+
+	// sampler settings do not matter without a texture
+	if opts.Texture != nil {
+		batchConfig.texture = opts.Texture.Root()
+		batchConfig.filterMode = opts.FilterMode
+		batchConfig.mipmapFilter = opts.MipmapFilter
+		batchConfig.addressModeU = opts.AddressModeU
+		batchConfig.addressModeV = opts.AddressModeV
 	}
 
-	// model view matrix
-	modelViewTransform := opts.Transform.ToWGPU()
+	if batchConfig.filterMode == wgpu.FilterModeUndefined {
+		batchConfig.filterMode = wgpu.FilterModeLinear
+	}
 
-	if len(p.modelTransforms) == 0 || p.modelTransforms[len(p.modelTransforms)-1] != modelViewTransform {
-		p.modelTransforms = append(p.modelTransforms, modelViewTransform)
+	if batchConfig.mipmapFilter == wgpu.MipmapFilterModeUndefined {
+		batchConfig.mipmapFilter = wgpu.MipmapFilterModeLinear
 	}
 
-	modelTransformIndex := uint32(len(p.modelTransforms) - 1)
+	if batchConfig.addressModeU == wgpu.AddressModeUndefined {
+		batchConfig.addressModeU = wgpu.AddressModeClampToEdge
+	}
 
-	for idx := 0; idx < len(opts.Vertices); idx += 3 {
-		requireFlush := p.batchConfig != batchConfig ||
-			len(p.vertices)+3 > maxMeshVertices
+	if batchConfig.addressModeV == wgpu.AddressModeUndefined {
+		batchConfig.addressModeV = wgpu.AddressModeClampToEdge
+	}
 
-		if requireFlush {
+	return batchConfig
+}
+
+// pushTransform adds the model view transform to the current batch,
+// if it differs from the previous one, and returns its index.
+func (p *Mesh2dCommand) pushTransform(transform glm.Mat3f) uint32 {
+	modelViewTransform := transform.ToWGPU()
+
+	if len(p.modelTransforms) == 0 || p.modelTransforms[len(p.modelTransforms)-1] != modelViewTransform {
+		if len(p.modelTransforms) >= maxMeshTransforms {
+			// keep the current batch config, we only run out of transforms
+			batchConfig := p.batchConfig
 			p.Flush()
 			p.batchConfig = batchConfig
-
-			// new batch, need to push our transform again
-			p.modelTransforms = append(p.modelTransforms, modelViewTransform)
-			modelTransformIndex = 0
 		}
 
-		for _, v := range opts.Vertices[idx : idx+3] {
-			p.vertices = append(p.vertices, MeshVertex{
-				Position:       v.Position,
-				Color:          v.Color.Mul(opts.Color),
-				TransformIndex: modelTransformIndex,
-			})
-		}
+		p.modelTransforms = append(p.modelTransforms, modelViewTransform)
+	}
+
+	return uint32(len(p.modelTransforms) - 1)
+}
+
+func (p *Mesh2dCommand) appendVertices(vertices []MeshVertex, opts *DrawMesh2dOptions, modelTransformIndex uint32) {
+	// map the uv coordinates into the region of a sub texture
+	uvOffset, uvScale := glm.Vec2f{0, 0}, glm.Vec2f{1, 1}
+
+	if opts.Texture != nil {
+		uv := opts.Texture.UV()
+		uvOffset = uv.Min
+		uvScale = uv.Size()
+	}
+
+	for _, v := range vertices {
+		p.vertices = append(p.vertices, MeshVertex{
+			Position:       v.Position,
+			Color:          v.Color.Mul(opts.Color),
+			UV:             uvOffset.Add(v.UV.Mul(uvScale)),
+			TransformIndex: modelTransformIndex,
+		})
 	}
 }
 
@@ -140,36 +350,92 @@ func (p *Mesh2dCommand) Flush() {
 		return
 	}
 
-	batchConfig := p.batchConfig
-
 	slog.Debug("Rendering triangles", slog.Int("vertexCount", len(p.vertices)))
 
 	p.ctx.WriteBuffer(p.bufVertices, 0, wgpu.ToBytes(p.vertices))
+	p.ctx.WriteBuffer(p.bufIndices, 0, wgpu.ToBytes(p.indices))
+
+	// vertex colors and uv coordinates are already adjusted to the draw call
+	p.render(p.batchConfig, p.bufVertices, p.bufIndices, uint32(len(p.indices)), mesh2dDrawCall{
+		Color:    glm.Vec4f{1, 1, 1, 1},
+		UVRegion: glm.Vec4f{0, 0, 1, 1},
+	})
+}
+
+// mesh2dDrawCall holds the values applied on the gpu to all vertices of a draw call
+type mesh2dDrawCall struct {
+	Color glm.Vec4f
+
+	// region of the texture the uv coordinates are mapped into (x, y, w, h)
+	UVRegion glm.Vec4f
+}
 
+// render draws the vertices with the model transforms of the current batch.
+func (p *Mesh2dCommand) render(batchConfig mesh2dBatchConfig, vertices, indices *wgpu.Buffer, indexCount uint32, call mesh2dDrawCall) {
 	pipelineConfig := mesh2dRenderPipeline{
 		TargetFormat:      batchConfig.target.Format(),
 		TargetSampleCount: batchConfig.target.SampleCount(),
 		BlendState:        batchConfig.blendState,
 		ShaderSource:      batchConfig.shader,
+		StencilClip:       batchConfig.target.Clip().Stencil(),
 	}
 
-	pc := p.pipelineCache.Get(pipelineConfig)
+	pc, err := p.pipelineCache.TryGet(pipelineConfig)
+	if err != nil {
+		// skip this batch and let the context handle the error
+		p.ctx.ReportError(err)
+		return
+	}
 
-	bindGroup := p.ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "Mesh2dBindGroup",
-		Layout: pc.GetBindGroupLayout(0),
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding: 0,
-				Buffer:  p.bufViewTransform,
-				Size:    wgpu.WholeSize,
+	entries := []wgpu.BindGroupEntry{
+		{
+			Binding: 0,
+			Buffer:  p.bufViewTransform,
+			Size:    wgpu.WholeSize,
+		},
+		{
+			Binding: 1,
+			Buffer:  p.bufModelTransforms,
+			Size:    wgpu.WholeSize,
+		},
+	}
+
+	texture := batchConfig.texture
+	if texture == nil && batchConfig.shader == mesh2dShaderCode {
+		// the default shader always samples a texture
+		texture = p.whiteTexture
+	}
+
+	if texture != nil {
+		sampler := pulse.CachedSampler(p.ctx.Device, wgpu.SamplerDescriptor{
+			Label:         "Mesh2d-Sampler",
+			AddressModeU:  batchConfig.addressModeU,
+			AddressModeV:  batchConfig.addressModeV,
+			AddressModeW:  wgpu.AddressModeUndefined,
+			MagFilter:     batchConfig.filterMode,
+			MinFilter:     batchConfig.filterMode,
+			MipmapFilter:  batchConfig.mipmapFilter,
+			LodMinClamp:   0,
+			LodMaxClamp:   32,
+			MaxAnisotropy: 1,
+		})
+
+		entries = append(entries,
+			wgpu.BindGroupEntry{
+				Binding:     2,
+				TextureView: texture.SourceView(),
 			},
-			{
-				Binding: 1,
-				Buffer:  p.bufModelTransforms,
-				Size:    wgpu.WholeSize,
+			wgpu.BindGroupEntry{
+				Binding: 3,
+				Sampler: sampler,
 			},
-		},
+		)
+	}
+
+	bindGroup := p.ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Label:   "Mesh2dBindGroup",
+		Layout:  pc.GetBindGroupLayout(0),
+		Entries: entries,
 	})
 
 	defer bindGroup.Release()
@@ -189,17 +455,25 @@ func (p *Mesh2dCommand) Flush() {
 				StoreOp:       wgpu.StoreOpStore,
 			},
 		},
+		DepthStencilAttachment: clipAttachment(p.ctx, batchConfig.target),
 	})
 
-	// set target region as clip rect
-	sx, sy := batchConfig.target.Offset().XY()
-	sw, sh := batchConfig.target.Size().XY()
+	// limit drawing to the target region and the clip
+	applyClip(pass, batchConfig.target, nil)
 
 	pass.SetPipeline(pc.Pipeline)
-	pass.SetScissorRect(sx, sy, sw, sh)
 	pass.SetBindGroup(0, bindGroup, nil)
-	pass.SetVertexBuffer(0, p.bufVertices, 0, wgpu.WholeSize)
-	pass.Draw(uint32(len(p.vertices)), 1, 0, 0)
+
+	if batchConfig.material != nil {
+		if materialBindGroup := batchConfig.material.createBindGroup(p.ctx, pc); materialBindGroup != nil {
+			defer materialBindGroup.Release()
+			pass.SetBindGroup(1, materialBindGroup, nil)
+		}
+	}
+	pass.SetVertexBuffer(0, vertices, 0, wgpu.WholeSize)
+	pass.SetVertexBuffer(1, p.bufDrawCall, 0, wgpu.WholeSize)
+	pass.SetIndexBuffer(indices, wgpu.IndexFormatUint32, 0, wgpu.WholeSize)
+	pass.DrawIndexed(indexCount, 1, 0, 0, 0)
 	pass.End()
 
 	cmdBuffer := encoder.Finish(nil)
@@ -213,6 +487,7 @@ func (p *Mesh2dCommand) Flush() {
 
 	p.ctx.WriteBuffer(p.bufModelTransforms, 0, wgpu.ToBytes(p.modelTransforms))
 	p.ctx.WriteBuffer(p.bufViewTransform, 0, wgpu.ToBytes(viewTransform[:]))
+	p.ctx.WriteBuffer(p.bufDrawCall, 0, wgpu.ToBytes([]mesh2dDrawCall{call}))
 	p.ctx.Submit(cmdBuffer)
 }
 
@@ -221,19 +496,22 @@ type mesh2dRenderPipeline struct {
 	BlendState        wgpu.BlendState
 	TargetSampleCount uint32
 	ShaderSource      string
+
+	// true if the target is clipped using the stencil test
+	StencilClip bool
 }
 
-func (conf mesh2dRenderPipeline) Specialize(dev *wgpu.Device) *wgpu.RenderPipeline {
+func (conf mesh2dRenderPipeline) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
 	slog.Info(
 		"Create RenderPipeline for mesh2d",
 		slog.Any("config", conf.TargetFormat),
 		slog.Any("sampleCount", conf.TargetSampleCount),
 	)
 
-	shader := dev.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label:      "Mesh2D.ShaderSource",
-		WGSLSource: &wgpu.ShaderSourceWGSL{Code: conf.ShaderSource},
-	})
+	shader, err := pulse.CreateShaderModule(dev, "Mesh2D.ShaderSource", conf.ShaderSource)
+	if err != nil {
+		return nil, err
+	}
 
 	defer shader.Release()
 
@@ -265,6 +543,29 @@ func (conf mesh2dRenderPipeline) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 							Offset:         uint64(unsafe.Offsetof(MeshVertex{}.TransformIndex)),
 							ShaderLocation: 2,
 						},
+						{
+							// uv
+							Format:         wgpu.VertexFormatFloat32x2,
+							Offset:         uint64(unsafe.Offsetof(MeshVertex{}.UV)),
+							ShaderLocation: 3,
+						},
+					},
+				},
+				{
+					// color and uv region of the draw call, used by static meshes
+					ArrayStride: uint64(unsafe.Sizeof(mesh2dDrawCall{})),
+					StepMode:    wgpu.VertexStepModeInstance,
+					Attributes: []wgpu.VertexAttribute{
+						{
+							Format:         wgpu.VertexFormatFloat32x4,
+							Offset:         uint64(unsafe.Offsetof(mesh2dDrawCall{}.Color)),
+							ShaderLocation: 4,
+						},
+						{
+							Format:         wgpu.VertexFormatFloat32x4,
+							Offset:         uint64(unsafe.Offsetof(mesh2dDrawCall{}.UVRegion)),
+							ShaderLocation: 5,
+						},
 					},
 				},
 			},
@@ -285,7 +586,6 @@ func (conf mesh2dRenderPipeline) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 			FrontFace: wgpu.FrontFaceCCW,
 			CullMode:  wgpu.CullModeNone,
 		},
-		DepthStencil: nil,
 		Multisample: wgpu.MultisampleState{
 			Count:                  conf.TargetSampleCount,
 			Mask:                   0xFFFFFFFF,
@@ -293,11 +593,16 @@ func (conf mesh2dRenderPipeline) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 		},
 	}
 
-	return dev.CreateRenderPipeline(desc)
+	if conf.StencilClip {
+		desc.DepthStencil = clipDepthStencilState()
+	}
+
+	return pulse.CreateRenderPipeline(dev, desc)
 }
 
 func (p *Mesh2dCommand) reset() {
 	p.vertices = p.vertices[:0]
+	p.indices = p.indices[:0]
 	p.modelTransforms = p.modelTransforms[:0]
 	p.batchConfig = mesh2dBatchConfig{}
 }
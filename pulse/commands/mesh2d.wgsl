@@ -1,5 +1,6 @@
 struct VertexOutput {
     @location(0) color: vec4f,
+    @location(1) uv: vec2f,
     @builtin(position) position: vec4f,
 };
 
@@ -7,11 +8,19 @@ struct VertexOutput {
 
 @group(0) @binding(1) var<storage, read> model_transforms: array<mat3x3<f32>>;
 
+// a white texture if the mesh is not textured
+@group(0) @binding(2) var texture: texture_2d<f32>;
+@group(0) @binding(3) var texture_sampler: sampler;
+
 @vertex
 fn vs_main(
     @location(0) position: vec2f,
     @location(1) color: vec4f,
     @location(2) transform_idx: u32,
+    @location(3) uv: vec2f,
+    @location(4) color_scale: vec4f,
+    // region of a sub texture to map the uv coordinates into (x, y, w, h)
+    @location(5) uv_region: vec4f,
 ) -> VertexOutput {
     let model_transform = model_transforms[transform_idx];
 
@@ -20,11 +29,12 @@ fn vs_main(
 
     var result: VertexOutput;
     result.position = vec4(pos.xy, 0.0, 1.0);
-    result.color = color;
+    result.color = color * color_scale;
+    result.uv = uv_region.xy + uv * uv_region.zw;
     return result;
 }
 
 @fragment
 fn fs_main(vertex: VertexOutput) -> @location(0) vec4f {
-    return vertex.color;
+    return textureSample(texture, texture_sampler, vertex.uv) * vertex.color;
 }
@@ -0,0 +1,589 @@
+package commands
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"structs"
+	"unsafe"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/pulse/pulse"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+//go:embed mesh3d.wgsl
+var mesh3dShaderCode string
+
+// maximum number of vertices to render in one batch
+const maxMesh3dVertices = 64 * 1024 * 3
+
+// maximum number of indices to render in one batch
+const maxMesh3dIndices = 64 * 1024 * 3
+
+// maximum number of model transforms in one batch
+const maxMesh3dTransforms = 1024 * 4
+
+type Mesh3dVertex struct {
+	_ structs.HostLayout
+
+	Position glm.Vec3f
+	Normal   glm.Vec3f
+
+	// UV coordinates to sample the texture at, in range 0 to 1
+	UV glm.Vec2f
+
+	Color glm.Vec4f
+
+	TransformIndex uint32
+}
+
+type mesh3dBatchConfig struct {
+	target     *pulse.Texture
+	blendState wgpu.BlendState
+	cullMode   wgpu.CullMode
+	shader     string
+	material   *Material
+
+	viewProjection glm.Mat4f
+	light          glm.Vec4f
+
+	// root of the texture to sample from, might be nil
+	texture      *pulse.Texture
+	filterMode   wgpu.FilterMode
+	mipmapFilter wgpu.MipmapFilterMode
+	addressModeU wgpu.AddressMode
+	addressModeV wgpu.AddressMode
+}
+
+// mesh3dUniforms matches the Uniforms struct in mesh3d.wgsl
+type mesh3dUniforms struct {
+	_ structs.HostLayout
+
+	ViewProjection [16]float32
+	Light          glm.Vec4f
+}
+
+// Mesh3dCommand draws triangles in 3d space. Depth testing uses the depth
+// texture attached to the target, see pulse.Texture.DepthTexture.
+type Mesh3dCommand struct {
+	ctx *pulse.Context
+
+	pipelineCache *pulse.PipelineCache[mesh3dRenderPipeline]
+
+	vertices        []Mesh3dVertex
+	indices         []uint32
+	modelTransforms [][16]float32
+
+	bufVertices        *wgpu.Buffer
+	bufIndices         *wgpu.Buffer
+	bufModelTransforms *wgpu.Buffer
+	bufUniforms        *wgpu.Buffer
+
+	// bound if a batch without texture is drawn with the default shader
+	whiteTexture *pulse.Texture
+
+	batchConfig mesh3dBatchConfig
+}
+
+func NewMesh3dCommand(ctx *pulse.Context) *Mesh3dCommand {
+	bufVertices := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh3d.Vertices",
+		Usage: wgpu.BufferUsageVertex | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof(Mesh3dVertex{})) * maxMesh3dVertices,
+	})
+
+	bufIndices := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh3d.Indices",
+		Usage: wgpu.BufferUsageIndex | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof(uint32(0))) * maxMesh3dIndices,
+	})
+
+	bufModelTransforms := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh3d.ModelTransformations",
+		Usage: wgpu.BufferUsageStorage | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof([16]float32{})) * maxMesh3dTransforms,
+	})
+
+	bufUniforms := ctx.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Mesh3d.Uniforms",
+		Usage: wgpu.BufferUsageUniform | wgpu.BufferUsageCopyDst,
+		Size:  uint64(unsafe.Sizeof(mesh3dUniforms{})),
+	})
+
+	whiteTexture := pulse.NewTexture(ctx, pulse.NewTextureOptions{
+		Label:  "Mesh3d.White",
+		Width:  1,
+		Height: 1,
+		Format: wgpu.TextureFormatRGBA8Unorm,
+	})
+
+	whiteTexture.WritePixels(ctx, []byte{0xff, 0xff, 0xff, 0xff})
+
+	p := &Mesh3dCommand{
+		ctx:                ctx,
+		bufVertices:        bufVertices,
+		bufIndices:         bufIndices,
+		bufModelTransforms: bufModelTransforms,
+		bufUniforms:        bufUniforms,
+		whiteTexture:       whiteTexture,
+	}
+
+	p.pipelineCache = pulse.NewPipelineCache[mesh3dRenderPipeline](ctx)
+
+	return p
+}
+
+type DrawMesh3dOptions struct {
+	// Model transform of the mesh
+	Model glm.Mat4f
+
+	// ViewProjection transform of the camera. The projection is expected to
+	// map depth to -1 to 1, as done by glm.Perspective.
+	ViewProjection glm.Mat4f
+
+	// LightDirection is the direction a directional light shines to. If zero,
+	// the mesh is not lit. Ambient is the brightness of faces facing away from
+	// the light, between 0 and 1.
+	LightDirection glm.Vec3f
+	Ambient        float32
+
+	// Color multiplied with the vertex colors
+	Color glm.Vec4f
+
+	BlendState wgpu.BlendState
+
+	// CullMode defaults to no culling. Front faces are counter-clockwise.
+	CullMode wgpu.CullMode
+
+	// Texture to sample from using the uv coordinates of the vertices. The
+	// sampled color is multiplied with the vertex color. Might be nil.
+	Texture *pulse.Texture
+
+	// Sampler configuration, defaults to linear filtering and clamp to edge
+	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
+	AddressModeU wgpu.AddressMode
+	AddressModeV wgpu.AddressMode
+
+	// shader code, use default if empty, see mesh3d.wgsl for the expected layout.
+	// A custom shader needs to declare the texture at binding 2 and the sampler
+	// at binding 3 only if a Texture is set.
+	Shader string
+
+	// Material provides additional bindings to a custom shader, might be nil
+	Material *Material
+}
+
+// DrawTriangles draws a list of triangles, each three vertices form a triangle.
+func (p *Mesh3dCommand) DrawTriangles(target *pulse.Texture, vertices []Mesh3dVertex, opts DrawMesh3dOptions) {
+	indices := make([]uint32, len(vertices)/3*3)
+	for idx := range indices {
+		indices[idx] = uint32(idx)
+	}
+
+	p.DrawIndexedTriangles(target, vertices, indices, opts)
+}
+
+// DrawIndexedTriangles draws triangles made of the vertices referenced by indices,
+// each three indices form a triangle.
+func (p *Mesh3dCommand) DrawIndexedTriangles(target *pulse.Texture, vertices []Mesh3dVertex, indices []uint32, opts DrawMesh3dOptions) {
+	indices = indices[:len(indices)/3*3]
+
+	if len(vertices) > maxMesh3dVertices || len(indices) > maxMesh3dIndices {
+		// too large for a single batch, split into chunks of individual triangles
+		const chunkSize = maxMesh3dVertices / 3 * 3
+
+		for len(indices) > 0 {
+			chunk := indices[:min(len(indices), chunkSize)]
+			indices = indices[len(chunk):]
+
+			chunkVertices := make([]Mesh3dVertex, len(chunk))
+			for idx, vertexIdx := range chunk {
+				chunkVertices[idx] = vertices[vertexIdx]
+			}
+
+			p.DrawTriangles(target, chunkVertices, opts)
+		}
+
+		return
+	}
+
+	batchConfig := p.batchConfigOf(target, &opts)
+
+	requireFlush := p.batchConfig != batchConfig ||
+		len(p.vertices)+len(vertices) > maxMesh3dVertices ||
+		len(p.indices)+len(indices) > maxMesh3dIndices ||
+		len(p.modelTransforms) >= maxMesh3dTransforms
+
+	if requireFlush {
+		p.Flush()
+		p.batchConfig = batchConfig
+	}
+
+	modelTransformIndex := p.pushTransform(opts.Model)
+
+	base := uint32(len(p.vertices))
+
+	for _, vertexIdx := range indices {
+		if int(vertexIdx) >= len(vertices) {
+			panic(fmt.Sprintf("vertex index %d out of range, have %d vertices", vertexIdx, len(vertices)))
+		}
+
+		p.indices = append(p.indices, base+vertexIdx)
+	}
+
+	// map the uv coordinates into the region of a sub texture
+	uvOffset, uvScale := glm.Vec2f{0, 0}, glm.Vec2f{1, 1}
+
+	if opts.Texture != nil {
+		uv := opts.Texture.UV()
+		uvOffset = uv.Min
+		uvScale = uv.Size()
+	}
+
+	for _, v := range vertices {
+		v.Color = v.Color.Mul(opts.Color)
+		v.UV = uvOffset.Add(v.UV.Mul(uvScale))
+		v.TransformIndex = modelTransformIndex
+
+		p.vertices = append(p.vertices, v)
+	}
+}
+
+func (p *Mesh3dCommand) batchConfigOf(target *pulse.Texture, opts *DrawMesh3dOptions) mesh3dBatchConfig {
+	if opts.Shader == "" {
+		opts.Shader = mesh3dShaderCode
+	}
+
+	if opts.CullMode == wgpu.CullModeUndefined {
+		opts.CullMode = wgpu.CullModeNone
+	}
+
+	batchConfig := mesh3dBatchConfig{
+		target:         target,
+		blendState:     opts.BlendState,
+		cullMode:       opts.CullMode,
+		shader:         opts.Shader,
+		material:       opts.Material,
+		viewProjection: opts.ViewProjection,
+		light:          opts.LightDirection.Extend(opts.Ambient),
+	}
+
+	// sampler settings do not matter without a texture
+	if opts.Texture != nil {
+		batchConfig.texture = opts.Texture.Root()
+		batchConfig.filterMode = opts.FilterMode
+		batchConfig.mipmapFilter = opts.MipmapFilter
+		batchConfig.addressModeU = opts.AddressModeU
+		batchConfig.addressModeV = opts.AddressModeV
+	}
+
+	if batchConfig.filterMode == wgpu.FilterModeUndefined {
+		batchConfig.filterMode = wgpu.FilterModeLinear
+	}
+
+	if batchConfig.mipmapFilter == wgpu.MipmapFilterModeUndefined {
+		batchConfig.mipmapFilter = wgpu.MipmapFilterModeLinear
+	}
+
+	if batchConfig.addressModeU == wgpu.AddressModeUndefined {
+		batchConfig.addressModeU = wgpu.AddressModeClampToEdge
+	}
+
+	if batchConfig.addressModeV == wgpu.AddressModeUndefined {
+		batchConfig.addressModeV = wgpu.AddressModeClampToEdge
+	}
+
+	return batchConfig
+}
+
+// pushTransform adds the model transform to the current batch,
+// if it differs from the previous one, and returns its index.
+func (p *Mesh3dCommand) pushTransform(transform glm.Mat4f) uint32 {
+	modelTransform := transform.ToWGPU()
+
+	if len(p.modelTransforms) == 0 || p.modelTransforms[len(p.modelTransforms)-1] != modelTransform {
+		p.modelTransforms = append(p.modelTransforms, modelTransform)
+	}
+
+	return uint32(len(p.modelTransforms) - 1)
+}
+
+func (p *Mesh3dCommand) Flush() {
+	defer p.reset()
+
+	if len(p.indices) == 0 {
+		return
+	}
+
+	slog.Debug("Rendering 3d triangles", slog.Int("vertexCount", len(p.vertices)))
+
+	batchConfig := p.batchConfig
+
+	pipelineConfig := mesh3dRenderPipeline{
+		TargetFormat:      batchConfig.target.Format(),
+		TargetSampleCount: batchConfig.target.SampleCount(),
+		BlendState:        batchConfig.blendState,
+		CullMode:          batchConfig.cullMode,
+		ShaderSource:      batchConfig.shader,
+		StencilClip:       batchConfig.target.Clip().Stencil(),
+	}
+
+	pc, err := p.pipelineCache.TryGet(pipelineConfig)
+	if err != nil {
+		// skip this batch and let the context handle the error
+		p.ctx.ReportError(err)
+		return
+	}
+
+	entries := []wgpu.BindGroupEntry{
+		{
+			Binding: 0,
+			Buffer:  p.bufUniforms,
+			Size:    wgpu.WholeSize,
+		},
+		{
+			Binding: 1,
+			Buffer:  p.bufModelTransforms,
+			Size:    wgpu.WholeSize,
+		},
+	}
+
+	texture := batchConfig.texture
+	if texture == nil && batchConfig.shader == mesh3dShaderCode {
+		// the default shader always samples a texture
+		texture = p.whiteTexture
+	}
+
+	if texture != nil {
+		sampler := pulse.CachedSampler(p.ctx.Device, wgpu.SamplerDescriptor{
+			Label:         "Mesh3d-Sampler",
+			AddressModeU:  batchConfig.addressModeU,
+			AddressModeV:  batchConfig.addressModeV,
+			AddressModeW:  wgpu.AddressModeUndefined,
+			MagFilter:     batchConfig.filterMode,
+			MinFilter:     batchConfig.filterMode,
+			MipmapFilter:  batchConfig.mipmapFilter,
+			LodMinClamp:   0,
+			LodMaxClamp:   32,
+			MaxAnisotropy: 1,
+		})
+
+		entries = append(entries,
+			wgpu.BindGroupEntry{
+				Binding:     2,
+				TextureView: texture.SourceView(),
+			},
+			wgpu.BindGroupEntry{
+				Binding: 3,
+				Sampler: sampler,
+			},
+		)
+	}
+
+	bindGroup := p.ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Label:   "Mesh3dBindGroup",
+		Layout:  pc.GetBindGroupLayout(0),
+		Entries: entries,
+	})
+
+	defer bindGroup.Release()
+
+	encoder := p.ctx.CreateCommandEncoder(nil)
+	defer encoder.Release()
+
+	view, resolveTarget := batchConfig.target.RenderViews()
+
+	depth := batchConfig.target.DepthTexture(p.ctx)
+
+	pass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+		Label: "RenderPassMesh3d",
+		ColorAttachments: []wgpu.RenderPassColorAttachment{
+			{
+				View:          view,
+				ResolveTarget: resolveTarget,
+				LoadOp:        wgpu.LoadOpLoad,
+				StoreOp:       wgpu.StoreOpStore,
+			},
+		},
+		DepthStencilAttachment: depth.DepthAttachment(wgpu.LoadOpLoad),
+	})
+
+	// limit drawing to the target region and the clip
+	applyClip(pass, batchConfig.target, nil)
+
+	pass.SetPipeline(pc.Pipeline)
+	pass.SetBindGroup(0, bindGroup, nil)
+
+	if batchConfig.material != nil {
+		if materialBindGroup := batchConfig.material.createBindGroup(p.ctx, pc); materialBindGroup != nil {
+			defer materialBindGroup.Release()
+			pass.SetBindGroup(1, materialBindGroup, nil)
+		}
+	}
+
+	pass.SetVertexBuffer(0, p.bufVertices, 0, wgpu.WholeSize)
+	pass.SetIndexBuffer(p.bufIndices, wgpu.IndexFormatUint32, 0, wgpu.WholeSize)
+	pass.DrawIndexed(uint32(len(p.indices)), 1, 0, 0, 0)
+	pass.End()
+
+	cmdBuffer := encoder.Finish(nil)
+	defer cmdBuffer.Release()
+
+	// map the clip space of the target region into the clip space of the root texture
+	viewProjection := regionTransform(batchConfig.target).Mul(batchConfig.viewProjection)
+
+	uniforms := mesh3dUniforms{
+		ViewProjection: viewProjection.ToWGPU(),
+		Light:          batchConfig.light,
+	}
+
+	p.ctx.WriteBuffer(p.bufVertices, 0, wgpu.ToBytes(p.vertices))
+	p.ctx.WriteBuffer(p.bufIndices, 0, wgpu.ToBytes(p.indices))
+	p.ctx.WriteBuffer(p.bufModelTransforms, 0, wgpu.ToBytes(p.modelTransforms))
+	p.ctx.WriteBuffer(p.bufUniforms, 0, wgpu.ToBytes([]mesh3dUniforms{uniforms}))
+	p.ctx.Submit(cmdBuffer)
+}
+
+// regionTransform transforms clip space coordinates of a sub texture
+// into clip space coordinates of its root texture.
+func regionTransform(target *pulse.Texture) glm.Mat4f {
+	if !target.IsSubTexture() {
+		return glm.Mat4f{}
+	}
+
+	rw, rh := target.Root().Size().ToVec2f().XY()
+	ox, oy := target.Offset().ToVec2f().XY()
+	sw, sh := target.Size().ToVec2f().XY()
+
+	// clip space y points up, texture coordinates point down
+	return glm.Mat4Of([4][4]float32{
+		{sw / rw, 0, 0, 0},
+		{0, sh / rh, 0, 0},
+		{0, 0, 1, 0},
+		{(2*ox+sw)/rw - 1, 1 - (2*oy+sh)/rh, 0, 1},
+	})
+}
+
+type mesh3dRenderPipeline struct {
+	TargetFormat      wgpu.TextureFormat
+	BlendState        wgpu.BlendState
+	CullMode          wgpu.CullMode
+	TargetSampleCount uint32
+	ShaderSource      string
+
+	// true if the target is clipped using the stencil test
+	StencilClip bool
+}
+
+func (conf mesh3dRenderPipeline) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
+	slog.Info(
+		"Create RenderPipeline for mesh3d",
+		slog.Any("config", conf.TargetFormat),
+		slog.Any("sampleCount", conf.TargetSampleCount),
+	)
+
+	shader, err := pulse.CreateShaderModule(dev, "Mesh3D.ShaderSource", conf.ShaderSource)
+	if err != nil {
+		return nil, err
+	}
+
+	defer shader.Release()
+
+	stencilFace := wgpu.StencilFaceState{
+		Compare:     wgpu.CompareFunctionAlways,
+		FailOp:      wgpu.StencilOperationKeep,
+		DepthFailOp: wgpu.StencilOperationKeep,
+		PassOp:      wgpu.StencilOperationKeep,
+	}
+
+	if conf.StencilClip {
+		// only draw where the stencil value matches the reference of the clip
+		stencilFace.Compare = wgpu.CompareFunctionEqual
+	}
+
+	desc := &wgpu.RenderPipelineDescriptor{
+		Label: fmt.Sprintf("Mesh3D.%s", conf.TargetFormat),
+		Vertex: wgpu.VertexState{
+			Module:     shader,
+			EntryPoint: "vs_main",
+			Buffers: []wgpu.VertexBufferLayout{
+				{
+					ArrayStride: uint64(unsafe.Sizeof(Mesh3dVertex{})),
+					StepMode:    wgpu.VertexStepModeVertex,
+					Attributes: []wgpu.VertexAttribute{
+						{
+							// position
+							Format:         wgpu.VertexFormatFloat32x3,
+							Offset:         uint64(unsafe.Offsetof(Mesh3dVertex{}.Position)),
+							ShaderLocation: 0,
+						},
+						{
+							// normal
+							Format:         wgpu.VertexFormatFloat32x3,
+							Offset:         uint64(unsafe.Offsetof(Mesh3dVertex{}.Normal)),
+							ShaderLocation: 1,
+						},
+						{
+							// uv
+							Format:         wgpu.VertexFormatFloat32x2,
+							Offset:         uint64(unsafe.Offsetof(Mesh3dVertex{}.UV)),
+							ShaderLocation: 2,
+						},
+						{
+							// color
+							Format:         wgpu.VertexFormatFloat32x4,
+							Offset:         uint64(unsafe.Offsetof(Mesh3dVertex{}.Color)),
+							ShaderLocation: 3,
+						},
+						{
+							// transform index
+							Format:         wgpu.VertexFormatUint32,
+							Offset:         uint64(unsafe.Offsetof(Mesh3dVertex{}.TransformIndex)),
+							ShaderLocation: 4,
+						},
+					},
+				},
+			},
+		},
+		Fragment: &wgpu.FragmentState{
+			Module:     shader,
+			EntryPoint: "fs_main",
+			Targets: []wgpu.ColorTargetState{
+				{
+					Format:    conf.TargetFormat,
+					Blend:     &conf.BlendState,
+					WriteMask: wgpu.ColorWriteMaskAll,
+				},
+			},
+		},
+		Primitive: wgpu.PrimitiveState{
+			Topology:  wgpu.PrimitiveTopologyTriangleList,
+			FrontFace: wgpu.FrontFaceCCW,
+			CullMode:  conf.CullMode,
+		},
+		DepthStencil: &wgpu.DepthStencilState{
+			Format:            pulse.DepthTextureFormat,
+			DepthWriteEnabled: depthWriteEnabled,
+			DepthCompare:      wgpu.CompareFunctionLess,
+			StencilFront:      stencilFace,
+			StencilBack:       stencilFace,
+			StencilReadMask:   0xff,
+		},
+		Multisample: wgpu.MultisampleState{
+			Count:                  conf.TargetSampleCount,
+			Mask:                   0xFFFFFFFF,
+			AlphaToCoverageEnabled: false,
+		},
+	}
+
+	return pulse.CreateRenderPipeline(dev, desc)
+}
+
+func (p *Mesh3dCommand) reset() {
+	p.vertices = p.vertices[:0]
+	p.indices = p.indices[:0]
+	p.modelTransforms = p.modelTransforms[:0]
+	p.batchConfig = mesh3dBatchConfig{}
+}
@@ -0,0 +1,66 @@
+struct VertexOutput {
+    @location(0) color: vec4f,
+    @location(1) uv: vec2f,
+    @location(2) normal: vec3f,
+    @builtin(position) position: vec4f,
+};
+
+struct Uniforms {
+    view_projection: mat4x4f,
+
+    // direction the light shines to in xyz, a zero direction disables lighting.
+    // w holds the ambient light in range 0 to 1
+    light: vec4f,
+};
+
+@group(0) @binding(0) var<uniform> uniforms: Uniforms;
+
+@group(0) @binding(1) var<storage, read> model_transforms: array<mat4x4f>;
+
+// a white texture if the mesh is not textured
+@group(0) @binding(2) var texture: texture_2d<f32>;
+@group(0) @binding(3) var texture_sampler: sampler;
+
+@vertex
+fn vs_main(
+    @location(0) position: vec3f,
+    @location(1) normal: vec3f,
+    @location(2) uv: vec2f,
+    @location(3) color: vec4f,
+    @location(4) transform_idx: u32,
+) -> VertexOutput {
+    let model_transform = model_transforms[transform_idx];
+
+    var pos = uniforms.view_projection * model_transform * vec4f(position, 1.0);
+
+    // the projection matrix maps depth to -1..1, webgpu expects 0..1
+    pos.z = (pos.z + pos.w) * 0.5;
+
+    // rotate the normal into world space, ignores non uniform scaling
+    let normal_transform = mat3x3f(model_transform[0].xyz, model_transform[1].xyz, model_transform[2].xyz);
+
+    var result: VertexOutput;
+    result.position = pos;
+    result.color = color;
+    result.uv = uv;
+    result.normal = normal_transform * normal;
+    return result;
+}
+
+@fragment
+fn fs_main(vertex: VertexOutput) -> @location(0) vec4f {
+    let color = textureSample(texture, texture_sampler, vertex.uv) * vertex.color;
+
+    var brightness = 1.0;
+
+    let light_len = length(uniforms.light.xyz);
+    let normal_len = length(vertex.normal);
+    if light_len > 0.0 && normal_len > 0.0 {
+        let diffuse = max(dot(vertex.normal / normal_len, -uniforms.light.xyz / light_len), 0.0);
+
+        let ambient = uniforms.light.w;
+        brightness = ambient + (1.0 - ambient) * diffuse;
+    }
+
+    return vec4f(color.rgb * brightness, color.a);
+}
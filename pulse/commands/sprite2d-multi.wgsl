@@ -0,0 +1,161 @@
+struct VertexOutput {
+    // texture coordinates in pixels, converted to uv in the fragment shader
+    @location(0) uv_px: vec2f,
+    @location(1) color: vec4f,
+    @location(2) @interpolate(flat) scissor_min: vec2f,
+    @location(3) @interpolate(flat) scissor_max: vec2f,
+    @location(4) @interpolate(flat) texture_index: u32,
+    @builtin(position) position: vec4f,
+};
+
+struct Region {
+    pos: vec2f,
+    size: vec2f,
+};
+
+struct Regions {
+    rects: array<Region, 4>,
+    transforms: array<mat3x3f, 8>,
+    twod: array<array<vec3f, 2>, 4>,
+}
+
+fn to_region(in: vec4f) -> Region {
+    var rect: Region;
+    rect.pos = in.xy;
+    rect.size = in.zw;
+    return rect;
+}
+
+struct VertexUniforms {
+    // transforms the resulting coordinates into the 0 to 1 scale
+    target_texture_size: vec2f,
+
+    // the size of the texture in the first slot.
+    // we query the size of each texture in the fragment shader instead.
+    source_texture_size: vec2f,
+}
+
+@group(0)
+@binding(2)
+var<uniform> vertex_uniforms: VertexUniforms;
+
+@vertex
+fn vs_main(
+    @builtin(vertex_index) index: u32,
+    @location(0) color: vec4f,
+    @location(1) tr0: vec3f,
+    @location(2) tr1: vec3f,
+    @location(3) source_region_in: vec4f,
+    @location(4) target_region_in: vec4<u32>,
+    @location(5) texture_index: u32,
+) -> VertexOutput {
+    // (sub) image source and target region. The source region
+    // has a negative size if the sprite is flipped
+    let source_region = to_region(source_region_in);
+    let target_region = to_region(vec4f(target_region_in));
+
+    // apply sprite transform
+    let model_transform = transpose(
+        mat3x3(tr0, tr1, vec3f(0, 0, 1)),
+    );
+
+    // need to scale with the source region
+    let view_transform = mat3x3(
+        vec3f(abs(source_region.size.x), 0, 0),
+        vec3f(0, abs(source_region.size.y), 0),
+        vec3f(0, 0, 1),
+    );
+
+    // between 0 and 1
+    // index vertices as p00, p01, p10, p11, this way
+    // x and y can be derived from the lower bit of index
+    let x = f32((index >> 1) & 1);
+    let y = f32(index & 1);
+    let vertex = vec2f(x, y);
+
+    // target position relative to target region in pixel space
+    let pos_px = (model_transform * view_transform * vec3f(vertex, 1)).xy;
+
+    // calculate vertex position in ndc space (-1 to +1)
+    let pos_zo = ((target_region.pos + pos_px) / vertex_uniforms.target_texture_size);
+    let pos = pos_zo * 2.0 - 1.0;
+
+    // texture coordinates in pixel space. The textures are only accessed
+    // in the fragment shader, so we do not know the texture size yet.
+    var result: VertexOutput;
+    result.uv_px = source_region.pos + source_region.size * vertex;
+    result.color = color;
+    result.texture_index = texture_index;
+    result.position = vec4(pos.x, -pos.y, 0.0, 1.0);
+
+    // configure our own scissor rect to discard fragment samples
+    // if they do not fall into the target region
+    result.scissor_min = target_region.pos;
+    result.scissor_max = target_region.pos + target_region.size;
+
+    return result;
+}
+
+// the sprite batch binds up to eight textures at once.
+// unused slots are bound to the texture in the first slot.
+@group(0) @binding(0) var texture0: texture_2d<f32>;
+@group(0) @binding(3) var texture1: texture_2d<f32>;
+@group(0) @binding(4) var texture2: texture_2d<f32>;
+@group(0) @binding(5) var texture3: texture_2d<f32>;
+@group(0) @binding(6) var texture4: texture_2d<f32>;
+@group(0) @binding(7) var texture5: texture_2d<f32>;
+@group(0) @binding(8) var texture6: texture_2d<f32>;
+@group(0) @binding(9) var texture7: texture_2d<f32>;
+
+@group(0)
+@binding(1)
+var texSampler: sampler;
+
+fn texture_size(index: u32) -> vec2f {
+    switch index {
+        case 1u: { return vec2f(textureDimensions(texture1)); }
+        case 2u: { return vec2f(textureDimensions(texture2)); }
+        case 3u: { return vec2f(textureDimensions(texture3)); }
+        case 4u: { return vec2f(textureDimensions(texture4)); }
+        case 5u: { return vec2f(textureDimensions(texture5)); }
+        case 6u: { return vec2f(textureDimensions(texture6)); }
+        case 7u: { return vec2f(textureDimensions(texture7)); }
+        default: { return vec2f(textureDimensions(texture0)); }
+    }
+}
+
+// the texture index is not uniform, so we can not use textureSample within the switch.
+// instead, we calculate the derivatives upfront and pass them to textureSampleGrad.
+fn sample_texture(index: u32, uv: vec2f, ddx: vec2f, ddy: vec2f) -> vec4f {
+    switch index {
+        case 1u: { return textureSampleGrad(texture1, texSampler, uv, ddx, ddy); }
+        case 2u: { return textureSampleGrad(texture2, texSampler, uv, ddx, ddy); }
+        case 3u: { return textureSampleGrad(texture3, texSampler, uv, ddx, ddy); }
+        case 4u: { return textureSampleGrad(texture4, texSampler, uv, ddx, ddy); }
+        case 5u: { return textureSampleGrad(texture5, texSampler, uv, ddx, ddy); }
+        case 6u: { return textureSampleGrad(texture6, texSampler, uv, ddx, ddy); }
+        case 7u: { return textureSampleGrad(texture7, texSampler, uv, ddx, ddy); }
+        default: { return textureSampleGrad(texture0, texSampler, uv, ddx, ddy); }
+    }
+}
+
+@fragment
+fn fs_main(vertex: VertexOutput) -> @location(0) vec4f {
+    // the texture index is constant for a sprite, so is the texture size
+    let texsize = 1.0 / texture_size(vertex.texture_index);
+    let uv = vertex.uv_px * texsize;
+
+    let ddx = dpdx(uv);
+    let ddy = dpdy(uv);
+
+    if vertex.position.x < vertex.scissor_min.x ||
+       vertex.position.x > vertex.scissor_max.x ||
+       vertex.position.y < vertex.scissor_min.y ||
+       vertex.position.y > vertex.scissor_max.y {
+
+       discard;
+    }
+
+    let tex = sample_texture(vertex.texture_index, uv, ddx, ddy);
+    return tex * vertex.color;
+}
@@ -14,24 +14,32 @@ import (
 //go:embed sprite2d.wgsl
 var spriteShaderCode string
 
+//go:embed sprite2d-multi.wgsl
+var spriteMultiShaderCode string
+
 // maximum number of sprite vertices to render in one batchConfig.
 const maxSpriteInstances = 128 * 1024
 
+// maximum number of textures bound to a single batch
+const maxSpriteTextures = 8
+
 type spriteVertexUniforms struct {
 	targetTextureSize glm.Vec2f
 	sourceTextureSize glm.Vec2f
 }
 
 type spriteBatchConfig struct {
-	target              *pulse.Texture
-	texture             *wgpu.TextureView
-	filterMode          wgpu.FilterMode
-	blendState          wgpu.BlendState
-	addressModeU        wgpu.AddressMode
-	addressModeV        wgpu.AddressMode
-	sourceTextureWidth  uint32
-	sourceTextureHeight uint32
-	shader              string
+	target       *pulse.Texture
+	filterMode   wgpu.FilterMode
+	mipmapFilter wgpu.MipmapFilterMode
+	blendState   wgpu.BlendState
+	addressModeU wgpu.AddressMode
+	addressModeV wgpu.AddressMode
+	shader       string
+	material     *Material
+
+	// number of textures the shader can sample from
+	textureSlots int
 }
 
 type spriteInstance struct {
@@ -42,15 +50,19 @@ type spriteInstance struct {
 	ModelTransposedCol0 glm.Vec3f
 	ModelTransposedCol1 glm.Vec3f
 
-	// Source region within the source texture (x, y, w, h).
-	// The source rectangle maps to uv 0 to 1.
-	SourceRegion glm.Vec4uh
+	// Source region within the root of the source texture in pixels (x, y, w, h).
+	// A negative width or height flips the sprite along that axis. The region
+	// may extend beyond the texture when using a repeating address mode.
+	SourceRegion glm.Vec4f
 
 	// Target region to draw to in target texture (x, y, w, h)
 	// The sprites vertex coordinates are interpreted relative to x, y
 	// and are clipped to the target region. The sprite is a square from 0 to 1 and
 	// transformed with the model matrix first.
-	TargetRegion glm.Vec4uh
+	TargetRegion glm.Vec4u
+
+	// Index of the texture slot to sample from
+	TextureIndex uint32
 }
 
 type SpriteCommand struct {
@@ -65,6 +77,12 @@ type SpriteCommand struct {
 	bufVertexUniforms *wgpu.Buffer
 
 	batchConfig spriteBatchConfig
+
+	// root textures bound to the current batch
+	textures []*pulse.Texture
+
+	// true if the device supports enough sampled textures for the multi texture shader
+	multiTexture bool
 }
 
 func NewSpriteCommand(ctx *pulse.Context) *SpriteCommand {
@@ -87,11 +105,16 @@ func NewSpriteCommand(ctx *pulse.Context) *SpriteCommand {
 		Size:  uint64(unsafe.Sizeof(spriteVertexUniforms{})),
 	})
 
+	// query the limits of the adapter, Device.GetLimits of the webgpu
+	// bindings frees memory it does not own.
+	limits := ctx.Adapter.GetLimits()
+
 	p := &SpriteCommand{
 		ctx:               ctx,
 		bufInstances:      bufInstances,
 		bufIndices:        bufIndices,
 		bufVertexUniforms: bufVertexUniforms,
+		multiTexture:      limits.MaxSampledTexturesPerShaderStage >= maxSpriteTextures,
 	}
 
 	p.pipelineCache = pulse.NewPipelineCache[spritePipelineConfig](ctx)
@@ -103,42 +126,80 @@ type DrawSpriteOptions struct {
 	Transform    glm.Mat3f
 	Color        pulse.Color
 	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
 	BlendState   wgpu.BlendState
 	AddressModeU wgpu.AddressMode
 	AddressModeV wgpu.AddressMode
 
-	// shader code, use default if empty
+	// SourceRegion to draw in pixels, relative to the source texture.
+	// Defaults to the full source texture if empty.
+	SourceRegion pulse.Rectangle2f
+
+	// Mirror the sprite horizontally or vertically
+	FlipX, FlipY bool
+
+	// shader code, use default if empty. A custom shader samples from a single
+	// texture bound at binding 0, like the single texture default shader in
+	// sprite2d.wgsl. See there for the expected layout.
 	Shader string
+
+	// Material provides additional bindings to a custom shader, might be nil
+	Material *Material
 }
 
 func (p *SpriteCommand) Draw(dest *pulse.Texture, source *pulse.Texture, opts DrawSpriteOptions) {
-	if opts.Shader == "" {
-		opts.Shader = spriteShaderCode
-	}
+	shader, textureSlots := p.shaderOf(opts.Shader)
 
 	batchConfig := spriteBatchConfig{
-		target:              dest.Root(),
-		texture:             source.Root().SourceView(),
-		sourceTextureWidth:  source.Root().Width(),
-		sourceTextureHeight: source.Root().Height(),
-		filterMode:          opts.FilterMode,
-		blendState:          opts.BlendState,
-		addressModeU:        opts.AddressModeU,
-		addressModeV:        opts.AddressModeV,
-		shader:              opts.Shader,
+		target:       dest.Root(),
+		filterMode:   opts.FilterMode,
+		mipmapFilter: opts.MipmapFilter,
+		blendState:   opts.BlendState,
+		addressModeU: opts.AddressModeU,
+		addressModeV: opts.AddressModeV,
+		shader:       shader,
+		material:     opts.Material,
+		textureSlots: textureSlots,
 	}
 
 	requireFlush := p.batchConfig != batchConfig ||
 		len(p.instances)+1 > maxSpriteInstances
 
+	var textureIndex uint32
+
+	if !requireFlush {
+		// try to bind the texture to the current batch
+		var ok bool
+		textureIndex, ok = p.textureSlot(source.Root())
+		requireFlush = !ok
+	}
+
 	if requireFlush {
 		p.Flush()
 
 		p.batchConfig = batchConfig
+
+		// we have a fresh batch, there is always a free slot
+		textureIndex, _ = p.textureSlot(source.Root())
 	}
 
-	sx, sy := source.Offset().XY()
-	sw, sh := source.Size().XY()
+	sx, sy := source.Offset().ToVec2f().XY()
+	sw, sh := source.Size().ToVec2f().XY()
+
+	if opts.SourceRegion != (pulse.Rectangle2f{}) {
+		sx += opts.SourceRegion.Min[0]
+		sy += opts.SourceRegion.Min[1]
+		sw, sh = opts.SourceRegion.Size().XY()
+	}
+
+	// flip by walking the source region backwards
+	if opts.FlipX {
+		sx, sw = sx+sw, -sw
+	}
+
+	if opts.FlipY {
+		sy, sh = sy+sh, -sh
+	}
 
 	dx, dy := dest.Offset().XY()
 	dw, dh := dest.Size().XY()
@@ -148,54 +209,80 @@ func (p *SpriteCommand) Draw(dest *pulse.Texture, source *pulse.Texture, opts Dr
 		ModelTransposedCol0: opts.Transform.Row(0),
 		ModelTransposedCol1: opts.Transform.Row(1),
 
-		SourceRegion: glm.Vec4uh{
-			uint16(sx),
-			uint16(sy),
-			uint16(sw),
-			uint16(sh),
-		},
+		SourceRegion: glm.Vec4f{sx, sy, sw, sh},
+		TargetRegion: glm.Vec4u{dx, dy, dw, dh},
 
-		TargetRegion: glm.Vec4uh{
-			uint16(dx),
-			uint16(dy),
-			uint16(dw),
-			uint16(dh),
-		},
+		TextureIndex: textureIndex,
 	})
 }
 
+// shaderOf returns the shader to use and the number of textures it can sample from.
+// The default limits of webgpu allow 16 sampled textures per shader stage, enough
+// for the multi texture shader. Devices with lower limits use the single texture shader.
+func (p *SpriteCommand) shaderOf(custom string) (string, int) {
+	if custom != "" {
+		return custom, 1
+	}
+
+	if !p.multiTexture {
+		return spriteShaderCode, 1
+	}
+
+	return spriteMultiShaderCode, maxSpriteTextures
+}
+
+// textureSlot returns the slot of the texture in the current batch, binding the texture
+// to a free slot if needed. Returns false if all slots are in use.
+func (p *SpriteCommand) textureSlot(texture *pulse.Texture) (uint32, bool) {
+	for idx, bound := range p.textures {
+		if bound == texture {
+			return uint32(idx), true
+		}
+	}
+
+	if len(p.textures) >= max(1, p.batchConfig.textureSlots) {
+		return 0, false
+	}
+
+	p.textures = append(p.textures, texture)
+	return uint32(len(p.textures) - 1), true
+}
+
 type DrawSpriteFromGPUOptions struct {
 	Buffer        *wgpu.Buffer
 	InstanceCount uint
 
 	FilterMode   wgpu.FilterMode
+	MipmapFilter wgpu.MipmapFilterMode
 	BlendState   wgpu.BlendState
 	AddressModeU wgpu.AddressMode
 	AddressModeV wgpu.AddressMode
 	Shader       string
+	Material     *Material
 }
 
+// DrawFromGPU draws sprite instances from the given buffer. The source texture
+// is bound to the first texture slot, all instances must use texture index zero.
 func (p *SpriteCommand) DrawFromGPU(dest *pulse.Texture, source *pulse.Texture, opts DrawSpriteFromGPUOptions) {
 	p.Flush()
 
-	if opts.Shader == "" {
-		opts.Shader = spriteShaderCode
-	}
+	shader, textureSlots := p.shaderOf(opts.Shader)
 
 	p.batchConfig = spriteBatchConfig{
-		target:              dest.Root(),
-		texture:             source.Root().SourceView(),
-		sourceTextureWidth:  source.Root().Width(),
-		sourceTextureHeight: source.Root().Height(),
-		filterMode:          opts.FilterMode,
-		blendState:          opts.BlendState,
-		addressModeU:        opts.AddressModeU,
-		addressModeV:        opts.AddressModeV,
-		shader:              opts.Shader,
+		target:       dest.Root(),
+		filterMode:   opts.FilterMode,
+		mipmapFilter: opts.MipmapFilter,
+		blendState:   opts.BlendState,
+		addressModeU: opts.AddressModeU,
+		addressModeV: opts.AddressModeV,
+		shader:       shader,
+		material:     opts.Material,
+		textureSlots: textureSlots,
 	}
 
-	p.flushWith(opts.Buffer, uint32(opts.InstanceCount), nil)
+	p.textures = append(p.textures[:0], source.Root())
 
+	p.flushWith(opts.Buffer, uint32(opts.InstanceCount), nil)
 }
 
 func (p *SpriteCommand) Flush() {
@@ -235,7 +322,7 @@ func (p *SpriteCommand) flushWith(instances *wgpu.Buffer, instanceCount uint32,
 	defer p.reset()
 
 	batchConfig := p.batchConfig
-	if len(p.instances) == 0 {
+	if instanceCount == 0 {
 		return
 	}
 
@@ -246,9 +333,9 @@ func (p *SpriteCommand) flushWith(instances *wgpu.Buffer, instanceCount uint32,
 		AddressModeW:  wgpu.AddressModeUndefined,
 		MagFilter:     batchConfig.filterMode,
 		MinFilter:     batchConfig.filterMode,
-		MipmapFilter:  wgpu.MipmapFilterModeLinear,
-		LodMinClamp:   1,
-		LodMaxClamp:   1,
+		MipmapFilter:  batchConfig.mipmapFilter,
+		LodMinClamp:   0,
+		LodMaxClamp:   32,
 		MaxAnisotropy: 1,
 	}
 
@@ -259,28 +346,50 @@ func (p *SpriteCommand) flushWith(instances *wgpu.Buffer, instanceCount uint32,
 		TargetSampleCount: batchConfig.target.SampleCount(),
 		BlendState:        batchConfig.blendState,
 		ShaderSource:      batchConfig.shader,
+		StencilClip:       batchConfig.target.Clip().Stencil(),
 	}
 
-	pc := p.pipelineCache.Get(pipelineConfig)
+	pc, err := p.pipelineCache.TryGet(pipelineConfig)
+	if err != nil {
+		// skip this batch and let the context handle the error
+		p.ctx.ReportError(err)
+		return
+	}
 
-	bindGroup := p.ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "Sprite2 BindGroup",
-		Layout: pc.GetBindGroupLayout(0),
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: batchConfig.texture,
-			},
-			{
-				Binding: 1,
-				Sampler: sampler,
-			},
-			{
-				Binding: 2,
-				Buffer:  p.bufVertexUniforms,
-				Size:    wgpu.WholeSize,
-			},
+	entries := []wgpu.BindGroupEntry{
+		{
+			Binding:     0,
+			TextureView: p.textures[0].SourceView(),
+		},
+		{
+			Binding: 1,
+			Sampler: sampler,
+		},
+		{
+			Binding: 2,
+			Buffer:  p.bufVertexUniforms,
+			Size:    wgpu.WholeSize,
 		},
+	}
+
+	// the remaining texture slots start at binding 3,
+	// unused slots are filled with the first texture
+	for slot := 1; slot < batchConfig.textureSlots; slot++ {
+		texture := p.textures[0]
+		if slot < len(p.textures) {
+			texture = p.textures[slot]
+		}
+
+		entries = append(entries, wgpu.BindGroupEntry{
+			Binding:     uint32(slot + 2),
+			TextureView: texture.SourceView(),
+		})
+	}
+
+	bindGroup := p.ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Label:   "Sprite2 BindGroup",
+		Layout:  pc.GetBindGroupLayout(0),
+		Entries: entries,
 	})
 
 	defer bindGroup.Release()
@@ -293,8 +402,8 @@ func (p *SpriteCommand) flushWith(instances *wgpu.Buffer, instanceCount uint32,
 		},
 
 		sourceTextureSize: glm.Vec2f{
-			float32(batchConfig.sourceTextureWidth),
-			float32(batchConfig.sourceTextureHeight),
+			float32(p.textures[0].Width()),
+			float32(p.textures[0].Height()),
 		},
 	}
 
@@ -317,14 +426,20 @@ func (p *SpriteCommand) flushWith(instances *wgpu.Buffer, instanceCount uint32,
 				StoreOp:       wgpu.StoreOpStore,
 			},
 		},
+		DepthStencilAttachment: clipAttachment(p.ctx, batchConfig.target),
 	})
 
-	if scissorRect != nil {
-		pass.SetScissorRect(scissorRect.XYWH())
-	}
+	applyClip(pass, batchConfig.target, scissorRect)
 
 	pass.SetPipeline(pc.Pipeline)
 	pass.SetBindGroup(0, bindGroup, nil)
+
+	if batchConfig.material != nil {
+		if materialBindGroup := batchConfig.material.createBindGroup(p.ctx, pc); materialBindGroup != nil {
+			defer materialBindGroup.Release()
+			pass.SetBindGroup(1, materialBindGroup, nil)
+		}
+	}
 	pass.SetVertexBuffer(0, instances, 0, wgpu.WholeSize)
 	pass.SetIndexBuffer(p.bufIndices, wgpu.IndexFormatUint16, 0, wgpu.WholeSize)
 	pass.DrawIndexed(6, instanceCount, 0, 0, 0)
@@ -341,19 +456,22 @@ type spritePipelineConfig struct {
 	BlendState        wgpu.BlendState
 	TargetSampleCount uint32
 	ShaderSource      string
+
+	// true if the target is clipped using the stencil test
+	StencilClip bool
 }
 
-func (conf spritePipelineConfig) Specialize(dev *wgpu.Device) *wgpu.RenderPipeline {
+func (conf spritePipelineConfig) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
 	slog.Info(
 		"Create RenderPipeline for sprites",
 		slog.Any("config", conf.TargetFormat),
 		slog.Any("sampleCount", conf.TargetSampleCount),
 	)
 
-	shader := dev.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label:      "Sprite2D.ShaderSource",
-		WGSLSource: &wgpu.ShaderSourceWGSL{Code: conf.ShaderSource},
-	})
+	shader, err := pulse.CreateShaderModule(dev, "Sprite2D.ShaderSource", conf.ShaderSource)
+	if err != nil {
+		return nil, err
+	}
 
 	defer shader.Release()
 
@@ -386,17 +504,23 @@ func (conf spritePipelineConfig) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 							ShaderLocation: 2,
 						},
 						{
-							// uv pos
-							Format:         wgpu.VertexFormatUint32x2,
+							// source region
+							Format:         wgpu.VertexFormatFloat32x4,
 							Offset:         uint64(unsafe.Offsetof(spriteInstance{}.SourceRegion)),
 							ShaderLocation: 3,
 						},
 						{
-							// uv scale
-							Format:         wgpu.VertexFormatUint32x2,
+							// target region
+							Format:         wgpu.VertexFormatUint32x4,
 							Offset:         uint64(unsafe.Offsetof(spriteInstance{}.TargetRegion)),
 							ShaderLocation: 4,
 						},
+						{
+							// texture index
+							Format:         wgpu.VertexFormatUint32,
+							Offset:         uint64(unsafe.Offsetof(spriteInstance{}.TextureIndex)),
+							ShaderLocation: 5,
+						},
 					},
 				},
 			},
@@ -417,7 +541,6 @@ func (conf spritePipelineConfig) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 			FrontFace: wgpu.FrontFaceCCW,
 			CullMode:  wgpu.CullModeNone,
 		},
-		DepthStencil: nil,
 		Multisample: wgpu.MultisampleState{
 			Count:                  conf.TargetSampleCount,
 			Mask:                   0xFFFFFFFF,
@@ -425,10 +548,15 @@ func (conf spritePipelineConfig) Specialize(dev *wgpu.Device) *wgpu.RenderPipeli
 		},
 	}
 
-	return dev.CreateRenderPipeline(desc)
+	if conf.StencilClip {
+		desc.DepthStencil = clipDepthStencilState()
+	}
+
+	return pulse.CreateRenderPipeline(dev, desc)
 }
 
 func (p *SpriteCommand) reset() {
 	p.instances = p.instances[:0]
+	p.textures = p.textures[:0]
 	p.batchConfig = spriteBatchConfig{}
 }
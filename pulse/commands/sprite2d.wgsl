@@ -17,15 +17,10 @@ struct Regions {
     twod: array<array<vec3f, 2>, 4>,
 }
 
-fn decode_region(in: vec2<u32>) -> Region {
-    let x = f32((in[0] ) & 0xffff);
-    let y = f32((in[0] >> 16) & 0xffff);
-    let width = f32((in[1] >> 0) & 0xffff);
-    let height = f32((in[1] >> 16) & 0xffff);
-
+fn to_region(in: vec4f) -> Region {
     var rect: Region;
-    rect.pos = vec2f(x, y);
-    rect.size = vec2f(width, height);
+    rect.pos = in.xy;
+    rect.size = in.zw;
     return rect;
 }
 
@@ -47,12 +42,13 @@ fn vs_main(
     @location(0) color: vec4f,
     @location(1) tr0: vec3f,
     @location(2) tr1: vec3f,
-    @location(3) source_region_in: vec2<u32>,
-    @location(4) target_region_in: vec2<u32>,
+    @location(3) source_region_in: vec4f,
+    @location(4) target_region_in: vec4<u32>,
 ) -> VertexOutput {
-    // decode (sub) image source and target region
-    let source_region = decode_region(source_region_in);
-    let target_region = decode_region(target_region_in);
+    // (sub) image source and target region. The source region
+    // has a negative size if the sprite is flipped
+    let source_region = to_region(source_region_in);
+    let target_region = to_region(vec4f(target_region_in));
 
     // apply sprite transform
     let model_transform = transpose(
@@ -61,8 +57,8 @@ fn vs_main(
 
     // need to scale with the source region
     let view_transform = mat3x3(
-        vec3f(source_region.size.x, 0, 0),
-        vec3f(0, source_region.size.y, 0),
+        vec3f(abs(source_region.size.x), 0, 0),
+        vec3f(0, abs(source_region.size.y), 0),
         vec3f(0, 0, 1),
     );
 
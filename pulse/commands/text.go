@@ -73,13 +73,7 @@ func (t *DebugTextCommand) DrawText(dest *pulse.Texture, opts DrawDebugTextOptio
 			continue
 		}
 
-		posCh, ok := chars[ch]
-		if !ok {
-			// substitute with question mark
-			posCh = chars['?']
-		}
-
-		charTexture := t.texture.SubTexture(posCh, glm.Vec2[uint32]{6, 10})
+		charTexture := t.Glyph(ch)
 		charTransform := scale.Translate(pos.XY()).Mul(baseTransform)
 
 		if opts.ShadowColor.Alpha() > 0 {
@@ -99,6 +93,18 @@ func (t *DebugTextCommand) DrawText(dest *pulse.Texture, opts DrawDebugTextOptio
 	}
 }
 
+// Glyph returns the 6x10 pixel region of the font texture containing the given
+// character. Characters not in the font are substituted with a question mark.
+func (t *DebugTextCommand) Glyph(ch rune) *pulse.Texture {
+	posCh, ok := chars[ch]
+	if !ok {
+		// substitute with question mark
+		posCh = chars['?']
+	}
+
+	return t.texture.SubTexture(posCh, glm.Vec2[uint32]{6, 10})
+}
+
 func (t *DebugTextCommand) Flush() {
 	t.sprites.Flush()
 }
@@ -1,9 +1,11 @@
 package pulse
 
 import (
+	"log/slog"
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 
 	"github.com/oliverbestmann/webgpu/wgpu"
 )
@@ -36,6 +38,25 @@ type Context struct {
 	*wgpu.Queue
 	Surface *wgpu.Surface
 	Adapter *wgpu.Adapter
+
+	// OnError is called with errors that can not be returned to the caller,
+	// e.g. a broken shader detected while flushing a batch. If not set,
+	// errors are logged.
+	OnError func(err error)
+
+	// OnDeviceLost is called when the device is lost, e.g. after a driver crash or
+	// update. All gpu resources created with the device are invalid afterward, call
+	// RecreateDevice to continue rendering.
+	OnDeviceLost func(reason wgpu.DeviceLostReason, message string)
+
+	// lazily initialized pipelines for mipmap generation
+	mipmapPipelines *PipelineCache[mipmapPipelineConfig]
+
+	// written by NotifyDeviceLost, which may be called from any goroutine
+	deviceLost atomic.Bool
+	releasing  atomic.Bool
+
+	generation uint32
 }
 
 func New(sd *wgpu.SurfaceDescriptor) (st *Context, err error) {
@@ -65,27 +86,170 @@ func New(sd *wgpu.SurfaceDescriptor) (st *Context, err error) {
 		return
 	}
 
-	// get a Device with the default settings
-	st.Device, err = st.Adapter.RequestDevice(nil)
+	err = st.requestDevice()
+	if err != nil {
+		return
+	}
+
+	return st, nil
+}
+
+// NewHeadless creates a Context that is not bound to any window. The Surface of
+// the returned Context is nil, rendering is only possible into offscreen textures.
+//
+// If no hardware adapter is available, NewHeadless falls back to the software
+// adapter, as if WGPU_FORCE_FALLBACK_ADAPTER=1 was set. This allows rendering on
+// machines without a display or a gpu, e.g. in tests or on a server.
+func NewHeadless() (st *Context, err error) {
+	defer func() {
+		if err != nil && st != nil {
+			st.Release()
+			st = nil
+		}
+	}()
+
+	st = &Context{}
+
+	// create the webgpu instance
+	instance := wgpu.CreateInstance(nil)
+	defer instance.Release()
+
+	// we do not need to be compatible to any surface
+	st.Adapter, err = instance.RequestAdapter(&wgpu.RequestAdapterOptions{
+		ForceFallbackAdapter: forceFallbackAdapter,
+	})
+
+	if err != nil && !forceFallbackAdapter {
+		slog.Warn("No adapter available, trying fallback adapter", slog.String("error", err.Error()))
+
+		st.Adapter, err = instance.RequestAdapter(&wgpu.RequestAdapterOptions{
+			ForceFallbackAdapter: true,
+		})
+	}
+
 	if err != nil {
 		return
 	}
 
-	st.Queue = st.Device.GetQueue()
+	err = st.requestDevice()
+	if err != nil {
+		return
+	}
 
 	return st, nil
 }
 
-func (d *Context) Release() {
+// ReportError passes the error to OnError
+func (d *Context) ReportError(err error) {
+	if isDeviceLostError(err) {
+		d.NotifyDeviceLost(wgpu.DeviceLostReasonUnknown, err.Error())
+	}
+
+	if d.OnError != nil {
+		d.OnError(err)
+		return
+	}
+
+	slog.Error("Unhandled error", slog.Any("err", err))
+}
+
+// Headless returns true, if this Context was created without a Surface.
+func (d *Context) Headless() bool {
+	return d.Surface == nil
+}
+
+// isDeviceLostError checks if the error was caused by a lost device.
+func isDeviceLostError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "device") && strings.Contains(message, "lost")
+}
+
+// DeviceLost returns true if the device was lost and
+// has not yet been recreated.
+func (d *Context) DeviceLost() bool {
+	return d.deviceLost.Load()
+}
+
+// Generation is incremented every time the device is recreated. Long living
+// objects can remember the generation to detect that their gpu resources are stale.
+func (d *Context) Generation() uint32 {
+	return d.generation
+}
+
+// RecreateDevice releases the current device and requests a new one from the same adapter.
+// All resources created using the previous device, like textures, buffers and pipelines,
+// are invalid and must be created again. A View must be configured again.
+func (d *Context) RecreateDevice() error {
+	d.releaseDevice()
+
+	// cached pipelines belong to the previous device
+	d.mipmapPipelines = nil
+
+	if err := d.requestDevice(); err != nil {
+		return err
+	}
+
+	d.deviceLost.Store(false)
+	d.generation += 1
+
+	return nil
+}
+
+func (d *Context) requestDevice() (err error) {
+	// get a Device with the default settings. We can not register a DeviceLostCallback,
+	// as the native binding passes the callback handle to C in go memory,
+	// which fails the cgo pointer checks. See NotifyDeviceLost instead.
+	d.Device, err = d.Adapter.RequestDevice(nil)
+	if err != nil {
+		return
+	}
+
+	d.Queue = d.Device.GetQueue()
+
+	return nil
+}
+
+// NotifyDeviceLost marks the device as lost and calls OnDeviceLost. This is called by
+// pulse if an operation fails due to a lost device, e.g. in View.CurrentTexture
+// or ReportError.
+func (d *Context) NotifyDeviceLost(reason wgpu.DeviceLostReason, message string) {
+	if d.releasing.Load() || reason == wgpu.DeviceLostReasonDestroyed {
+		// we are destroying the device ourselves
+		return
+	}
+
+	if !d.deviceLost.CompareAndSwap(false, true) {
+		// already notified
+		return
+	}
+
+	slog.Error("Device lost", slog.String("reason", reason.String()), slog.String("message", message))
+
+	if d.OnDeviceLost != nil {
+		d.OnDeviceLost(reason, message)
+	}
+}
+
+func (d *Context) releaseDevice() {
+	d.releasing.Store(true)
+	defer d.releasing.Store(false)
+
 	if d.Queue != nil {
 		d.Queue.Release()
 		d.Queue = nil
 	}
 
 	if d.Device != nil {
+		// cached samplers belong to this device
+		purgeCachedSamplers(d.Device)
+
 		d.Device.Release()
 		d.Device = nil
 	}
+}
+
+func (d *Context) Release() {
+	d.releaseDevice()
 
 	if d.Adapter != nil {
 		d.Adapter.Release()
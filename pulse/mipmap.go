@@ -0,0 +1,160 @@
+package pulse
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+//go:embed mipmap.wgsl
+var mipmapShaderCode string
+
+// GenerateMipmaps fills all mip levels of the texture by downsampling the first level.
+// Each level is rendered from the previous one using a linear filter. As the levels
+// are rendered, srgb formats are correctly decoded and encoded.
+//
+// This works on the root texture. The texture must have been created
+// with the TextureUsageRenderAttachment and TextureUsageTextureBinding usages.
+// If the pipeline can not be created, the error is passed to Context.ReportError.
+func (t *Texture) GenerateMipmaps(ctx *Context) {
+	root := t.root
+	if root.mipLevelCount <= 1 {
+		return
+	}
+
+	if ctx.mipmapPipelines == nil {
+		ctx.mipmapPipelines = NewPipelineCache[mipmapPipelineConfig](ctx)
+	}
+
+	pc, err := ctx.mipmapPipelines.TryGet(mipmapPipelineConfig{
+		TargetFormat: root.format,
+	})
+
+	if err != nil {
+		// keep the mip levels as they are and let the context handle the error
+		ctx.ReportError(err)
+		return
+	}
+
+	sampler := CachedSampler(ctx.Device, wgpu.SamplerDescriptor{
+		Label:         "Mipmap-Sampler",
+		AddressModeU:  wgpu.AddressModeClampToEdge,
+		AddressModeV:  wgpu.AddressModeClampToEdge,
+		AddressModeW:  wgpu.AddressModeClampToEdge,
+		MagFilter:     wgpu.FilterModeLinear,
+		MinFilter:     wgpu.FilterModeLinear,
+		MipmapFilter:  wgpu.MipmapFilterModeNearest,
+		LodMaxClamp:   32,
+		MaxAnisotropy: 1,
+	})
+
+	encoder := ctx.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{Label: "GenerateMipmaps"})
+	defer encoder.Release()
+
+	// create one view per mip level
+	views := make([]*wgpu.TextureView, root.mipLevelCount)
+	for level := range views {
+		views[level] = root.texture.CreateView(&wgpu.TextureViewDescriptor{
+			Label:           fmt.Sprintf("Mipmap.Level%d", level),
+			Format:          root.format,
+			Dimension:       wgpu.TextureViewDimension2D,
+			BaseMipLevel:    uint32(level),
+			MipLevelCount:   1,
+			BaseArrayLayer:  0,
+			ArrayLayerCount: 1,
+			Aspect:          wgpu.TextureAspectAll,
+		})
+
+		defer views[level].Release()
+	}
+
+	for level := 1; level < len(views); level++ {
+		// render the current level using the previous one as source
+		bindGroup := ctx.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Label:  "Mipmap BindGroup",
+			Layout: pc.GetBindGroupLayout(0),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding:     0,
+					TextureView: views[level-1],
+				},
+				{
+					Binding: 1,
+					Sampler: sampler,
+				},
+			},
+		})
+
+		defer bindGroup.Release()
+
+		pass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+			Label: "RenderPassMipmap",
+			ColorAttachments: []wgpu.RenderPassColorAttachment{
+				{
+					View:    views[level],
+					LoadOp:  wgpu.LoadOpClear,
+					StoreOp: wgpu.StoreOpStore,
+				},
+			},
+		})
+
+		pass.SetPipeline(pc.Pipeline)
+		pass.SetBindGroup(0, bindGroup, nil)
+		pass.Draw(3, 1, 0, 0)
+		pass.End()
+	}
+
+	cmdBuffer := encoder.Finish(nil)
+	defer cmdBuffer.Release()
+
+	ctx.Submit(cmdBuffer)
+}
+
+type mipmapPipelineConfig struct {
+	TargetFormat wgpu.TextureFormat
+}
+
+func (conf mipmapPipelineConfig) Specialize(dev *wgpu.Device) (*wgpu.RenderPipeline, error) {
+	slog.Info(
+		"Create RenderPipeline for mipmaps",
+		slog.Any("config", conf.TargetFormat),
+	)
+
+	shader, err := CreateShaderModule(dev, "Mipmap.ShaderSource", mipmapShaderCode)
+	if err != nil {
+		return nil, err
+	}
+
+	defer shader.Release()
+
+	return CreateRenderPipeline(dev, &wgpu.RenderPipelineDescriptor{
+		Label: fmt.Sprintf("Mipmap.%s", conf.TargetFormat),
+		Vertex: wgpu.VertexState{
+			Module:     shader,
+			EntryPoint: "vs_main",
+		},
+		Fragment: &wgpu.FragmentState{
+			Module:     shader,
+			EntryPoint: "fs_main",
+			Targets: []wgpu.ColorTargetState{
+				{
+					Format:    conf.TargetFormat,
+					Blend:     &wgpu.BlendStateReplace,
+					WriteMask: wgpu.ColorWriteMaskAll,
+				},
+			},
+		},
+		Primitive: wgpu.PrimitiveState{
+			Topology:  wgpu.PrimitiveTopologyTriangleList,
+			FrontFace: wgpu.FrontFaceCCW,
+			CullMode:  wgpu.CullModeNone,
+		},
+		Multisample: wgpu.MultisampleState{
+			Count:                  1,
+			Mask:                   0xFFFFFFFF,
+			AlphaToCoverageEnabled: false,
+		},
+	})
+}
@@ -0,0 +1,30 @@
+struct VertexOutput {
+    @location(0) uv: vec2f,
+    @builtin(position) position: vec4f,
+};
+
+@group(0)
+@binding(0)
+var source: texture_2d<f32>;
+
+@group(0)
+@binding(1)
+var source_sampler: sampler;
+
+@vertex
+fn vs_main(@builtin(vertex_index) index: u32) -> VertexOutput {
+    // a single triangle covering the full target:
+    // uv (0, 0), (2, 0) and (0, 2)
+    let uv = vec2f(f32((index << 1) & 2), f32(index & 2));
+
+    var result: VertexOutput;
+    result.uv = uv;
+    result.position = vec4f(uv.x * 2.0 - 1.0, 1.0 - uv.y * 2.0, 0.0, 1.0);
+    return result;
+}
+
+@fragment
+fn fs_main(vertex: VertexOutput) -> @location(0) vec4f {
+    // linear filtering averages the four texels of the previous level
+    return textureSample(source, source_sampler, vertex.uv);
+}
@@ -26,38 +26,67 @@ type PipelineConfig interface {
 	comparable
 
 	// Specialize creates a specialized pipeline for the
-	// current PipelineConfig
-	Specialize(def *wgpu.Device) *wgpu.RenderPipeline
+	// current PipelineConfig. Shader compilation and pipeline
+	// validation errors should be returned as *ShaderError.
+	Specialize(def *wgpu.Device) (*wgpu.RenderPipeline, error)
 }
 
 type PipelineCache[C PipelineConfig] struct {
 	device *wgpu.Device
 	cache  *lru.Cache[C, CachedPipeline]
+
+	// configs that failed to specialize. We remember the error
+	// to not compile a broken shader again on every frame.
+	failed *lru.Cache[C, error]
 }
 
 func NewPipelineCache[C PipelineConfig](ctx *Context) *PipelineCache[C] {
 	cache, _ := lru.NewWithEvict[C, CachedPipeline](16, releasePipelineOnEviction[C])
+	failed, _ := lru.New[C, error](16)
 
 	return &PipelineCache[C]{
 		device: ctx.Device,
 		cache:  cache,
+		failed: failed,
 	}
 }
 
+// Get returns the pipeline for the given config. It panics if the
+// pipeline can not be created, see TryGet.
 func (p *PipelineCache[C]) Get(conf C) CachedPipeline {
+	pc, err := p.TryGet(conf)
+	if err != nil {
+		panic(err)
+	}
+
+	return pc
+}
+
+// TryGet returns the pipeline for the given config, specializing it if
+// it is not yet cached. If specialization fails, the error is cached too
+// and returned on the following calls for the same config.
+func (p *PipelineCache[C]) TryGet(conf C) (CachedPipeline, error) {
 	cached, ok := p.cache.Get(conf)
 	if ok {
-		return cached
+		return cached, nil
 	}
 
-	pipeline := conf.Specialize(p.device)
+	if err, ok := p.failed.Get(conf); ok {
+		return CachedPipeline{}, err
+	}
+
+	pipeline, err := conf.Specialize(p.device)
+	if err != nil {
+		p.failed.Add(conf, err)
+		return CachedPipeline{}, err
+	}
 
 	bindGroupsCache, _ := lru.NewWithEvict[uint32, *wgpu.BindGroupLayout](16, releaseBindGroupLayoutOnEviction)
 
 	pc := CachedPipeline{Pipeline: pipeline, bindGroups: bindGroupsCache}
 	p.cache.Add(conf, pc)
 
-	return pc
+	return pc, nil
 }
 
 func releasePipelineOnEviction[C any](_config C, pipe CachedPipeline) {
@@ -0,0 +1,287 @@
+package pulse
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// ReadPixels copies the pixels of the given region back from the gpu into cpu memory.
+// The region is relative to the texture, a sub texture reads only from its own region.
+// For multisample textures, the pixels are read from the resolve target.
+//
+// The result contains the raw texel data in the textures format, tightly packed
+// without any row padding.
+//
+// ReadPixels blocks until the data is available. In the browser, the mapping is
+// awaited on the calling goroutine, so it must not be called from a javascript callback.
+func (t *Texture) ReadPixels(ctx *Context, region Rectangle2u) ([]byte, error) {
+	source := t
+	if source.resolveTarget != nil {
+		// we can not copy from a multisample texture
+		source = source.resolveTarget
+	}
+
+	if region.Max[0] > t.Width() || region.Max[1] > t.Height() {
+		return nil, fmt.Errorf("region %s exceeds texture of size %s", region, t.Size())
+	}
+
+	bytesPerPixel, ok := formatBytesPerPixel(source.format)
+	if !ok {
+		return nil, fmt.Errorf("readback of texture format %s is not supported", source.format)
+	}
+
+	width, height := region.Size().XY()
+	if width == 0 || height == 0 {
+		return nil, nil
+	}
+
+	// a buffer copy requires each row to be aligned
+	bytesPerRow := width * bytesPerPixel
+	bytesPerRowAligned := alignTo(bytesPerRow, wgpu.CopyBytesPerRowAlignment)
+
+	bufSize := uint64(bytesPerRowAligned) * uint64(height)
+
+	buf, err := ctx.TryCreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Texture.Readback",
+		Usage: wgpu.BufferUsageMapRead | wgpu.BufferUsageCopyDst,
+		Size:  bufSize,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("create readback buffer: %w", err)
+	}
+
+	defer buf.Release()
+
+	encoder := ctx.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{Label: "ReadPixels"})
+	defer encoder.Release()
+
+	origin := source.region.Min.Add(region.Min)
+
+	err = encoder.TryCopyTextureToBuffer(
+		&wgpu.TexelCopyTextureInfo{
+			Texture: source.texture,
+			Origin: wgpu.Origin3D{
+				X: origin[0],
+				Y: origin[1],
+			},
+			Aspect: wgpu.TextureAspectAll,
+		},
+		&wgpu.TexelCopyBufferInfo{
+			Buffer: buf,
+			Layout: wgpu.TexelCopyBufferLayout{
+				BytesPerRow:  bytesPerRowAligned,
+				RowsPerImage: height,
+			},
+		},
+		&wgpu.Extent3D{
+			Width:              width,
+			Height:             height,
+			DepthOrArrayLayers: 1,
+		},
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("copy texture to buffer: %w", err)
+	}
+
+	cmdBuffer, err := encoder.TryFinish(nil)
+	if err != nil {
+		return nil, fmt.Errorf("finish command encoder: %w", err)
+	}
+
+	defer cmdBuffer.Release()
+
+	ctx.Submit(cmdBuffer)
+
+	// map the buffer and wait for the mapping to complete
+	mapStatus := make(chan wgpu.MapAsyncStatus, 1)
+
+	err = buf.TryMapAsync(wgpu.MapModeRead, 0, bufSize, func(s wgpu.MapAsyncStatus) {
+		mapStatus <- s
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("map readback buffer: %w", err)
+	}
+
+	if len(mapStatus) == 0 {
+		// the native bindings invoke the callback while polling the device. In the
+		// browser, TryMapAsync already waited for the mapping and Poll does nothing.
+		ctx.Poll(true, nil)
+	}
+
+	status := wgpu.MapAsyncStatusUnknown
+
+	select {
+	case status = <-mapStatus:
+	default:
+	}
+
+	if status != wgpu.MapAsyncStatusSuccess {
+		return nil, fmt.Errorf("map readback buffer: %s", status)
+	}
+
+	defer buf.Unmap()
+
+	mapped := buf.GetMappedRange(0, uint(bufSize))
+
+	// remove the row padding
+	pixels := make([]byte, 0, bytesPerRow*height)
+	for y := range height {
+		offset := y * bytesPerRowAligned
+		pixels = append(pixels, mapped[offset:offset+bytesPerRow]...)
+	}
+
+	return pixels, nil
+}
+
+// ToImage reads the content of the texture back into an image.NRGBA.
+//
+// Texels of a non-srgb texture format are interpreted as linear rgb values and are
+// encoded to srgb, texels of an srgb format are copied as they are.
+// Use ReadPixels if you need access to the raw texel data.
+func (t *Texture) ToImage(ctx *Context) (*image.NRGBA, error) {
+	region := RectangleFromSize(glm.Vec2u{}, t.Size())
+
+	pixels, err := t.ReadPixels(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	format := t.format
+	if t.resolveTarget != nil {
+		format = t.resolveTarget.format
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(t.Width()), int(t.Height())))
+	if err := decodePixels(img.Pix, pixels, format); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+func decodePixels(target, pixels []byte, format wgpu.TextureFormat) error {
+	switch format {
+	case wgpu.TextureFormatRGBA8UnormSrgb:
+		copy(target, pixels)
+
+	case wgpu.TextureFormatBGRA8UnormSrgb:
+		for idx := 0; idx < len(pixels); idx += 4 {
+			target[idx+0] = pixels[idx+2]
+			target[idx+1] = pixels[idx+1]
+			target[idx+2] = pixels[idx+0]
+			target[idx+3] = pixels[idx+3]
+		}
+
+	case wgpu.TextureFormatRGBA8Unorm:
+		for idx := 0; idx < len(pixels); idx += 4 {
+			target[idx+0] = encodeLinear(float32(pixels[idx+0]) / 255)
+			target[idx+1] = encodeLinear(float32(pixels[idx+1]) / 255)
+			target[idx+2] = encodeLinear(float32(pixels[idx+2]) / 255)
+			target[idx+3] = pixels[idx+3]
+		}
+
+	case wgpu.TextureFormatBGRA8Unorm:
+		for idx := 0; idx < len(pixels); idx += 4 {
+			target[idx+0] = encodeLinear(float32(pixels[idx+2]) / 255)
+			target[idx+1] = encodeLinear(float32(pixels[idx+1]) / 255)
+			target[idx+2] = encodeLinear(float32(pixels[idx+0]) / 255)
+			target[idx+3] = pixels[idx+3]
+		}
+
+	case wgpu.TextureFormatRGBA16Float:
+		for idx := 0; idx < len(target); idx += 4 {
+			px := pixels[idx*2:]
+			target[idx+0] = encodeLinear(float16ToFloat32(binary.LittleEndian.Uint16(px[0:])))
+			target[idx+1] = encodeLinear(float16ToFloat32(binary.LittleEndian.Uint16(px[2:])))
+			target[idx+2] = encodeLinear(float16ToFloat32(binary.LittleEndian.Uint16(px[4:])))
+			target[idx+3] = toUnorm8(float16ToFloat32(binary.LittleEndian.Uint16(px[6:])))
+		}
+
+	case wgpu.TextureFormatRGBA32Float:
+		for idx := 0; idx < len(target); idx += 4 {
+			px := pixels[idx*4:]
+			target[idx+0] = encodeLinear(math.Float32frombits(binary.LittleEndian.Uint32(px[0:])))
+			target[idx+1] = encodeLinear(math.Float32frombits(binary.LittleEndian.Uint32(px[4:])))
+			target[idx+2] = encodeLinear(math.Float32frombits(binary.LittleEndian.Uint32(px[8:])))
+			target[idx+3] = toUnorm8(math.Float32frombits(binary.LittleEndian.Uint32(px[12:])))
+		}
+
+	default:
+		return errors.New("conversion of texture format " + format.String() + " is not supported")
+	}
+
+	return nil
+}
+
+func formatBytesPerPixel(format wgpu.TextureFormat) (uint32, bool) {
+	switch format {
+	case wgpu.TextureFormatRGBA8Unorm,
+		wgpu.TextureFormatRGBA8UnormSrgb,
+		wgpu.TextureFormatBGRA8Unorm,
+		wgpu.TextureFormatBGRA8UnormSrgb:
+		return 4, true
+
+	case wgpu.TextureFormatRGBA16Float:
+		return 8, true
+
+	case wgpu.TextureFormatRGBA32Float:
+		return 16, true
+
+	case wgpu.TextureFormatR8Unorm:
+		return 1, true
+
+	default:
+		return 0, false
+	}
+}
+
+func alignTo(value, alignment uint32) uint32 {
+	return (value + alignment - 1) / alignment * alignment
+}
+
+// encodeLinear converts a linear color value into an 8 bit srgb encoded value
+func encodeLinear(value float32) uint8 {
+	return toUnorm8(gamma(value))
+}
+
+func toUnorm8(value float32) uint8 {
+	return uint8(min(1, max(0, value))*255 + 0.5)
+}
+
+// float16ToFloat32 converts an IEEE 754 half precision value to a float32.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0 && mant == 0:
+		// signed zero
+		return math.Float32frombits(sign)
+
+	case exp == 0:
+		// subnormal value, normalize it
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+
+		exp++
+		mant &= 0x3ff
+
+	case exp == 0x1f:
+		// infinity or NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
@@ -64,6 +64,88 @@ func (r Rectangle2[T]) Contains(other Rectangle2[T]) bool {
 		r.Max[0] >= other.Max[0] && r.Max[1] >= other.Max[1]
 }
 
+// ContainsPoint returns true if the point lies within the rectangle. The minimum
+// edges are inclusive, the maximum edges are exclusive.
+func (r Rectangle2[T]) ContainsPoint(point glm.Vec2[T]) bool {
+	return r.Min[0] <= point[0] && point[0] < r.Max[0] &&
+		r.Min[1] <= point[1] && point[1] < r.Max[1]
+}
+
+// Overlaps returns true if both rectangles share an area larger than zero.
+func (r Rectangle2[T]) Overlaps(other Rectangle2[T]) bool {
+	return r.Min[0] < other.Max[0] && other.Min[0] < r.Max[0] &&
+		r.Min[1] < other.Max[1] && other.Min[1] < r.Max[1]
+}
+
+// Intersection returns the area shared by both rectangles. If the rectangles
+// do not overlap, the result is an empty rectangle.
+func (r Rectangle2[T]) Intersection(other Rectangle2[T]) Rectangle2[T] {
+	if !r.Overlaps(other) {
+		return Rectangle2[T]{}
+	}
+
+	return Rectangle2[T]{
+		Min: glm.Vec2[T]{max(r.Min[0], other.Min[0]), max(r.Min[1], other.Min[1])},
+		Max: glm.Vec2[T]{min(r.Max[0], other.Max[0]), min(r.Max[1], other.Max[1])},
+	}
+}
+
+// Empty returns true if the rectangle has no area.
+func (r Rectangle2[T]) Empty() bool {
+	return r.Max[0] <= r.Min[0] || r.Max[1] <= r.Min[1]
+}
+
+// Translate moves the rectangle by the given offset.
+func (r Rectangle2[T]) Translate(offset glm.Vec2[T]) Rectangle2[T] {
+	return Rectangle2[T]{
+		Min: r.Min.Add(offset),
+		Max: r.Max.Add(offset),
+	}
+}
+
+// Inset shrinks the rectangle by the given amount on each side. If the rectangle
+// is too small, it collapses to its center.
+func (r Rectangle2[T]) Inset(amount T) Rectangle2[T] {
+	result := r
+
+	if r.Width() >= 2*amount {
+		result.Min[0] += amount
+		result.Max[0] -= amount
+	} else {
+		result.Min[0] = r.Min[0] + r.Width()/2
+		result.Max[0] = result.Min[0]
+	}
+
+	if r.Height() >= 2*amount {
+		result.Min[1] += amount
+		result.Max[1] -= amount
+	} else {
+		result.Min[1] = r.Min[1] + r.Height()/2
+		result.Max[1] = result.Min[1]
+	}
+
+	return result
+}
+
+// Outset grows the rectangle by the given amount on each side.
+func (r Rectangle2[T]) Outset(amount T) Rectangle2[T] {
+	return Rectangle2[T]{
+		Min: glm.Vec2[T]{r.Min[0] - amount, r.Min[1] - amount},
+		Max: glm.Vec2[T]{r.Max[0] + amount, r.Max[1] + amount},
+	}
+}
+
+// Transform transforms the corners of the rectangle and
+// returns the axis aligned bounding box of the result.
+func (r Rectangle2[T]) Transform(transform glm.Mat3[T]) Rectangle2[T] {
+	a := transform.Transform2(r.Min)
+	b := transform.Transform2(glm.Vec2[T]{r.Max[0], r.Min[1]})
+	c := transform.Transform2(r.Max)
+	d := transform.Transform2(glm.Vec2[T]{r.Min[0], r.Max[1]})
+
+	return RectangleFromPoints(a, b).Extend(c).Extend(d)
+}
+
 func (r Rectangle2[T]) Center() glm.Vec2[T] {
 	return r.Min.Add(r.Max).Div(glm.Vec2[T]{2, 2})
 }
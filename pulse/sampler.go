@@ -5,16 +5,24 @@ import (
 	"github.com/oliverbestmann/webgpu/wgpu"
 )
 
-var samplerCache, _ = lru.NewWithEvict[wgpu.SamplerDescriptor, *wgpu.Sampler](16, samplerCacheOnEvict)
+// samplers are shared between contexts, the key includes the device a sampler was created with
+type samplerCacheKey struct {
+	device *wgpu.Device
+	desc   wgpu.SamplerDescriptor
+}
+
+var samplerCache, _ = lru.NewWithEvict[samplerCacheKey, *wgpu.Sampler](16, samplerCacheOnEvict)
 
-func samplerCacheOnEvict(key wgpu.SamplerDescriptor, value *wgpu.Sampler) {
+func samplerCacheOnEvict(key samplerCacheKey, value *wgpu.Sampler) {
 	value.Release()
 }
 
 // CachedSampler returns a sampler matching your description. The sampler may be cached,
 // you  must not call wgpu.Sampler.Release() on it.
 func CachedSampler(dev *wgpu.Device, desc wgpu.SamplerDescriptor) *wgpu.Sampler {
-	cachedSampler, ok := samplerCache.Get(desc)
+	key := samplerCacheKey{device: dev, desc: desc}
+
+	cachedSampler, ok := samplerCache.Get(key)
 	if ok {
 		return cachedSampler
 	}
@@ -23,7 +31,16 @@ func CachedSampler(dev *wgpu.Device, desc wgpu.SamplerDescriptor) *wgpu.Sampler
 	sampler := dev.CreateSampler(&desc)
 
 	// and cache it for the next access
-	samplerCache.Add(desc, sampler)
+	samplerCache.Add(key, sampler)
 
 	return sampler
 }
+
+// purgeCachedSamplers releases all cached samplers created with the given device.
+func purgeCachedSamplers(dev *wgpu.Device) {
+	for _, key := range samplerCache.Keys() {
+		if key.device == dev {
+			samplerCache.Remove(key)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pulse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// ShaderError is returned if a shader module or a render pipeline
+// could not be created.
+type ShaderError struct {
+	// Label of the shader module or pipeline
+	Label string
+
+	// Position of the error within the WGSL source. Line and Column
+	// are one based and zero if the position is not known.
+	Line   int
+	Column int
+
+	// Message is a short description of the error
+	Message string
+
+	// Details contains the full error as reported by wgpu
+	Details string
+}
+
+func (e *ShaderError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("shader %q, line %d, column %d: %s", e.Label, e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("shader %q: %s", e.Label, e.Message)
+}
+
+// CreateShaderModule compiles the WGSL source into a shader module. A compilation
+// error is returned as a *ShaderError.
+//
+// Errors are only captured by the native backend. In the browser, errors are
+// reported asynchronously to the console.
+func CreateShaderModule(dev *wgpu.Device, label string, source string) (*wgpu.ShaderModule, error) {
+	shader, err := dev.TryCreateShaderModule(&wgpu.ShaderModuleDescriptor{
+		Label:      label,
+		WGSLSource: &wgpu.ShaderSourceWGSL{Code: source},
+	})
+
+	if err != nil {
+		return nil, newShaderError(label, err)
+	}
+
+	return shader, nil
+}
+
+// CreateRenderPipeline creates the render pipeline and returns
+// any validation error as a *ShaderError.
+func CreateRenderPipeline(dev *wgpu.Device, desc *wgpu.RenderPipelineDescriptor) (*wgpu.RenderPipeline, error) {
+	pipeline, err := dev.TryCreateRenderPipeline(desc)
+	if err != nil {
+		return nil, newShaderError(desc.Label, err)
+	}
+
+	return pipeline, nil
+}
+
+// matches the source location in a wgsl diagnostic, e.g. "┌─ wgsl:12:5"
+var reShaderLocation = regexp.MustCompile(`wgsl:(\d+):(\d+)`)
+
+func newShaderError(label string, err error) *ShaderError {
+	details := err.Error()
+
+	// unwrap the context added by wgpu
+	if wgpuErr, ok := err.(wgpu.Error); ok && wgpuErr.Wrapped != nil {
+		details = wgpuErr.Wrapped.Error()
+	}
+
+	shaderErr := &ShaderError{
+		Label:   label,
+		Message: shaderErrorMessage(details),
+		Details: details,
+	}
+
+	if match := reShaderLocation.FindStringSubmatch(details); match != nil {
+		shaderErr.Line, _ = strconv.Atoi(match[1])
+		shaderErr.Column, _ = strconv.Atoi(match[2])
+	}
+
+	return shaderErr
+}
+
+// shaderErrorMessage picks the most descriptive line of the diagnostic.
+func shaderErrorMessage(details string) string {
+	var fallback string
+
+	for line := range strings.Lines(details) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// naga reports errors as "Shader 'label' parsing error: ..."
+		if idx := strings.Index(line, "error: "); idx >= 0 {
+			return line[idx+len("error: "):]
+		}
+
+		// skip headers like "Validation Error" and "Caused by:"
+		if fallback == "" && !strings.HasSuffix(line, ":") && !strings.HasSuffix(line, "Error") {
+			fallback = line
+		}
+	}
+
+	if fallback == "" {
+		return strings.TrimSpace(details)
+	}
+
+	return fallback
+}
@@ -0,0 +1,132 @@
+package pulse
+
+import (
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+)
+
+// skylineNode is a horizontal segment of the skyline. All space
+// below the segment is considered to be allocated.
+type skylineNode struct {
+	x, y, width uint32
+}
+
+// skylinePacker implements the bottom-left variant of the skyline
+// rectangle packing algorithm.
+type skylinePacker struct {
+	width, height uint32
+	nodes         []skylineNode
+}
+
+func newSkylinePacker(width, height uint32) *skylinePacker {
+	return &skylinePacker{
+		width:  width,
+		height: height,
+		nodes:  []skylineNode{{x: 0, y: 0, width: width}},
+	}
+}
+
+// Allocate reserves a rectangle of the given size. Returns false
+// if there is not enough space left.
+func (p *skylinePacker) Allocate(size glm.Vec2u) (glm.Vec2u, bool) {
+	width, height := size.XY()
+
+	bestIdx := -1
+	bestY := uint32(math.MaxUint32)
+	bestWidth := uint32(math.MaxUint32)
+
+	for idx := range p.nodes {
+		y, ok := p.fits(idx, width, height)
+		if !ok {
+			continue
+		}
+
+		// prefer the lowest position, then the narrowest segment
+		if y < bestY || (y == bestY && p.nodes[idx].width < bestWidth) {
+			bestIdx = idx
+			bestY = y
+			bestWidth = p.nodes[idx].width
+		}
+	}
+
+	if bestIdx < 0 {
+		return glm.Vec2u{}, false
+	}
+
+	pos := glm.Vec2u{p.nodes[bestIdx].x, bestY}
+	p.insert(bestIdx, pos, size)
+
+	return pos, true
+}
+
+// fits checks if a rectangle fits at the start of the node with the given index and
+// returns the y position the rectangle would be placed at.
+func (p *skylinePacker) fits(idx int, width, height uint32) (uint32, bool) {
+	x := p.nodes[idx].x
+	if x+width > p.width {
+		return 0, false
+	}
+
+	var y uint32
+
+	remaining := int64(width)
+	for remaining > 0 {
+		if idx >= len(p.nodes) {
+			return 0, false
+		}
+
+		y = max(y, p.nodes[idx].y)
+		if y+height > p.height {
+			return 0, false
+		}
+
+		remaining -= int64(p.nodes[idx].width)
+		idx++
+	}
+
+	return y, true
+}
+
+func (p *skylinePacker) insert(idx int, pos glm.Vec2u, size glm.Vec2u) {
+	node := skylineNode{
+		x:     pos[0],
+		y:     pos[1] + size[1],
+		width: size[0],
+	}
+
+	// insert the new node at idx
+	p.nodes = append(p.nodes, skylineNode{})
+	copy(p.nodes[idx+1:], p.nodes[idx:])
+	p.nodes[idx] = node
+
+	// shrink or remove the nodes now covered by the new node
+	for idx+1 < len(p.nodes) {
+		next := &p.nodes[idx+1]
+
+		end := node.x + node.width
+		if next.x >= end {
+			break
+		}
+
+		shrink := end - next.x
+		if shrink < next.width {
+			next.x += shrink
+			next.width -= shrink
+			break
+		}
+
+		p.nodes = append(p.nodes[:idx+1], p.nodes[idx+2:]...)
+	}
+
+	// merge neighbouring nodes on the same height
+	for i := 0; i+1 < len(p.nodes); {
+		if p.nodes[i].y == p.nodes[i+1].y {
+			p.nodes[i].width += p.nodes[i+1].width
+			p.nodes = append(p.nodes[:i+1], p.nodes[i+2:]...)
+			continue
+		}
+
+		i++
+	}
+}
@@ -0,0 +1,127 @@
+package pulse
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/oliverbestmann/pulse/glm"
+)
+
+func TestSkylinePacker(t *testing.T) {
+	type allocation struct {
+		size glm.Vec2u
+		pos  glm.Vec2u
+		ok   bool
+	}
+
+	cases := []struct {
+		name        string
+		size        glm.Vec2u
+		allocations []allocation
+	}{
+		{
+			name: "lowest position wins",
+			size: glm.Vec2u{10, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{4, 3}, pos: glm.Vec2u{0, 0}, ok: true},
+				{size: glm.Vec2u{4, 2}, pos: glm.Vec2u{4, 0}, ok: true},
+				{size: glm.Vec2u{2, 5}, pos: glm.Vec2u{8, 0}, ok: true},
+				{size: glm.Vec2u{6, 1}, pos: glm.Vec2u{0, 3}, ok: true},
+			},
+		},
+		{
+			name: "narrowest segment wins on the same height",
+			size: glm.Vec2u{6, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{3, 1}, pos: glm.Vec2u{0, 0}, ok: true},
+				{size: glm.Vec2u{1, 2}, pos: glm.Vec2u{3, 0}, ok: true},
+				{size: glm.Vec2u{2, 1}, pos: glm.Vec2u{4, 0}, ok: true},
+				{size: glm.Vec2u{2, 1}, pos: glm.Vec2u{4, 1}, ok: true},
+			},
+		},
+		{
+			name: "segments on the same height are merged",
+			size: glm.Vec2u{10, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{3, 2}, pos: glm.Vec2u{0, 0}, ok: true},
+				{size: glm.Vec2u{7, 2}, pos: glm.Vec2u{3, 0}, ok: true},
+				{size: glm.Vec2u{10, 8}, pos: glm.Vec2u{0, 2}, ok: true},
+			},
+		},
+		{
+			name: "exact fit fills the packer",
+			size: glm.Vec2u{10, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{10, 10}, pos: glm.Vec2u{0, 0}, ok: true},
+				{size: glm.Vec2u{1, 1}},
+			},
+		},
+		{
+			name: "too wide",
+			size: glm.Vec2u{10, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{11, 1}},
+				{size: glm.Vec2u{10, 1}, pos: glm.Vec2u{0, 0}, ok: true},
+			},
+		},
+		{
+			name: "too high",
+			size: glm.Vec2u{10, 10},
+			allocations: []allocation{
+				{size: glm.Vec2u{5, 6}, pos: glm.Vec2u{0, 0}, ok: true},
+				{size: glm.Vec2u{6, 6}},
+				{size: glm.Vec2u{5, 6}, pos: glm.Vec2u{5, 0}, ok: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			packer := newSkylinePacker(tc.size.XY())
+
+			for idx, alloc := range tc.allocations {
+				pos, ok := packer.Allocate(alloc.size)
+
+				if ok != alloc.ok || (ok && pos != alloc.pos) {
+					t.Fatalf("allocation %d of %v: expected %v %v, got %v %v",
+						idx, alloc.size, alloc.pos, alloc.ok, pos, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestSkylinePackerDoesNotOverlap(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	packer := newSkylinePacker(256, 256)
+
+	var allocated []Rectangle2u
+
+	for range 1000 {
+		size := glm.Vec2u{1 + rng.Uint32N(32), 1 + rng.Uint32N(32)}
+
+		pos, ok := packer.Allocate(size)
+		if !ok {
+			continue
+		}
+
+		rect := Rectangle2u{Min: pos, Max: pos.Add(size)}
+
+		if rect.Max[0] > 256 || rect.Max[1] > 256 {
+			t.Fatalf("%v exceeds the packer", rect)
+		}
+
+		for _, other := range allocated {
+			if rect.Overlaps(other) {
+				t.Fatalf("%v overlaps %v", rect, other)
+			}
+		}
+
+		allocated = append(allocated, rect)
+	}
+
+	if len(allocated) < 50 {
+		t.Errorf("expected at least 50 allocations, got %d", len(allocated))
+	}
+}
@@ -0,0 +1,213 @@
+package pulse
+
+import (
+	"iter"
+	"math"
+
+	"github.com/oliverbestmann/pulse/glm"
+)
+
+// SpatialIndex is a spatial hash that maps keys to their bounding rectangles. Space is
+// divided into square cells, each entry is stored in all cells its bounds touch. Use it
+// as a broad phase to quickly find the entries within a region, e.g. for culling.
+//
+// The cell size should be in the order of magnitude of the typical entry size.
+type SpatialIndex[K comparable] struct {
+	cellSize float32
+	cells    map[cellKey][]K
+	entries  map[K]spatialEntry
+}
+
+type cellKey struct {
+	x, y int32
+}
+
+// cellRange is an inclusive range of cells
+type cellRange struct {
+	minX, minY int32
+	maxX, maxY int32
+}
+
+type spatialEntry struct {
+	bounds Rectangle2f
+	cells  cellRange
+}
+
+func NewSpatialIndex[K comparable](cellSize float32) *SpatialIndex[K] {
+	if cellSize <= 0 {
+		panic("cell size must be positive")
+	}
+
+	return &SpatialIndex[K]{
+		cellSize: cellSize,
+		cells:    map[cellKey][]K{},
+		entries:  map[K]spatialEntry{},
+	}
+}
+
+// Len returns the number of entries in the index.
+func (s *SpatialIndex[K]) Len() int {
+	return len(s.entries)
+}
+
+// Insert adds the key with the given bounds. If the key is already
+// in the index, it is moved to the new bounds.
+func (s *SpatialIndex[K]) Insert(key K, bounds Rectangle2f) {
+	if _, ok := s.entries[key]; ok {
+		s.Move(key, bounds)
+		return
+	}
+
+	cells := s.cellRangeOf(bounds)
+	s.entries[key] = spatialEntry{bounds: bounds, cells: cells}
+
+	for cell := range cells.all() {
+		s.cells[cell] = append(s.cells[cell], key)
+	}
+}
+
+// Move updates the bounds of the key. Returns false if the key is not in the index.
+func (s *SpatialIndex[K]) Move(key K, bounds Rectangle2f) bool {
+	entry, ok := s.entries[key]
+	if !ok {
+		return false
+	}
+
+	cells := s.cellRangeOf(bounds)
+	if cells == entry.cells {
+		// still in the same cells, only update the bounds
+		s.entries[key] = spatialEntry{bounds: bounds, cells: cells}
+		return true
+	}
+
+	s.Remove(key)
+	s.Insert(key, bounds)
+
+	return true
+}
+
+// Remove removes the key from the index. Returns false if the key was not in the index.
+func (s *SpatialIndex[K]) Remove(key K) bool {
+	entry, ok := s.entries[key]
+	if !ok {
+		return false
+	}
+
+	delete(s.entries, key)
+
+	for cell := range entry.cells.all() {
+		keys := s.cells[cell]
+
+		for idx := range keys {
+			if keys[idx] == key {
+				// swap remove, order within a cell does not matter
+				keys[idx] = keys[len(keys)-1]
+				keys = keys[:len(keys)-1]
+				break
+			}
+		}
+
+		if len(keys) == 0 {
+			delete(s.cells, cell)
+		} else {
+			s.cells[cell] = keys
+		}
+	}
+
+	return true
+}
+
+// Bounds returns the bounds of the key.
+func (s *SpatialIndex[K]) Bounds(key K) (Rectangle2f, bool) {
+	entry, ok := s.entries[key]
+	return entry.bounds, ok
+}
+
+// Clear removes all entries.
+func (s *SpatialIndex[K]) Clear() {
+	clear(s.cells)
+	clear(s.entries)
+}
+
+// QueryRect yields all keys whose bounds intersect or touch the region. Each key is
+// yielded exactly once. The index must not be modified while iterating.
+func (s *SpatialIndex[K]) QueryRect(region Rectangle2f) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		query := s.cellRangeOf(region)
+
+		// for large regions it is cheaper to just test every entry
+		if query.count() > int64(len(s.cells)) {
+			for key, entry := range s.entries {
+				if touches(entry.bounds, region) && !yield(key) {
+					return
+				}
+			}
+
+			return
+		}
+
+		for cell := range query.all() {
+			for _, key := range s.cells[cell] {
+				entry := s.entries[key]
+
+				// an entry spanning multiple cells is only reported in the
+				// first cell that is shared by the entry and the query
+				first := cellKey{
+					x: max(entry.cells.minX, query.minX),
+					y: max(entry.cells.minY, query.minY),
+				}
+
+				if cell != first {
+					continue
+				}
+
+				if touches(entry.bounds, region) && !yield(key) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// QueryPoint yields all keys whose bounds contain the point,
+// including the edges. The index must not be modified while iterating.
+func (s *SpatialIndex[K]) QueryPoint(point glm.Vec2f) iter.Seq[K] {
+	return s.QueryRect(Rectangle2f{Min: point, Max: point})
+}
+
+func (s *SpatialIndex[K]) cellRangeOf(bounds Rectangle2f) cellRange {
+	return cellRange{
+		minX: s.cellOf(bounds.Min[0]),
+		minY: s.cellOf(bounds.Min[1]),
+		maxX: s.cellOf(bounds.Max[0]),
+		maxY: s.cellOf(bounds.Max[1]),
+	}
+}
+
+func (s *SpatialIndex[K]) cellOf(value float32) int32 {
+	cell := math.Floor(float64(value / s.cellSize))
+	return int32(min(max(cell, math.MinInt32), math.MaxInt32))
+}
+
+func (r cellRange) count() int64 {
+	return (int64(r.maxX) - int64(r.minX) + 1) * (int64(r.maxY) - int64(r.minY) + 1)
+}
+
+func (r cellRange) all() iter.Seq[cellKey] {
+	return func(yield func(cellKey) bool) {
+		for y := int64(r.minY); y <= int64(r.maxY); y++ {
+			for x := int64(r.minX); x <= int64(r.maxX); x++ {
+				if !yield(cellKey{x: int32(x), y: int32(y)}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// touches is like Rectangle2.Overlaps, but also true for
+// rectangles that only share an edge or have no area.
+func touches(a, b Rectangle2f) bool {
+	return a.Min[0] <= b.Max[0] && b.Min[0] <= a.Max[0] &&
+		a.Min[1] <= b.Max[1] && b.Min[1] <= a.Max[1]
+}
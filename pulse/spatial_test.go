@@ -0,0 +1,193 @@
+package pulse
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/oliverbestmann/pulse/glm"
+)
+
+func rect(minX, minY, maxX, maxY float32) Rectangle2f {
+	return Rectangle2f{Min: glm.Vec2f{minX, minY}, Max: glm.Vec2f{maxX, maxY}}
+}
+
+func TestSpatialIndexQuery(t *testing.T) {
+	index := NewSpatialIndex[string](10)
+	index.Insert("small", rect(0, 0, 5, 5))
+	index.Insert("large", rect(8, 8, 25, 25))
+	index.Insert("negative", rect(-15, -15, -5, -5))
+	index.Insert("point", rect(30, 0, 30, 0))
+
+	cases := []struct {
+		name     string
+		query    Rectangle2f
+		expected []string
+	}{
+		{name: "single cell", query: rect(0, 0, 1, 1), expected: []string{"small"}},
+		{name: "entry spanning multiple cells", query: rect(12, 12, 30, 30), expected: []string{"large"}},
+		{name: "touching edges", query: rect(5, 5, 8, 8), expected: []string{"large", "small"}},
+		{name: "same cell without overlap", query: rect(6, 0, 7, 1)},
+		{name: "negative coordinates", query: rect(-6, -6, -6, -6), expected: []string{"negative"}},
+		{name: "entry without area", query: rect(25, -5, 35, 5), expected: []string{"point"}},
+		{name: "large region", query: rect(-1000, -1000, 1000, 1000), expected: []string{"large", "negative", "point", "small"}},
+		{name: "empty region", query: rect(40, 40, 50, 50)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// keys must be yielded exactly once, sorting keeps duplicates
+			keys := slices.Sorted(index.QueryRect(tc.query))
+
+			if !slices.Equal(keys, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestSpatialIndexQueryPoint(t *testing.T) {
+	index := NewSpatialIndex[int](4)
+	index.Insert(1, rect(0, 0, 10, 10))
+	index.Insert(2, rect(10, 10, 20, 20))
+
+	cases := []struct {
+		name     string
+		point    glm.Vec2f
+		expected []int
+	}{
+		{name: "inside", point: glm.Vec2f{5, 5}, expected: []int{1}},
+		{name: "on a shared corner", point: glm.Vec2f{10, 10}, expected: []int{1, 2}},
+		{name: "outside", point: glm.Vec2f{15, 5}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if keys := slices.Sorted(index.QueryPoint(tc.point)); !slices.Equal(keys, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestSpatialIndexUpdates(t *testing.T) {
+	index := NewSpatialIndex[string](10)
+
+	index.Insert("a", rect(0, 0, 5, 5))
+	index.Insert("b", rect(0, 0, 5, 5))
+
+	// inserting an existing key moves it
+	index.Insert("a", rect(50, 50, 55, 55))
+
+	if index.Len() != 2 {
+		t.Fatalf("expected two entries, got %d", index.Len())
+	}
+
+	if bounds, ok := index.Bounds("a"); !ok || bounds != rect(50, 50, 55, 55) {
+		t.Errorf("unexpected bounds %v", bounds)
+	}
+
+	if keys := slices.Sorted(index.QueryRect(rect(0, 0, 5, 5))); !slices.Equal(keys, []string{"b"}) {
+		t.Errorf("expected only b at the old position, got %v", keys)
+	}
+
+	// moving within the same cells only updates the bounds
+	if !index.Move("b", rect(6, 6, 9, 9)) {
+		t.Fatal("expected b to be moved")
+	}
+
+	if keys := slices.Sorted(index.QueryRect(rect(0, 0, 5, 5))); len(keys) != 0 {
+		t.Errorf("expected no keys at the old position of b, got %v", keys)
+	}
+
+	if keys := slices.Sorted(index.QueryPoint(glm.Vec2f{7, 7})); !slices.Equal(keys, []string{"b"}) {
+		t.Errorf("expected b at its new position, got %v", keys)
+	}
+
+	if index.Move("missing", rect(0, 0, 1, 1)) {
+		t.Error("expected moving a missing key to fail")
+	}
+
+	if !index.Remove("a") || index.Remove("a") {
+		t.Error("expected a to be removed exactly once")
+	}
+
+	if _, ok := index.Bounds("a"); ok {
+		t.Error("expected no bounds for a removed key")
+	}
+
+	if keys := slices.Sorted(index.QueryRect(rect(-100, -100, 100, 100))); !slices.Equal(keys, []string{"b"}) {
+		t.Errorf("expected only b to remain, got %v", keys)
+	}
+
+	index.Clear()
+
+	if index.Len() != 0 || len(slices.Collect(index.QueryPoint(glm.Vec2f{7, 7}))) != 0 {
+		t.Error("expected an empty index after Clear")
+	}
+}
+
+func TestSpatialIndexStopsIteration(t *testing.T) {
+	index := NewSpatialIndex[int](1)
+	for key := range 10 {
+		index.Insert(key, rect(0, 0, 5, 5))
+	}
+
+	for _, query := range []Rectangle2f{rect(1, 1, 2, 2), rect(-100, -100, 100, 100)} {
+		var count int
+		for range index.QueryRect(query) {
+			count++
+			break
+		}
+
+		if count != 1 {
+			t.Errorf("expected iteration to stop after the first key, got %d keys", count)
+		}
+	}
+}
+
+func TestSpatialIndexMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	randomRect := func(maxSize float32) Rectangle2f {
+		pos := glm.Vec2f{rng.Float32()*200 - 100, rng.Float32()*200 - 100}
+		size := glm.Vec2f{rng.Float32() * maxSize, rng.Float32() * maxSize}
+		return Rectangle2f{Min: pos, Max: pos.Add(size)}
+	}
+
+	index := NewSpatialIndex[int](8)
+	bounds := map[int]Rectangle2f{}
+
+	for key := range 500 {
+		bounds[key] = randomRect(20)
+		index.Insert(key, bounds[key])
+	}
+
+	// move and remove some of the entries
+	for key := range 100 {
+		bounds[key] = randomRect(20)
+		index.Move(key, bounds[key])
+	}
+
+	for key := 100; key < 150; key++ {
+		delete(bounds, key)
+		index.Remove(key)
+	}
+
+	for range 200 {
+		query := randomRect(60)
+
+		var expected []int
+		for key, entry := range bounds {
+			if touches(entry, query) {
+				expected = append(expected, key)
+			}
+		}
+
+		slices.Sort(expected)
+
+		if keys := slices.Sorted(index.QueryRect(query)); !slices.Equal(keys, expected) {
+			t.Fatalf("query %v: expected %v, got %v", query, expected, keys)
+		}
+	}
+}
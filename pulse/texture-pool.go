@@ -0,0 +1,198 @@
+package pulse
+
+import (
+	"github.com/oliverbestmann/webgpu/wgpu"
+)
+
+// number of frames an unused texture is kept in the pool before it is released
+const texturePoolMaxIdleFrames = 3
+
+// TexturePool hands out temporary textures that are only valid for the current frame.
+// Textures are recycled at the end of the frame, so effects that need an intermediate
+// render target every frame do not allocate new textures all the time.
+//
+// Textures are matched by their descriptor, that is size, format, sample count,
+// mip levels and usage.
+type TexturePool struct {
+	ctx *Context
+
+	// OnRecycle is called for each texture given back to the pool, e.g. to
+	// drop state that was associated with the texture by the previous user.
+	OnRecycle func(texture *Texture)
+
+	free  map[wgpu.TextureDescriptor][]pooledTexture
+	inUse map[*Texture]wgpu.TextureDescriptor
+
+	frame uint64
+	stats TexturePoolStats
+}
+
+type pooledTexture struct {
+	texture *Texture
+
+	// frame the texture was last returned to the pool
+	lastUsed uint64
+}
+
+type TexturePoolStats struct {
+	// Number of textures owned by the pool, including the ones in use
+	Textures int
+
+	// Number of textures currently handed out
+	InUse int
+
+	// Estimated gpu memory used by all textures of the pool
+	Bytes uint64
+
+	// Number of textures allocated since the pool was created
+	Allocations uint64
+}
+
+func NewTexturePool(ctx *Context) *TexturePool {
+	return &TexturePool{
+		ctx:   ctx,
+		free:  map[wgpu.TextureDescriptor][]pooledTexture{},
+		inUse: map[*Texture]wgpu.TextureDescriptor{},
+	}
+}
+
+// Acquire returns a texture as it would be created by NewTexture. The texture
+// must not be released and must not be used after EndFrame was called.
+func (p *TexturePool) Acquire(opts NewTextureOptions) *Texture {
+	return p.AcquireFromDesc(*newTextureDescriptor(p.ctx, opts))
+}
+
+// AcquireFromDesc returns a texture matching the descriptor, see Acquire.
+// The label of the descriptor is ignored.
+func (p *TexturePool) AcquireFromDesc(desc wgpu.TextureDescriptor) *Texture {
+	desc.Label = "PooledTexture"
+
+	var texture *Texture
+
+	if free := p.free[desc]; len(free) > 0 {
+		// take the most recently used texture
+		texture = free[len(free)-1].texture
+		p.free[desc] = free[:len(free)-1]
+	} else {
+		texture = NewTextureFromDesc(p.ctx, &desc)
+
+		p.stats.Textures += 1
+		p.stats.Bytes += textureSizeInBytes(desc)
+		p.stats.Allocations += 1
+	}
+
+	p.inUse[texture] = desc
+	p.stats.InUse = len(p.inUse)
+
+	return texture
+}
+
+// Recycle gives the texture back to the pool before the end of the frame. Draw calls
+// that have been submitted before still work as expected, but the texture
+// must not be used by the caller afterward.
+//
+// The clip of the texture is reset and its depth texture is released, the next
+// user gets the texture as if it was newly created.
+func (p *TexturePool) Recycle(texture *Texture) {
+	desc, ok := p.inUse[texture]
+	if !ok {
+		panic("texture was not acquired from this pool")
+	}
+
+	delete(p.inUse, texture)
+
+	texture.SetClip(Clip{})
+
+	if texture.depth != nil {
+		texture.depth.Release()
+		texture.depth = nil
+	}
+
+	if p.OnRecycle != nil {
+		p.OnRecycle(texture)
+	}
+	p.stats.InUse = len(p.inUse)
+
+	p.free[desc] = append(p.free[desc], pooledTexture{texture: texture, lastUsed: p.frame})
+}
+
+// EndFrame recycles all textures handed out during the current frame and
+// releases textures that have not been used for a few frames.
+func (p *TexturePool) EndFrame() {
+	for texture := range p.inUse {
+		p.Recycle(texture)
+	}
+
+	for desc, free := range p.free {
+		// textures are ordered by their last use, oldest first
+		var expired int
+		for expired < len(free) && p.frame-free[expired].lastUsed >= texturePoolMaxIdleFrames {
+			free[expired].texture.Release()
+
+			p.stats.Textures -= 1
+			p.stats.Bytes -= textureSizeInBytes(desc)
+
+			expired++
+		}
+
+		if expired == len(free) {
+			delete(p.free, desc)
+		} else {
+			p.free[desc] = free[expired:]
+		}
+	}
+
+	p.frame += 1
+}
+
+// Stats returns the current memory statistics of the pool.
+func (p *TexturePool) Stats() TexturePoolStats {
+	return p.stats
+}
+
+// Release releases all textures of the pool, including the ones in use.
+func (p *TexturePool) Release() {
+	for texture := range p.inUse {
+		texture.Release()
+	}
+
+	for _, free := range p.free {
+		for _, pooled := range free {
+			pooled.texture.Release()
+		}
+	}
+
+	clear(p.inUse)
+	clear(p.free)
+
+	p.stats.Textures = 0
+	p.stats.InUse = 0
+	p.stats.Bytes = 0
+}
+
+// textureSizeInBytes estimates the memory required by a texture
+// created with NewTextureFromDesc, including the resolve target.
+func textureSizeInBytes(desc wgpu.TextureDescriptor) uint64 {
+	bytesPerPixel, ok := formatBytesPerPixel(desc.Format)
+	if !ok {
+		// a good guess for most formats
+		bytesPerPixel = 4
+	}
+
+	var size uint64
+
+	width, height := uint64(desc.Size.Width), uint64(desc.Size.Height)
+	for range max(1, desc.MipLevelCount) {
+		size += width * height * uint64(bytesPerPixel)
+
+		width = max(1, width/2)
+		height = max(1, height/2)
+	}
+
+	if desc.SampleCount > 1 {
+		// the multisample texture plus the resolve target
+		size *= uint64(desc.SampleCount) + 1
+	}
+
+	return size
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"image"
 	"image/draw"
+	"math/bits"
 
 	"github.com/oliverbestmann/pulse/glm"
 	"github.com/oliverbestmann/webgpu/wgpu"
@@ -20,14 +21,27 @@ type Texture struct {
 	texture     *wgpu.Texture
 	textureView *wgpu.TextureView
 
+	// view of the first mip level to render into. Only
+	// set if the texture has more than one mip level.
+	renderView *wgpu.TextureView
+
 	resolveTarget *Texture
 
+	// depth texture attached to a root texture, see DepthTexture
+	depth *Texture
+
+	// clip of a root texture, see SetClip
+	clip Clip
+
 	// equal to texture.GetFormat()
 	format wgpu.TextureFormat
 
 	// equal to texture.GetSampleCount()
 	sampleCount uint32
 
+	// equal to texture.GetMipLevelCount()
+	mipLevelCount uint32
+
 	// sub texture
 	region Rectangle2u
 }
@@ -39,9 +53,25 @@ type NewTextureOptions struct {
 
 	MSAA  bool
 	Label string
+
+	// Number of mip levels. Zero or one disables mipmapping. Use MaxMipLevels
+	// to get the number of levels for a full mip chain. Mipmapping is not supported
+	// for multisample textures.
+	MipLevels uint32
+}
+
+// MaxMipLevels returns the number of mip levels of a full
+// mip chain for a texture of the given size.
+func MaxMipLevels(width, height uint32) uint32 {
+	return uint32(bits.Len32(max(width, height, 1)))
 }
 
 func NewTexture(ctx *Context, opts NewTextureOptions) *Texture {
+	return NewTextureFromDesc(ctx, newTextureDescriptor(ctx, opts))
+}
+
+// newTextureDescriptor creates the descriptor used by NewTexture
+func newTextureDescriptor(ctx *Context, opts NewTextureOptions) *wgpu.TextureDescriptor {
 	var sampleCount uint32 = 1
 
 	if isOpenGL(ctx) {
@@ -53,13 +83,16 @@ func NewTexture(ctx *Context, opts NewTextureOptions) *Texture {
 
 	if opts.MSAA {
 		sampleCount = 4
+
+		// multisample textures can only have one mip level
+		opts.MipLevels = 1
 	}
 
 	desc := &wgpu.TextureDescriptor{
 		Label:         opts.Label,
 		Format:        opts.Format,
 		SampleCount:   sampleCount,
-		MipLevelCount: 1,
+		MipLevelCount: max(1, opts.MipLevels),
 
 		Dimension: wgpu.TextureDimension2D,
 		Size: wgpu.Extent3D{
@@ -75,7 +108,7 @@ func NewTexture(ctx *Context, opts NewTextureOptions) *Texture {
 			wgpu.TextureUsageCopySrc,
 	}
 
-	return NewTextureFromDesc(ctx, desc)
+	return desc
 }
 
 // NewTextureFromDesc gives you full control and creates a texture directly from
@@ -104,14 +137,32 @@ func NewTextureFromDesc(ctx *Context, desc *wgpu.TextureDescriptor) *Texture {
 		},
 	)
 
+	var renderView *wgpu.TextureView
+
+	if desc.MipLevelCount > 1 {
+		// a render attachment must only contain a single mip level
+		renderView = texture.CreateView(&wgpu.TextureViewDescriptor{
+			Label:           "RenderView",
+			Format:          desc.Format,
+			Dimension:       wgpu.TextureViewDimension2D,
+			BaseMipLevel:    0,
+			MipLevelCount:   1,
+			BaseArrayLayer:  0,
+			ArrayLayerCount: 1,
+			Aspect:          wgpu.TextureAspectAll,
+		})
+	}
+
 	t := &Texture{
 		texture:       texture,
 		textureView:   textureView,
+		renderView:    renderView,
 		resolveTarget: resolveTarget,
 
-		format:      desc.Format,
-		region:      region,
-		sampleCount: desc.SampleCount,
+		format:        desc.Format,
+		region:        region,
+		sampleCount:   desc.SampleCount,
+		mipLevelCount: max(1, desc.MipLevelCount),
 	}
 
 	// texture itself is the root
@@ -182,6 +233,7 @@ func WrapTexture(texture *wgpu.Texture, opts WrapTextureOptions) *Texture {
 		resolveTarget: opts.ResolveTarget,
 		format:        textureViewFormat,
 		sampleCount:   texture.GetSampleCount(),
+		mipLevelCount: 1,
 		region:        region,
 	}
 
@@ -193,10 +245,10 @@ func WrapTexture(texture *wgpu.Texture, opts WrapTextureOptions) *Texture {
 func (t *Texture) SubTexture(pos glm.Vec2u, size glm.Vec2u) *Texture {
 	sub := *t
 
-	pos = t.region.Min.Add(pos)
-	sub.region = RectangleFromSize(pos, size)
+	sub.region = RectangleFromSize(t.region.Min.Add(pos), size)
 
 	if sub.resolveTarget != nil {
+		// pos is relative to the resolve target too
 		sub.resolveTarget = sub.resolveTarget.SubTexture(pos, size)
 	}
 
@@ -242,6 +294,11 @@ func (t *Texture) SampleCount() uint32 {
 	return t.sampleCount
 }
 
+// MipLevelCount returns the number of mip levels of this texture.
+func (t *Texture) MipLevelCount() uint32 {
+	return t.mipLevelCount
+}
+
 func (t *Texture) ToWGPUTexture() *wgpu.Texture {
 	return t.texture
 }
@@ -268,14 +325,78 @@ func (t *Texture) SourceView() *wgpu.TextureView {
 // garbage collector handle cleanup.
 func (t *Texture) Release() {
 	if t.root == t {
+		if t.renderView != nil {
+			t.renderView.Release()
+		}
+
+		if t.depth != nil {
+			t.depth.Release()
+			t.depth = nil
+		}
+
 		t.textureView.Release()
 		t.texture.Release()
 	}
 }
 
+// HasDepthTexture returns true, if a depth texture was attached to the root
+// of this texture using DepthTexture.
+func (t *Texture) HasDepthTexture() bool {
+	return t.root.depth != nil
+}
+
+// DepthTexture returns the depth texture attached to the root of this texture. The
+// depth texture is created on first use with the size and sample count of the root
+// texture and the format DepthTextureFormat. It is initially cleared to a depth of 1
+// and a stencil value of 0.
+func (t *Texture) DepthTexture(ctx *Context) *Texture {
+	root := t.root
+
+	if root.depth == nil {
+		root.depth = createDepthStencilTexture(ctx, root.region.Width(), root.region.Height(), root.sampleCount)
+		clearDepthTexture(ctx, root.depth)
+	}
+
+	return root.depth
+}
+
+// clearDepthTexture resets the depth to 1 and the stencil value to 0.
+func clearDepthTexture(ctx *Context, depth *Texture) {
+	enc := ctx.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{Label: "ClearDepthTexture"})
+	defer enc.Release()
+
+	enc.BeginRenderPass(&wgpu.RenderPassDescriptor{
+		Label:                  "ClearDepthTexture",
+		DepthStencilAttachment: depth.DepthAttachment(wgpu.LoadOpClear),
+	}).End()
+
+	buf := enc.Finish(nil)
+	defer buf.Release()
+
+	ctx.Submit(buf)
+}
+
+// DepthAttachment returns a depth stencil attachment for this depth texture. When the
+// attachment is cleared, the depth is reset to 1 and the stencil value to 0.
+func (t *Texture) DepthAttachment(loadOp wgpu.LoadOp) *wgpu.RenderPassDepthStencilAttachment {
+	return &wgpu.RenderPassDepthStencilAttachment{
+		View:            t.textureView,
+		DepthLoadOp:     loadOp,
+		DepthStoreOp:    wgpu.StoreOpStore,
+		DepthClearValue: 1.0,
+
+		StencilLoadOp:  loadOp,
+		StencilStoreOp: wgpu.StoreOpStore,
+	}
+}
+
 func (t *Texture) RenderViews() (view, resolveView *wgpu.TextureView) {
 	view = t.textureView
 
+	if t.renderView != nil {
+		view = t.renderView
+	}
+
 	if t.sampleCount > 1 {
 		resolveView = t.resolveTarget.textureView
 	}
@@ -347,10 +468,25 @@ func DecodeTextureFromMemory(ctx *Context, buf []byte, srgb bool) (*Texture, err
 
 // NewTextureFromImage creates a new Texture from the given golang image.Image instance.
 func NewTextureFromImage(ctx *Context, src image.Image, srgb bool) *Texture {
+	return newTextureFromImage(ctx, src, srgb, 1)
+}
+
+// NewMipmappedTextureFromImage creates a new Texture with a full mip chain from
+// the given golang image.Image instance. The mip levels are generated on the gpu.
+func NewMipmappedTextureFromImage(ctx *Context, src image.Image, srgb bool) *Texture {
+	iw, ih := src.Bounds().Dx(), src.Bounds().Dy()
+
+	t := newTextureFromImage(ctx, src, srgb, MaxMipLevels(uint32(iw), uint32(ih)))
+	t.GenerateMipmaps(ctx)
+
+	return t
+}
+
+func newTextureFromImage(ctx *Context, src image.Image, srgb bool, mipLevels uint32) *Texture {
 	iw, ih := src.Bounds().Dx(), src.Bounds().Dy()
 	rgba := image.NewNRGBA(image.Rect(0, 0, iw, ih))
 
-	draw.Draw(rgba, rgba.Bounds(), src, image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
 
 	format := wgpu.TextureFormatRGBA8Unorm
 	if srgb {
@@ -358,10 +494,11 @@ func NewTextureFromImage(ctx *Context, src image.Image, srgb bool) *Texture {
 	}
 
 	t := NewTexture(ctx, NewTextureOptions{
-		Format: format,
-		Width:  uint32(iw),
-		Height: uint32(ih),
-		Label:  "TexFromImage",
+		Format:    format,
+		Width:     uint32(iw),
+		Height:    uint32(ih),
+		Label:     "TexFromImage",
+		MipLevels: mipLevels,
 	})
 
 	t.WritePixels(ctx, rgba.Pix)
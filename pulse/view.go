@@ -1,6 +1,8 @@
 package pulse
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/oliverbestmann/webgpu/wgpu"
@@ -18,6 +20,9 @@ type View struct {
 	// has the same sampleCount as the surface itself
 	depthTexture *Texture
 
+	// the surface texture of the previous frame, see SurfaceAsTexture
+	surfaceTexture *Texture
+
 	sampleCount uint32
 
 	// true if depth is enabled
@@ -25,6 +30,10 @@ type View struct {
 }
 
 func NewView(dev *Context, msaa bool, depth bool) *View {
+	if dev.Headless() {
+		panic("can not create a View for a headless Context")
+	}
+
 	st := &View{Context: dev, depth: depth}
 
 	if msaa {
@@ -53,6 +62,37 @@ func NewView(dev *Context, msaa bool, depth bool) *View {
 	return st
 }
 
+// ErrSurfaceUnavailable is returned by CurrentTexture if the surface did not provide
+// a texture, e.g. because acquiring it timed out, or the surface is outdated or lost.
+var ErrSurfaceUnavailable = errors.New("surface texture not available")
+
+// CurrentTexture acquires the next texture of the surface to render to. If no texture is
+// available, the surface is reconfigured and an error wrapping ErrSurfaceUnavailable is
+// returned. The caller should skip the current frame and try again with the next one.
+func (vs *View) CurrentTexture() (*wgpu.Texture, error) {
+	texture, err := vs.Surface.TryGetCurrentTexture()
+	if err != nil {
+		if isDeviceLostError(err) {
+			vs.NotifyDeviceLost(wgpu.DeviceLostReasonUnknown, err.Error())
+		}
+
+		if vs.DeviceLost() {
+			// the surface can not be configured until the device is recreated
+			return nil, fmt.Errorf("%w: %w", ErrSurfaceUnavailable, err)
+		}
+
+		slog.Warn("Failed to get current surface texture, reconfigure surface", slog.Any("err", err))
+
+		if vs.surfaceConfig.Width > 0 && vs.surfaceConfig.Height > 0 {
+			vs.Configure(vs.surfaceConfig.Width, vs.surfaceConfig.Height)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrSurfaceUnavailable, err)
+	}
+
+	return texture, nil
+}
+
 func (vs *View) MSAA() bool {
 	return vs.sampleCount > 1
 }
@@ -61,29 +101,57 @@ func (vs *View) Depth() bool {
 	return vs.depth
 }
 
+// SurfaceAsTexture wraps the current surface texture into a root texture to render to.
+// The depth texture of the view is attached to the returned texture and cleared. If
+// there is none, a depth texture created on the returned texture using DepthTexture
+// is kept by the view and attached to the surface texture of the following frames.
 func (vs *View) SurfaceAsTexture(screen *wgpu.Texture, screenView *wgpu.TextureView) *Texture {
+	if vs.depthTexture == nil && vs.surfaceTexture != nil {
+		vs.depthTexture = vs.surfaceTexture.depth
+	}
+
 	screenTexture := WrapTexture(screen, WrapTextureOptions{
 		TextureView:       screenView,
 		TextureViewFormat: wgpu.TextureFormatBGRA8UnormSrgb,
 	})
 
+	texture := screenTexture
+
 	if vs.MSAA() {
-		return WrapTexture(vs.msaaTexture.texture, WrapTextureOptions{
+		texture = WrapTexture(vs.msaaTexture.texture, WrapTextureOptions{
 			TextureViewFormat: wgpu.TextureFormatBGRA8UnormSrgb,
 			TextureView:       vs.msaaTexture.textureView,
 			ResolveTarget:     screenTexture,
 		})
-	} else {
-		return screenTexture
 	}
+
+	if vs.depthTexture != nil {
+		// each frame starts with a cleared depth texture, like a new surface texture
+		texture.depth = vs.depthTexture
+		clearDepthTexture(vs.Context, vs.depthTexture)
+	}
+
+	vs.surfaceTexture = texture
+
+	return texture
 }
 
 func (vs *View) ReleaseTexture() {
+	// a depth texture created on the surface texture of the previous frame
+	if st := vs.surfaceTexture; st != nil && st.depth != nil && st.depth != vs.depthTexture {
+		st.depth.Release()
+	}
+
+	vs.surfaceTexture = nil
+
 	if vs.depthTexture != nil {
 		vs.depthTexture.Release()
+		vs.depthTexture = nil
 	}
+
 	if vs.msaaTexture != nil {
 		vs.msaaTexture.Release()
+		vs.msaaTexture = nil
 	}
 }
 
@@ -97,7 +165,7 @@ func (vs *View) Configure(width, height uint32) {
 
 	// create depth texture
 	if vs.depth {
-		vs.depthTexture = createDepthTexture(vs.Context, width, height, vs.sampleCount)
+		vs.depthTexture = createDepthStencilTexture(vs.Context, width, height, vs.sampleCount)
 	}
 
 	if vs.MSAA() {
@@ -122,7 +190,7 @@ func createMultisampleTexture(ctx *Context, surfaceConfig *wgpu.SurfaceConfigura
 	})
 }
 
-func createDepthTexture(ctx *Context, width, height, sampleCount uint32) *Texture {
+func createDepthStencilTexture(ctx *Context, width, height, sampleCount uint32) *Texture {
 	return NewTextureFromDesc(ctx, &wgpu.TextureDescriptor{
 		Label:     "DepthTexture",
 		Usage:     wgpu.TextureUsageRenderAttachment | wgpu.TextureUsageTextureBinding,
@@ -132,7 +200,7 @@ func createDepthTexture(ctx *Context, width, height, sampleCount uint32) *Textur
 			Height:             height,
 			DepthOrArrayLayers: 1,
 		},
-		Format:        wgpu.TextureFormatDepth32Float,
+		Format:        DepthTextureFormat,
 		MipLevelCount: 1,
 		SampleCount:   sampleCount,
 	})